@@ -0,0 +1,68 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// ErrCodecNotFound is returned when no codec can be picked for
+// encoding or decoding.
+var ErrCodecNotFound = errors.New("codec not found")
+
+var extCodecs = struct {
+	sync.RWMutex
+	m map[string]func() Codec
+}{m: map[string]func() Codec{
+	"json": func() Codec { return &jsonCodec{} },
+}}
+
+// RegisterCodec binds a file extension to a codec getter, so that
+// [storeS.SaveAs] can pick a codec by the extension of the output
+// file.
+//
+// The extension is case-insensitive and the leading dot is
+// optional. A simple json codec has been registered for "json"
+// by default.
+//
+// Importing `github.com/hedzr/store/codecs/all` registers all
+// known codecs.
+func RegisterCodec(ext string, getter func() Codec) {
+	extCodecs.Lock()
+	defer extCodecs.Unlock()
+	extCodecs.m[normalizeExt(ext)] = getter
+}
+
+// DeregisterCodec removes the codec getter bound to an extension.
+func DeregisterCodec(ext string) {
+	extCodecs.Lock()
+	defer extCodecs.Unlock()
+	delete(extCodecs.m, normalizeExt(ext))
+}
+
+// CodecByExt returns the codec getter bound to an extension.
+func CodecByExt(ext string) (getter func() Codec, exists bool) {
+	extCodecs.RLock()
+	defer extCodecs.RUnlock()
+	getter, exists = extCodecs.m[normalizeExt(ext)]
+	return
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// jsonCodec is the builtin fallback codec, just like what
+// Loader.tryLoad does while no codec specified.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(m map[string]any) (data []byte, err error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func (jsonCodec) Unmarshal(b []byte) (data map[string]any, err error) {
+	err = json.Unmarshal(b, &data)
+	return
+}
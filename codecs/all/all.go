@@ -27,12 +27,24 @@ var suffixCodecMap = map[string]func() store.Codec{
 	"":           func() store.Codec { return nestext.New() },
 }
 
+func init() {
+	for ext, getter := range suffixCodecMap {
+		if ext != "" {
+			store.RegisterCodec(ext, getter)
+		}
+	}
+}
+
+// Register binds a codec getter to ext, for both this package
+// and [store.SaveAs].
 func Register(ext string, getter func() store.Codec) {
 	suffixCodecMap[ext] = getter
+	store.RegisterCodec(ext, getter)
 }
 
 func Deregister(ext string) {
 	delete(suffixCodecMap, ext)
+	store.DeregisterCodec(ext)
 }
 
 func ExtCodecMap() map[string]func() store.Codec { return suffixCodecMap }
@@ -0,0 +1,455 @@
+// Package encrypted provides a store.Codec decorator for the
+// sops-style encrypted leaves, such as:
+//
+//	db:
+//	  host: 10.0.0.1
+//	  password: ENC[AES256_GCM,data:7Vx1Hw==,iv:...,tag:...,type:str]
+//
+// Wrap a codec to decrypt the leaves on loading, and encrypt
+// them again on saving:
+//
+//	codec := encrypted.Wrap(yaml.New(), encrypted.KeyEnv("STORE_KEY"),
+//	    encrypted.WithPaths("**.password"))
+//	err := conf.Load(ctx, store.WithCodec(codec), store.WithProvider(file.New("app.yml")))
+//
+// The values can be encrypted by Encrypt, the data key is a
+// 32 bytes AES-256 key. Like sops, the dotted path of a leaf is
+// bound to its ciphertext as the additional data, so a value
+// moved to another key cannot be decrypted.
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store"
+)
+
+// Wrap decorates a codec.
+//
+// Unmarshal decrypts every ENC[AES256_GCM,...] string in the
+// decoded data. Marshal encrypts the leaves which were encrypted
+// on Unmarshal, and the leaves matching the patterns set by
+// WithPaths.
+//
+// The data may be marshaled at another position than it was
+// decoded, such as the whole store holding it under the prefix
+// of store.WithStorePrefix. So a leaf encrypted on Unmarshal is
+// matched by the tail of its path, it fails closed: a few more
+// leaves may be encrypted, but never fewer.
+func Wrap(codec store.Codec, keyring Keyring, opts ...Opt) store.Codec {
+	s := &codecS{
+		Codec:     codec,
+		keyring:   keyring,
+		encrypted: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type Opt func(s *codecS)
+
+// WithPaths sets the patterns of the dotted paths which should be
+// encrypted on Marshal. A '*' matches a segment of path, and a
+// '**' matches any number of segments, such as "db.password",
+// "*.token" and "**.secret".
+func WithPaths(patterns ...string) Opt {
+	return func(s *codecS) {
+		s.patterns = append(s.patterns, patterns...)
+	}
+}
+
+type codecS struct {
+	store.Codec
+	keyring  Keyring
+	patterns []string
+
+	mu        sync.Mutex
+	encrypted map[string]bool // the paths encrypted on input, relative to the decoded data
+}
+
+var _ store.Codec = (*codecS)(nil)
+
+// Unmarshal decodes b by the wrapped codec, and decrypts the
+// encrypted leaves.
+func (s *codecS) Unmarshal(b []byte) (data map[string]any, err error) {
+	if data, err = s.Codec.Unmarshal(b); err != nil {
+		return
+	}
+	var key []byte
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = walk(data, "", func(path string, v any) (ret any, err error) {
+		str, ok := v.(string)
+		if !ok || !IsEncrypted(str) {
+			return v, nil
+		}
+		if key == nil {
+			if key, err = s.keyring.Key(); err != nil {
+				return v, err
+			}
+		}
+		if ret, err = decrypt(key, path, str); err != nil {
+			return v, errors.New("cannot decrypt %q", path).WithErrors(err)
+		}
+		s.encrypted[path] = true
+		return
+	})
+	return
+}
+
+// Marshal encrypts the leaves, and encodes m by the wrapped codec.
+// m is not modified.
+func (s *codecS) Marshal(m map[string]any) (data []byte, err error) {
+	var key []byte
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m = copyMap(m)
+	err = walk(m, "", func(path string, v any) (ret any, err error) {
+		if str, ok := v.(string); ok && IsEncrypted(str) {
+			return v, nil
+		}
+		if !s.wasEncrypted(path) && !s.matches(path) {
+			return v, nil
+		}
+		if key == nil {
+			if key, err = s.keyring.Key(); err != nil {
+				return v, err
+			}
+		}
+		if ret, err = encrypt(key, path, v); err != nil {
+			return v, errors.New("cannot encrypt %q", path).WithErrors(err)
+		}
+		return
+	})
+	if err == nil {
+		data, err = s.Codec.Marshal(m)
+	}
+	return
+}
+
+// wasEncrypted reports whether the leaf at path was encrypted on
+// Unmarshal. A path matches a recorded one if either of them ends
+// with the other at a segment boundary.
+func (s *codecS) wasEncrypted(path string) bool {
+	if s.encrypted[path] {
+		return true
+	}
+	for p := range s.encrypted {
+		if strings.HasSuffix(path, "."+p) || strings.HasSuffix(p, "."+path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *codecS) matches(path string) bool {
+	for _, p := range s.patterns {
+		if match(strings.Split(p, "."), strings.Split(path, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+func match(pattern, parts []string) bool {
+	for i, p := range pattern {
+		if p == "**" {
+			for j := len(parts); j >= i; j-- {
+				if match(pattern[i+1:], parts[j:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if i >= len(parts) || (p != "*" && p != parts[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(parts)
+}
+
+// walk calls fn for each leaf in m, and replaces the leaf with
+// the returned value. The elements of a slice are the leaves
+// with the index as the last segment of path.
+func walk(m map[string]any, prefix string, fn func(path string, v any) (any, error)) (err error) {
+	for k, v := range m {
+		if m[k], err = walkValue(v, joinPath(prefix, k), fn); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// walkAny is walk for the map[any]any decoded by some codecs,
+// such as yaml.v2.
+func walkAny(m map[any]any, prefix string, fn func(path string, v any) (any, error)) (err error) {
+	for k, v := range m {
+		if m[k], err = walkValue(v, joinPath(prefix, fmt.Sprint(k)), fn); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func walkValue(v any, path string, fn func(path string, v any) (any, error)) (ret any, err error) {
+	switch vv := v.(type) {
+	case map[string]any:
+		err = walk(vv, path, fn)
+		return vv, err
+	case map[any]any:
+		err = walkAny(vv, path, fn)
+		return vv, err
+	case []any:
+		for i, it := range vv {
+			if vv[i], err = walkValue(it, path+"."+strconv.Itoa(i), fn); err != nil {
+				break
+			}
+		}
+		return vv, err
+	}
+	return fn(path, v)
+}
+
+func copyMap(m map[string]any) map[string]any {
+	r := make(map[string]any, len(m))
+	for k, v := range m {
+		r[k] = copyValue(v)
+	}
+	return r
+}
+
+func copyValue(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		return copyMap(vv)
+	case map[any]any:
+		r := make(map[any]any, len(vv))
+		for k, it := range vv {
+			r[k] = copyValue(it)
+		}
+		return r
+	case []any:
+		a := make([]any, len(vv))
+		for i, it := range vv {
+			a[i] = copyValue(it)
+		}
+		return a
+	}
+	return v
+}
+
+//
+
+// Keyring provides the data key for the encryption.
+type Keyring interface {
+	Key() (key []byte, err error)
+}
+
+// KeyringFunc is a functional Keyring.
+type KeyringFunc func() (key []byte, err error)
+
+func (f KeyringFunc) Key() (key []byte, err error) { return f() }
+
+// ErrNoKey is returned if a Keyring has no key.
+var ErrNoKey = errors.New("encryption key not found")
+
+// KeyBytes returns a Keyring with a fixed key.
+func KeyBytes(key []byte) Keyring {
+	return KeyringFunc(func() ([]byte, error) { return parseKey(key) })
+}
+
+// KeyFile returns a Keyring which reads the key from a local file.
+// The file holds 32 bytes raw key, or its base64 or hex form.
+func KeyFile(path string) Keyring {
+	return KeyringFunc(func() (key []byte, err error) {
+		var b []byte
+		if b, err = os.ReadFile(path); err != nil {
+			return nil, errors.New("cannot read key file %q", path).WithErrors(err, ErrNoKey)
+		}
+		return parseKey(b)
+	})
+}
+
+// KeyEnv returns a Keyring which reads the key from an environment
+// variable, in base64 or hex form.
+func KeyEnv(name string) Keyring {
+	return KeyringFunc(func() (key []byte, err error) {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, errors.New("env var %q not found", name).WithErrors(ErrNoKey)
+		}
+		return parseKey([]byte(v))
+	})
+}
+
+// FirstOf returns a Keyring which tries the keyrings in order, and
+// returns the first key found.
+func FirstOf(keyrings ...Keyring) Keyring {
+	return KeyringFunc(func() (key []byte, err error) {
+		for _, k := range keyrings {
+			if key, err = k.Key(); err == nil {
+				return
+			}
+		}
+		if err == nil {
+			err = ErrNoKey
+		}
+		return
+	})
+}
+
+func parseKey(b []byte) (key []byte, err error) {
+	if len(b) == 32 {
+		return b, nil
+	}
+	text := strings.TrimSpace(string(b))
+	if key, err = base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return
+	}
+	if key, err = hex.DecodeString(text); err == nil && len(key) == 32 {
+		return
+	}
+	return nil, errors.New("invalid key, want 32 bytes AES-256 key, or its base64 or hex form")
+}
+
+//
+
+const (
+	encPrefix = "ENC[AES256_GCM,"
+	encSuffix = "]"
+)
+
+// IsEncrypted reports whether str is an ENC[AES256_GCM,...] value.
+func IsEncrypted(str string) bool {
+	return strings.HasPrefix(str, encPrefix) && strings.HasSuffix(str, encSuffix)
+}
+
+// Encrypt encrypts a value to an ENC[AES256_GCM,...] string for
+// the leaf at the dotted path, such as "db.password". The value
+// can be a string, bool, integer or float, its type will be
+// restored on decryption.
+func Encrypt(keyring Keyring, path string, v any) (str string, err error) {
+	var key []byte
+	if key, err = keyring.Key(); err == nil {
+		str, err = encrypt(key, path, v)
+	}
+	return
+}
+
+// Decrypt decrypts an ENC[AES256_GCM,...] string of the leaf at
+// the dotted path, it fails if the value was encrypted for
+// another path.
+func Decrypt(keyring Keyring, path, str string) (v any, err error) {
+	var key []byte
+	if key, err = keyring.Key(); err == nil {
+		v, err = decrypt(key, path, str)
+	}
+	return
+}
+
+func encrypt(key []byte, path string, v any) (str string, err error) {
+	var plain, typ string
+	switch vv := v.(type) {
+	case string:
+		plain, typ = vv, "str"
+	case bool:
+		plain, typ = strconv.FormatBool(vv), "bool"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		plain, typ = fmt.Sprint(vv), "int"
+	case float32, float64:
+		plain, typ = fmt.Sprint(vv), "float"
+	default:
+		return "", errors.New("cannot encrypt the value of type %T", v)
+	}
+
+	var gcm cipher.AEAD
+	if gcm, err = newGCM(key); err != nil {
+		return
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return
+	}
+	sealed := gcm.Seal(nil, iv, []byte(plain), []byte(path))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("%sdata:%s,iv:%s,tag:%s,type:%s%s", encPrefix, enc(data), enc(iv), enc(tag), typ, encSuffix), nil
+}
+
+func decrypt(key []byte, path, str string) (v any, err error) {
+	if !IsEncrypted(str) {
+		return nil, errors.New("not an encrypted value")
+	}
+	fields := make(map[string]string)
+	for _, f := range strings.Split(str[len(encPrefix):len(str)-len(encSuffix)], ",") {
+		if k, val, ok := strings.Cut(f, ":"); ok {
+			fields[k] = val
+		}
+	}
+
+	var data, iv, tag []byte
+	dec := base64.StdEncoding.DecodeString
+	if data, err = dec(fields["data"]); err != nil {
+		return
+	}
+	if iv, err = dec(fields["iv"]); err != nil {
+		return
+	}
+	if tag, err = dec(fields["tag"]); err != nil {
+		return
+	}
+
+	var gcm cipher.AEAD
+	if gcm, err = newGCM(key); err != nil {
+		return
+	}
+	if len(iv) != gcm.NonceSize() {
+		return nil, errors.New("invalid iv size %d", len(iv))
+	}
+	var plain []byte
+	if plain, err = gcm.Open(nil, iv, append(data, tag...), []byte(path)); err != nil {
+		return
+	}
+
+	switch text := string(plain); fields["type"] {
+	case "bool":
+		return strconv.ParseBool(text)
+	case "int":
+		var i int64
+		i, err = strconv.ParseInt(text, 10, 64)
+		return int(i), err
+	case "float":
+		return strconv.ParseFloat(text, 64)
+	default:
+		return text, nil
+	}
+}
+
+func newGCM(key []byte) (gcm cipher.AEAD, err error) {
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err == nil {
+		gcm, err = cipher.NewGCM(block)
+	}
+	return
+}
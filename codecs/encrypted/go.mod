@@ -0,0 +1,33 @@
+module github.com/hedzr/store/codecs/encrypted
+
+go 1.26
+
+// replace gopkg.in/hedzr/errors.v3 => ../../../../24/libs.errors
+
+// replace github.com/hedzr/evendeep => ../../../libs.diff
+
+// replace github.com/hedzr/go-errors/v2 => ../../../libs.errors
+
+// replace github.com/hedzr/env => ../../../libs.env
+
+// replace github.com/hedzr/is => ../../../libs.is
+
+// replace github.com/hedzr/logg => ../../../libs.logg
+
+replace github.com/hedzr/store => ../..
+
+// replace github.com/hedzr/store/providers/file => ../../providers/file
+
+require (
+	github.com/hedzr/store v1.4.3
+	gopkg.in/hedzr/errors.v3 v3.3.5
+)
+
+require (
+	github.com/hedzr/evendeep v1.4.3 // indirect
+	github.com/hedzr/is v0.9.3 // indirect
+	github.com/hedzr/logg v0.9.3 // indirect
+	golang.org/x/net v0.56.0 // indirect
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/term v0.44.0 // indirect
+)
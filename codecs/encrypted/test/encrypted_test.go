@@ -0,0 +1,142 @@
+package test_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hedzr/store"
+	"github.com/hedzr/store/codecs/encrypted"
+	"github.com/hedzr/store/codecs/json"
+	"github.com/hedzr/store/providers/file"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func TestEncrypt(t *testing.T) {
+	keyring := encrypted.KeyBytes([]byte(testKey))
+	for _, v := range []any{"p@ss", 5432, 1.5, true} {
+		str, err := encrypted.Encrypt(keyring, "db.v", v)
+		assert.NoError(t, err)
+		assert.True(t, encrypted.IsEncrypted(str), str)
+		back, err := encrypted.Decrypt(keyring, "db.v", str)
+		assert.NoError(t, err)
+		assert.Equal(t, v, back)
+	}
+
+	str, _ := encrypted.Encrypt(keyring, "db.password", "p@ss")
+	_, err := encrypted.Decrypt(encrypted.KeyBytes([]byte(strings.Repeat("x", 32))), "db.password", str)
+	assert.Error(t, err, "wrong key")
+	_, err = encrypted.Decrypt(keyring, "app.password", str)
+	assert.Error(t, err, "the value is bound to its path")
+}
+
+func TestWrap(t *testing.T) {
+	t.Setenv("STORE_TEST_KEY", base64.StdEncoding.EncodeToString([]byte(testKey)))
+	keyring := encrypted.FirstOf(encrypted.KeyFile("/nonexistent"), encrypted.KeyEnv("STORE_TEST_KEY"))
+
+	password, _ := encrypted.Encrypt(keyring, "db.password", "p@ss")
+	port, _ := encrypted.Encrypt(keyring, "db.port", 5432)
+	src := `{"db":{"host":"10.0.0.1","password":"` + password + `","port":"` + port + `"},"app":{"token":"t0k3n"}}`
+	cfg := filepath.Join(t.TempDir(), "app.json")
+	assert.NoError(t, os.WriteFile(cfg, []byte(src), 0600))
+
+	codec := encrypted.Wrap(json.New(), keyring, encrypted.WithPaths("**.token"))
+	conf := store.New()
+	defer conf.Close()
+	_, err := conf.Load(context.TODO(),
+		store.WithCodec(codec),
+		store.WithProvider(file.New(cfg)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ss", conf.MustString("db.password"))
+	assert.Equal(t, 5432, conf.MustInt("db.port"))
+	assert.Equal(t, "10.0.0.1", conf.MustString("db.host"))
+
+	m := conf.MustM("", store.WithoutFlattenKeys[any](true))
+	b, err := codec.Marshal(m)
+	assert.NoError(t, err)
+	out := string(b)
+	assert.NotContains(t, out, "p@ss")
+	assert.NotContains(t, out, "t0k3n")
+	assert.Contains(t, out, "10.0.0.1")
+	assert.Equal(t, "p@ss", conf.MustString("db.password"), "m is not modified")
+
+	back, err := codec.Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "t0k3n", back["app"].(map[string]any)["token"])
+	assert.Equal(t, 5432, back["db"].(map[string]any)["port"])
+
+	_, err = encrypted.Wrap(json.New(), encrypted.KeyEnv("STORE_NO_KEY")).Unmarshal([]byte(src))
+	assert.ErrorIs(t, err, encrypted.ErrNoKey)
+
+	swapped := strings.NewReplacer(password, port, port, password).Replace(src)
+	_, err = encrypted.Wrap(json.New(), keyring).Unmarshal([]byte(swapped))
+	assert.Error(t, err, "the values swapped between keys cannot be decrypted")
+}
+
+func TestWrapWithStorePrefix(t *testing.T) {
+	keyring := encrypted.KeyBytes([]byte(testKey))
+	password, _ := encrypted.Encrypt(keyring, "db.password", "p@ss")
+	cfg := filepath.Join(t.TempDir(), "app.json")
+	assert.NoError(t, os.WriteFile(cfg, []byte(`{"db":{"host":"10.0.0.1","password":"`+password+`"}}`), 0600))
+
+	codec := encrypted.Wrap(json.New(), keyring)
+	conf := store.New()
+	defer conf.Close()
+	_, err := conf.Load(context.TODO(),
+		store.WithCodec(codec),
+		store.WithProvider(file.New(cfg)),
+		store.WithStorePrefix("ext"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ss", conf.MustString("ext.db.password"))
+
+	out := filepath.Join(t.TempDir(), "all.json")
+	assert.NoError(t, conf.SaveAs(context.TODO(), out, store.WithSaveAsCodec(codec)))
+	b, _ := os.ReadFile(out)
+	assert.NotContains(t, string(b), "p@ss")
+	assert.Contains(t, string(b), "10.0.0.1")
+
+	out = filepath.Join(t.TempDir(), "db.json")
+	assert.NoError(t, conf.SaveAs(context.TODO(), out, store.WithSaveAsCodec(codec), store.WithSaveAsPosition("ext.db")))
+	b, _ = os.ReadFile(out)
+	assert.NotContains(t, string(b), "p@ss")
+
+	back, err := codec.Unmarshal(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ss", back["password"])
+}
+
+// anyMapCodec decodes to the map[any]any, like yaml.v2 does.
+type anyMapCodec struct{ out map[string]any }
+
+func (c *anyMapCodec) Marshal(m map[string]any) (data []byte, err error) {
+	c.out = m
+	return []byte("{}"), nil
+}
+
+func (c *anyMapCodec) Unmarshal(b []byte) (data map[string]any, err error) {
+	return map[string]any{"db": map[any]any{"password": string(b)}}, nil
+}
+
+func TestWrapAnyMap(t *testing.T) {
+	keyring := encrypted.KeyBytes([]byte(testKey))
+	password, _ := encrypted.Encrypt(keyring, "db.password", "p@ss")
+
+	inner := &anyMapCodec{}
+	codec := encrypted.Wrap(inner, keyring)
+	m, err := codec.Unmarshal([]byte(password))
+	assert.NoError(t, err)
+	assert.Equal(t, "p@ss", m["db"].(map[any]any)["password"])
+
+	_, err = codec.Marshal(m)
+	assert.NoError(t, err)
+	assert.True(t, encrypted.IsEncrypted(inner.out["db"].(map[any]any)["password"].(string)))
+	assert.Equal(t, "p@ss", m["db"].(map[any]any)["password"], "m is not modified")
+}
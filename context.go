@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// ContextProvider is a Provider whose reading can be cancelled.
+//
+// If a provider implements it, ReadContext is used instead of
+// Read by Load.
+type ContextProvider interface {
+	ReadContext(ctx context.Context) (m map[string]ValPkg, err error) // return ErrNotImplemented as an identifier if it wants to be skipped
+}
+
+// ContextOnceProvider is a OnceProvider whose reading and writing
+// can be cancelled.
+//
+// If a provider implements it, ReadBytesContext and WriteContext
+// are used instead of ReadBytes and Write by Load, Save and
+// SaveAs.
+type ContextOnceProvider interface {
+	ReadBytesContext(ctx context.Context) (data []byte, err error) // return ErrNotImplemented as an identifier if it wants to be skipped
+	WriteContext(ctx context.Context, data []byte) (err error)     // return ErrNotImplemented as an identifier if it wants to be skipped
+}
+
+// WithTimeout sets the timeout for the loading and saving of the
+// provider. Load returns an error wrapping
+// context.DeadlineExceeded if the provider isn't responding in
+// time.
+//
+// The watching started by Load isn't affected by the timeout.
+func WithTimeout(timeout time.Duration) LoadOpt {
+	return func(s *Loader) {
+		s.timeout = timeout
+	}
+}
+
+// withTimeout derives a context with the loader's timeout.
+func (s *Loader) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(ctx, s.timeout)
+	}
+	return ctx, func() {}
+}
+
+// callContext runs fn and returns its result, or returns the error
+// of ctx once ctx is done.
+//
+// It's for the providers which are not context-aware. fn keeps
+// running in background after ctx done, its result is dropped.
+func callContext[T any](ctx context.Context, what string, fn func() (T, error)) (ret T, err error) {
+	if err = ctx.Err(); err != nil {
+		return ret, errors.New("%s cancelled", what).WithErrors(err)
+	}
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	type result struct {
+		ret T
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		r, e := fn()
+		ch <- result{r, e}
+	}()
+	select {
+	case r := <-ch:
+		return r.ret, r.err
+	case <-ctx.Done():
+		return ret, errors.New("%s cancelled", what).WithErrors(ctx.Err())
+	}
+}
+
+func readProvider(ctx context.Context, p Provider) (m map[string]ValPkg, err error) {
+	if cp, ok := p.(ContextProvider); ok {
+		return cp.ReadContext(ctx)
+	}
+	return callContext(ctx, "reading", p.Read)
+}
+
+func readProviderBytes(ctx context.Context, p OnceProvider) (data []byte, err error) {
+	if cp, ok := p.(ContextOnceProvider); ok {
+		return cp.ReadBytesContext(ctx)
+	}
+	return callContext(ctx, "reading", p.ReadBytes)
+}
+
+// writeProvider writes data by a OnceProvider, or an io.Writer.
+func writeProvider(ctx context.Context, p Provider, data []byte) (err error) {
+	switch fp := p.(type) {
+	case ContextOnceProvider:
+		err = fp.WriteContext(ctx, data)
+	case OnceProvider:
+		_, err = callContext(ctx, "writing", func() (struct{}, error) { return struct{}{}, fp.Write(data) })
+	default:
+		err = ErrNotImplemented
+	}
+
+	if errors.Is(err, ErrNotImplemented) {
+		if wr, ok := p.(io.Writer); ok {
+			_, err = callContext(ctx, "writing", func() (int, error) { return wr.Write(data) })
+		}
+	}
+	return
+}
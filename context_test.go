@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// hangingS is a provider which never responds, like an unreachable
+// remote KV.
+type hangingS struct{ watchableS }
+
+func (s *hangingS) Read() (m map[string]ValPkg, err error) { select {} }
+
+// ctxAwareS is a context-aware provider.
+type ctxAwareS struct {
+	watchableS
+	deadline bool
+}
+
+func (s *ctxAwareS) ReadBytesContext(ctx context.Context) (data []byte, err error) {
+	_, s.deadline = ctx.Deadline()
+	return s.ReadBytes()
+}
+func (s *ctxAwareS) WriteContext(ctx context.Context, data []byte) (err error) { return ctx.Err() }
+
+func TestStoreS_LoadTimeout(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+
+	start := time.Now()
+	_, err := conf.Load(context.TODO(), WithProvider(&hangingS{}), WithTimeout(50*time.Millisecond))
+	assertTrue(t, errors.Is(err, context.DeadlineExceeded), "expecting deadline exceeded")
+	assertTrue(t, time.Since(start) < time.Second, "Load must not hang")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = conf.Load(ctx, WithProvider(&hangingS{}))
+	assertTrue(t, errors.Is(err, context.Canceled), "expecting canceled")
+
+	src := &ctxAwareS{watchableS: watchableS{data: `{"app":{"debug":true}}`}}
+	_, err = conf.Load(context.TODO(), WithProvider(src), WithTimeout(time.Second))
+	assertEqual(t, nil, err)
+	assertTrue(t, src.deadline, "ReadBytesContext is preferred")
+	assertTrue(t, conf.MustBool("app.debug"))
+
+	err = conf.SaveAs(ctx, "", WithSaveAsProvider(src), WithSaveAsCodec(jsonCodec{}))
+	assertTrue(t, errors.Is(err, context.Canceled), "expecting canceled")
+}
@@ -0,0 +1,408 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// Delta is a difference of a leaf between two stores.
+//
+// Op can be OpCreate (the leaf was added), OpWrite (the value
+// was changed) or OpRemove (the leaf was removed). OpRename is
+// reported by [Store.Move] and [Store.Rename], the leaf was moved
+// from OldPath to Path.
+//
+// Path is relative to the prefix of the store, so a diff taken
+// from one store can be applied to another one.
+type Delta struct {
+	Op       Op
+	Path     string
+	OldPath  string // the source path of OpRename
+	OldValue any
+	NewValue any
+}
+
+func (d Delta) String() string {
+	var sb strings.Builder
+	_, _ = sb.WriteString(opStrings[d.Op])
+	_, _ = sb.WriteString(" ")
+	_, _ = sb.WriteString(d.Path)
+	switch d.Op {
+	case OpCreate:
+		_, _ = sb.WriteString(": ")
+		_, _ = sb.WriteString(fmt.Sprint(d.NewValue))
+	case OpWrite:
+		_, _ = sb.WriteString(": ")
+		_, _ = sb.WriteString(fmt.Sprint(d.OldValue))
+		_, _ = sb.WriteString(" => ")
+		_, _ = sb.WriteString(fmt.Sprint(d.NewValue))
+	case OpRename:
+		_, _ = sb.WriteString(" <= ")
+		_, _ = sb.WriteString(d.OldPath)
+	}
+	return sb.String()
+}
+
+// Diff compares the subtrees at 'path' of two stores and
+// returns the added, removed and changed leaves, sorted by path.
+//
+// Diff(a, b, "") compares the whole trees. The returned deltas
+// transform 'a' to 'b', by [Store.Apply].
+//
+// The values in deltas are not copied, don't modify them.
+func Diff(a, b Store, path string) (deltas []Delta) {
+	return diffLeaves(collectLeaves(a, path), collectLeaves(b, path))
+}
+
+func diffLeaves(la, lb map[string]any) (deltas []Delta) {
+	for k, va := range la {
+		if vb, ok := lb[k]; !ok {
+			deltas = append(deltas, Delta{Op: OpRemove, Path: k, OldValue: va})
+		} else if !reflect.DeepEqual(va, vb) {
+			deltas = append(deltas, Delta{Op: OpWrite, Path: k, OldValue: va, NewValue: vb})
+		}
+	}
+	for k, vb := range lb {
+		if _, ok := la[k]; !ok {
+			deltas = append(deltas, Delta{Op: OpCreate, Path: k, NewValue: vb})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Path < deltas[j].Path })
+	return
+}
+
+// collectLeaves returns the leaves under 'path' as a flatten map.
+// The keys are relative to the prefix of store.
+func collectLeaves(s Store, path string) (ret map[string]any) {
+	ret = make(map[string]any)
+	prefix := s.Prefix()
+	if prefix != "" {
+		prefix += string(s.Delimiter())
+	}
+	for key, val := range s.Leaves(path) {
+		ret[strings.TrimPrefix(key, prefix)] = val
+	}
+	return
+}
+
+func joinPath(delim string, parts ...string) string {
+	var a []string
+	for _, p := range parts {
+		if p != "" {
+			a = append(a, p)
+		}
+	}
+	return strings.Join(a, delim)
+}
+
+// Apply replays the deltas, which generally come from [Diff].
+//
+// The change handlers are triggered as you call Set and Remove.
+// If a key cannot be written by the view, see ReadOnly and Freeze,
+// nothing is applied and a *ReadOnlyError is returned.
+func (s *storeS) Apply(deltas []Delta) (err error) {
+	if err = s.checkDeltas(deltas); err != nil {
+		return
+	}
+	for _, d := range deltas {
+		switch d.Op {
+		case OpCreate, OpWrite:
+			s.Set(d.Path, d.NewValue)
+		case OpRemove:
+			s.Remove(d.Path)
+		case OpRename:
+			if err = s.Move(d.OldPath, d.Path); err != nil {
+				return
+			}
+		default:
+			return errors.New("unsupported delta op %v at %q", d.Op, d.Path)
+		}
+	}
+	return
+}
+
+// checkDeltas tests if the keys changed by the deltas can be
+// written by the view.
+func (s *storeS) checkDeltas(deltas []Delta) (err error) {
+	for _, d := range deltas {
+		switch d.Op {
+		case OpCreate, OpWrite:
+			err = s.checkWritable("set", d.Path, false)
+		case OpRemove:
+			err = s.checkWritable("remove", d.Path, true)
+		case OpRename:
+			if err = s.checkWritable("move", d.OldPath, true); err == nil {
+				err = s.checkWritable("move", d.Path, true)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+
+// jsonPatchOp is an operation of RFC 6902 JSON Patch.
+type jsonPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps "value" for add, replace and test even if it's
+// null, RFC 6902 requires it for them.
+func (op jsonPatchOp) MarshalJSON() ([]byte, error) {
+	type plain jsonPatchOp // without MarshalJSON
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			plain
+			Value any `json:"value"`
+		}{plain(op), op.Value})
+	}
+	return json.Marshal(plain(op))
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func toJSONPointer(path string, delimiter rune) string {
+	var sb strings.Builder
+	for _, seg := range radix.SplitPath(path, delimiter) {
+		_ = sb.WriteByte('/')
+		_, _ = sb.WriteString(jsonPointerEscaper.Replace(seg))
+	}
+	return sb.String()
+}
+
+func fromJSONPointer(ptr string, delimiter rune) (path string, err error) {
+	if ptr == "" {
+		return
+	}
+	if ptr[0] != '/' {
+		return "", errors.New("invalid json pointer %q", ptr)
+	}
+	segs := strings.Split(ptr[1:], "/")
+	for i, seg := range segs {
+		segs[i] = radix.EscapeKey(jsonPointerUnescaper.Replace(seg), delimiter)
+	}
+	return strings.Join(segs, string(delimiter)), nil
+}
+
+// JSONPatch exports the deltas as a RFC 6902 JSON Patch
+// document.
+//
+// The 'delimiter' is the one of the store which the deltas come
+// from, it's used for splitting the paths to JSON pointers.
+func JSONPatch(deltas []Delta, delimiter rune) (data []byte, err error) {
+	ops := make([]jsonPatchOp, 0, len(deltas))
+	for _, d := range deltas {
+		op := jsonPatchOp{Path: toJSONPointer(d.Path, delimiter)}
+		switch d.Op {
+		case OpCreate:
+			op.Op, op.Value = "add", d.NewValue
+		case OpWrite:
+			op.Op, op.Value = "replace", d.NewValue
+		case OpRemove:
+			op.Op = "remove"
+		case OpRename:
+			op.Op, op.From = "move", toJSONPointer(d.OldPath, delimiter)
+		default:
+			return nil, errors.New("unsupported delta op %v at %q", d.Op, d.Path)
+		}
+		ops = append(ops, op)
+	}
+	return json.Marshal(ops)
+}
+
+// ApplyJSONPatch applies a RFC 6902 JSON Patch document to the
+// store.
+//
+// The operations add, remove, replace, move, copy and test are
+// supported. An object value is merged as a subtree.
+//
+// The operations are applied in order in a transaction, see
+// [Store.Tx]. If one fails, such as a test, the patch is discarded
+// as a whole and no handlers are fired. Don't apply it to the
+// 'tx' store inside Tx, the transactions aren't reentrant.
+func ApplyJSONPatch(s Store, patch []byte) (err error) {
+	var ops []jsonPatchOp
+	if err = json.Unmarshal(patch, &ops); err != nil {
+		return
+	}
+	return s.Tx(func(tx Store) error { return applyJSONPatch(tx, ops) })
+}
+
+func applyJSONPatch(s Store, ops []jsonPatchOp) (err error) {
+	d := s.Delimiter()
+	for i, op := range ops {
+		var path, from string
+		if path, err = fromJSONPointer(op.Path, d); err != nil {
+			return
+		}
+		switch op.Op {
+		case "add", "replace":
+			if op.Op == "replace" && !s.Has(path) {
+				return errors.New("json patch #%d: path %q not found", i, op.Path)
+			}
+			err = putValue(s, path, op.Value)
+		case "remove":
+			if !s.Remove(path) {
+				err = errors.New("json patch #%d: path %q not found", i, op.Path)
+			}
+		case "move", "copy":
+			if from, err = fromJSONPointer(op.From, d); err != nil {
+				return
+			}
+			var v any
+			if v, err = subtreeValue(s, from); err != nil {
+				return errors.New("json patch #%d: from %q not found", i, op.From)
+			}
+			if op.Op == "move" {
+				s.Remove(from)
+			}
+			err = putValue(s, path, v)
+		case "test":
+			var v any
+			if v, err = subtreeValue(s, path); err != nil {
+				return errors.New("json patch #%d: path %q not found", i, op.Path)
+			}
+			if !jsonEqual(v, op.Value) {
+				err = errors.New("json patch #%d: test failed at %q", i, op.Path)
+			}
+		default:
+			err = errors.New("json patch #%d: unsupported op %q", i, op.Op)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// putValue replaces the node at path with 'v'. A map value is
+// merged as a subtree.
+func putValue(s Store, path string, v any) (err error) {
+	if m, ok := v.(map[string]any); ok {
+		s.Remove(path)
+		return s.Merge(path, m)
+	}
+	if node, branch, _, found := s.Locate(path, nil); found && branch && node != nil && !node.HasData() {
+		s.Remove(path)
+	}
+	s.Set(path, v)
+	return
+}
+
+// subtreeValue returns the value of a leaf, or a nested map for
+// a branch.
+func subtreeValue(s Store, path string) (v any, err error) {
+	if !s.Has(path) {
+		return nil, errors.NotFound
+	}
+	if leaves := collectLeaves(s, path); len(leaves) == 1 {
+		if v, ok := leaves[path]; ok {
+			return v, nil
+		}
+	}
+	return s.GetM(path, WithoutFlattenKeys[any](true), WithRawValues[any](true))
+}
+
+func jsonEqual(a, b any) bool {
+	ja, err1 := json.Marshal(a)
+	jb, err2 := json.Marshal(b)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	var va, vb any
+	_ = json.Unmarshal(ja, &va)
+	_ = json.Unmarshal(jb, &vb)
+	return reflect.DeepEqual(va, vb)
+}
+
+// JSONMergePatch exports the deltas as a RFC 7386 JSON Merge
+// Patch document. A removed leaf is represented as null.
+func JSONMergePatch(deltas []Delta, delimiter rune) (data []byte, err error) {
+	m := make(map[string]any)
+	for _, d := range deltas {
+		keys := radix.SplitPath(d.Path, delimiter)
+		switch d.Op {
+		case OpCreate, OpWrite:
+			putMergePatch(m, keys, d.NewValue)
+		case OpRemove:
+			putMergePatch(m, keys, nil)
+		case OpRename:
+			putMergePatch(m, radix.SplitPath(d.OldPath, delimiter), nil)
+			putMergePatch(m, keys, d.NewValue)
+		default:
+			return nil, errors.New("unsupported delta op %v at %q", d.Op, d.Path)
+		}
+	}
+	var bb bytes.Buffer
+	enc := json.NewEncoder(&bb)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(m); err == nil {
+		data = bytes.TrimRight(bb.Bytes(), "\n")
+	}
+	return
+}
+
+func putMergePatch(m map[string]any, keys []string, v any) {
+	if len(keys) == 1 {
+		m[keys[0]] = v
+		return
+	}
+	sub, ok := m[keys[0]].(map[string]any)
+	if !ok {
+		sub = make(map[string]any)
+		m[keys[0]] = sub
+	}
+	putMergePatch(sub, keys[1:], v)
+}
+
+// ApplyJSONMergePatch applies a RFC 7386 JSON Merge Patch
+// document to the store. A null value removes the target key.
+//
+// To patch a subtree, apply it on a prefixed store:
+//
+//	err := store.ApplyJSONMergePatch(conf.WithPrefix("app"), patch)
+func ApplyJSONMergePatch(s Store, patch []byte) (err error) {
+	var m map[string]any
+	if err = json.Unmarshal(patch, &m); err != nil {
+		return
+	}
+	applyMergePatch(s, "", m)
+	return
+}
+
+// applyMergePatch applies m at path. The members of m are taken
+// literally, a member "a.b" is one key rather than a path, as
+// JSONMergePatch produces.
+func applyMergePatch(s Store, path string, m map[string]any) {
+	delim := string(s.Delimiter())
+	for k, v := range m {
+		key := joinPath(delim, path, radix.EscapeKey(k, s.Delimiter()))
+		switch vv := v.(type) {
+		case nil:
+			s.Remove(key)
+		case map[string]any:
+			if node, branch, _, found := s.Locate(key, nil); found && node != nil && !branch {
+				s.Remove(key) // a leaf is replaced by an object
+			}
+			applyMergePatch(s, key, vv)
+		default:
+			_ = putValue(s, key, v)
+		}
+	}
+}
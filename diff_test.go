@@ -0,0 +1,140 @@
+package store
+
+import (
+	"testing"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+func TestDiff(t *testing.T) {
+	a := newBasicStore()
+	b := a.Dup()
+	b.Set("app.server.start", 6)
+	b.Set("app.server.port", 7999)
+	b.Remove("app.debug")
+
+	deltas := Diff(a, b, "")
+	for _, d := range deltas {
+		t.Logf("%v", d)
+	}
+	assertEqual(t, []Delta{
+		{Op: OpRemove, Path: "app.debug", OldValue: false},
+		{Op: OpCreate, Path: "app.server.port", NewValue: 7999},
+		{Op: OpWrite, Path: "app.server.start", OldValue: 5, NewValue: 6},
+	}, deltas)
+
+	assertEqual(t, 0, len(Diff(a, b, "app.logging")))
+	assertEqual(t, 2, len(Diff(a.WithPrefix("app"), b.WithPrefix("app"), "server")))
+	assertEqual(t, "server.port", Diff(a.WithPrefix("app"), b.WithPrefix("app"), "server")[0].Path)
+
+	unfreeze := a.Freeze("app.debug")
+	assertTrue(t, errors.Is(a.Apply(deltas), ErrReadOnly), "the rejection is reported")
+	assertEqual(t, 3, len(Diff(a, b, "")), "nothing is applied")
+	unfreeze()
+
+	if err := a.Apply(deltas); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	assertEqual(t, 0, len(Diff(a, b, "")))
+}
+
+func TestJSONPatch(t *testing.T) {
+	a := newBasicStore()
+	b := a.Dup()
+	b.Set("app.server.start", 6)
+	b.Set("app.server.port", 7999)
+	b.Remove("app.debug")
+
+	patch, err := JSONPatch(Diff(a, b, ""), a.Delimiter())
+	if err != nil {
+		t.Fatalf("JSONPatch failed: %v", err)
+	}
+	assertEqual(t, `[{"op":"remove","path":"/app/debug"},{"op":"add","path":"/app/server/port","value":7999},{"op":"replace","path":"/app/server/start","value":6}]`, string(patch))
+
+	if err = ApplyJSONPatch(a, patch); err != nil {
+		t.Fatalf("ApplyJSONPatch failed: %v", err)
+	}
+	assertEqual(t, 6, a.MustInt("app.server.start"))
+	assertEqual(t, 7999, a.MustInt("app.server.port"))
+	assertFalse(t, a.Has("app.debug"))
+
+	err = ApplyJSONPatch(a, []byte(`[
+		{"op":"test","path":"/app/server/port","value":7999},
+		{"op":"copy","from":"/app/server/port","path":"/app/backup/port"},
+		{"op":"move","from":"/app/logging/file","path":"/app/log~1file"},
+		{"op":"add","path":"/app/tls","value":{"cert":"a.pem","key":"a.key"}}
+	]`))
+	if err != nil {
+		t.Fatalf("ApplyJSONPatch failed: %v", err)
+	}
+	assertEqual(t, 7999, a.MustInt("app.backup.port"))
+	assertEqual(t, "/tmp/1.log", a.MustString("app.log/file"))
+	assertFalse(t, a.Has("app.logging.file"))
+	assertEqual(t, "a.key", a.MustString("app.tls.key"))
+
+	err = ApplyJSONPatch(a, []byte(`[{"op":"test","path":"/app/server/port","value":1}]`))
+	assertTrue(t, err != nil, "test op should fail")
+	err = ApplyJSONPatch(a, []byte(`[{"op":"replace","path":"/app/nothing","value":1}]`))
+	assertTrue(t, err != nil, "replace a non-existed path should fail")
+
+	var events []string
+	a.Watch("app.**", func(d Delta) { events = append(events, d.Path) })
+	err = ApplyJSONPatch(a, []byte(`[
+		{"op":"replace","path":"/app/server/port","value":8080},
+		{"op":"remove","path":"/app/tls"},
+		{"op":"test","path":"/app/server/port","value":1}
+	]`))
+	assertTrue(t, err != nil, "the trailing test op should fail")
+	assertEqual(t, 7999, a.MustInt("app.server.port"), "the patch is discarded as a whole")
+	assertEqual(t, "a.key", a.MustString("app.tls.key"))
+	assertEqual(t, []string(nil), events, "no handlers are fired")
+
+	patch, err = JSONPatch([]Delta{
+		{Op: OpCreate, Path: "app.a"},
+		{Op: OpWrite, Path: "app.b", OldValue: 1},
+	}, '.')
+	if err != nil {
+		t.Fatalf("JSONPatch failed: %v", err)
+	}
+	assertEqual(t, `[{"op":"add","path":"/app/a","value":null},{"op":"replace","path":"/app/b","value":null}]`, string(patch))
+}
+
+func TestJSONMergePatch(t *testing.T) {
+	a := newBasicStore()
+	b := a.Dup()
+	b.Set("app.server.start", 6)
+	b.Remove("app.debug")
+
+	patch, err := JSONMergePatch(Diff(a, b, ""), a.Delimiter())
+	if err != nil {
+		t.Fatalf("JSONMergePatch failed: %v", err)
+	}
+	assertEqual(t, `{"app":{"debug":null,"server":{"start":6}}}`, string(patch))
+
+	if err = ApplyJSONMergePatch(a, patch); err != nil {
+		t.Fatalf("ApplyJSONMergePatch failed: %v", err)
+	}
+	assertEqual(t, 6, a.MustInt("app.server.start"))
+	assertFalse(t, a.Has("app.debug"))
+
+	err = ApplyJSONMergePatch(a.WithPrefix("app"), []byte(`{"dump":{"to":"stdout"},"logging":{"rotate":null}}`))
+	if err != nil {
+		t.Fatalf("ApplyJSONMergePatch failed: %v", err)
+	}
+	assertEqual(t, "stdout", a.MustString("app.dump.to"))
+	assertFalse(t, a.Has("app.logging.rotate"))
+
+	key := "app.upstreams." + radix.EscapeKey("api.example.com", '.')
+	patch, err = JSONMergePatch([]Delta{{Op: OpCreate, Path: key, NewValue: 10}}, '.')
+	if err != nil {
+		t.Fatalf("JSONMergePatch failed: %v", err)
+	}
+	assertEqual(t, `{"app":{"upstreams":{"api.example.com":10}}}`, string(patch))
+	if err = ApplyJSONMergePatch(a, patch); err != nil {
+		t.Fatalf("ApplyJSONMergePatch failed: %v", err)
+	}
+	assertEqual(t, 10, a.MustInt(key))
+	assertFalse(t, a.Has("app.upstreams.api"), "a member is a literal key")
+}
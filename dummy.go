@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"iter"
 	"strings"
 	"time"
 
@@ -42,7 +43,26 @@ func (s *dummyS) GetEx(path string, cb func(node radix.Node[any], data any, bran
 func (s *dummyS) SetEx(path string, data any, cb radix.OnSetEx[any]) (old any)             { return }
 func (s *dummyS) Remove(path string) (removed bool)                                        { return }
 func (s *dummyS) RemoveEx(path string) (nodeRemoved, parent radix.Node[any], removed bool) { return }
-func (s *dummyS) Merge(pathAt string, data map[string]any) (err error)                     { return }
+func (s *dummyS) Merge(pathAt string, data map[string]any, opts ...MergeOpt) (err error)   { return }
+func (s *dummyS) Move(src, dst string) (err error)                                         { return }
+func (s *dummyS) Copy(src, dst string) (err error)                                         { return }
+func (s *dummyS) Rename(path, newLeafName string) (err error)                              { return }
+func (s *dummyS) Apply(deltas []Delta) (err error)                                         { return }
+func (s *dummyS) Tx(fn func(tx Store) error) (err error)                                   { return }
+func (s *dummyS) Snapshot() (v VersionID, ro ReadOnlyStore)                                { return }
+func (s *dummyS) History() (vers []VersionInfo)                                            { return }
+func (s *dummyS) Rollback(v VersionID) (err error)                                         { return }
+func (s *dummyS) Watch(pattern string, fn WatchFunc) (unsubscribe func())                  { return func() {} }
+func (s *dummyS) Events(ctx context.Context, opts ...EventsOpt) <-chan ChangeBatch         { return nil }
+func (s *dummyS) LoadAll(ctx context.Context, sources ...Source) (err error)               { return }
+func (s *dummyS) ActivateProfiles(base string, names ...string) (err error)                { return }
+func (s *dummyS) ActiveProfiles(base string) (names []string)                              { return }
+func (s *dummyS) RawView(base string) (raw Store)                                          { return s }
+func (s *dummyS) ReadOnly() (ro Store)                                                     { return s }
+func (s *dummyS) Freeze(path string, opts ...FreezeOpt) (unfreeze func())                  { return func() {} }
+func (s *dummyS) Writable(path string) (err error)                                         { return }
+func (s *dummyS) CompactJournal() (err error)                                              { return }
+func (s *dummyS) Validate() (err error)                                                    { return }
 func (s *dummyS) Has(path string) (found bool)                                             { return }
 func (s *dummyS) Update(path string, cb func(node radix.Node[any], old any))               {}
 
@@ -51,6 +71,8 @@ func (s *dummyS) Locate(path string, kvpair radix.KVPair) (node radix.Node[any],
 	return
 }
 
+func (s *dummyS) QueryResolved(path string) (data any, branch, found bool, err error) { return }
+
 func (s *dummyS) GetString(path string, defaultVal ...string) (ret string, err error)        { return }
 func (s *dummyS) MustString(path string, defaultVal ...string) (ret string)                  { return }
 func (s *dummyS) GetStringSlice(path string, defaultVal ...string) (ret []string, err error) { return }
@@ -330,17 +352,28 @@ func (s *dummyS) GetSectionFrom(path string, holder any, opts ...radix.MOpt[any]
 func (s *dummyS) To(path string, target any, opts ...radix.MOpt[any]) (err error)             { return }
 
 func (s *dummyS) Dump() (text string)                                                    { return }
+func (s *dummyS) Explain() (text string)                                                 { return }
+func (s *dummyS) Origin(path string) (chain []Origin)                                    { return }
 func (s *dummyS) Clone() (newStore Store)                                                { return }
 func (s *dummyS) Dup() (newStore Store)                                                  { return }
 func (s *dummyS) Walk(path string, cb func(path, fragment string, node radix.Node[any])) {}
-func (s *dummyS) WithPrefix(prefix ...string) (newStore Store)                           { return s }
-func (s *dummyS) WithPrefixReplaced(prefix ...string) (newStore Store)                   { return s }
-func (s *dummyS) SetPrefix(prefix ...string)                                             { s.p = strings.Join(prefix, ".") }
-func (s *dummyS) Prefix() string                                                         { return s.p }
-func (s *dummyS) Delimiter() rune                                                        { return '.' }
-func (s *dummyS) SetDelimiter(delimiter rune)                                            {}
-func (s *dummyS) Load(ctx context.Context, opts ...LoadOpt) (wr Writeable, err error)    { return }
-func (s *dummyS) WithinLoading(fn func())                                                { fn() }
+func (s *dummyS) All(prefix string) iter.Seq2[string, radix.Node[any]] {
+	return func(func(string, radix.Node[any]) bool) {}
+}
+func (s *dummyS) Leaves(prefix string) iter.Seq2[string, any] { return func(func(string, any) bool) {} }
+func (s *dummyS) Keys(prefix string) iter.Seq[string]         { return func(func(string) bool) {} }
+func (s *dummyS) Children(path string) iter.Seq2[string, radix.Node[any]] {
+	return func(func(string, radix.Node[any]) bool) {}
+}
+func (s *dummyS) WithPrefix(prefix ...string) (newStore Store)                        { return s }
+func (s *dummyS) WithPrefixReplaced(prefix ...string) (newStore Store)                { return s }
+func (s *dummyS) SetPrefix(prefix ...string)                                          { s.p = strings.Join(prefix, ".") }
+func (s *dummyS) Prefix() string                                                      { return s.p }
+func (s *dummyS) Delimiter() rune                                                     { return '.' }
+func (s *dummyS) SetDelimiter(delimiter rune)                                         {}
+func (s *dummyS) ChangeDelimiter(delimiter rune) (err error)                          { return }
+func (s *dummyS) Load(ctx context.Context, opts ...LoadOpt) (wr Writeable, err error) { return }
+func (s *dummyS) WithinLoading(fn func())                                             { fn() }
 
 func (s *dummyS) SaveAs(ctx context.Context, file string, opts ...SaveAsOpt) (err error) { return }
 
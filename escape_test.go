@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreS_EscapedKeys(t *testing.T) {
+	conf := New(WithLiteralKeys(true))
+	defer conf.Close()
+
+	src := &watchableS{data: `{"upstreams":{"api.example.com":{"weight":10},"10.0.0.1":{"weight":5}}}`}
+	if _, err := conf.Load(context.TODO(), WithProvider(src)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertEqual(t, 10, conf.MustInt(`upstreams."api.example.com".weight`))
+	assertEqual(t, 5, conf.MustInt(`upstreams.10\.0\.0\.1.weight`))
+	assertFalse(t, conf.Has("upstreams.api"))
+
+	var deltas []Delta
+	conf.Watch("upstreams.*.weight", func(d Delta) { deltas = append(deltas, d) })
+	conf.Set(`upstreams."api.example.com".weight`, 20)
+	assertEqual(t, 1, len(deltas))
+	assertEqual(t, `upstreams.api\.example\.com.weight`, deltas[0].Path)
+
+	err := conf.Merge("props", map[string]any{"java": map[string]any{"org.slf4j.level": "debug"}})
+	assertTrue(t, err == nil, err)
+	assertEqual(t, "debug", conf.MustString(`props.java."org.slf4j.level"`))
+
+	m := conf.MustM("upstreams")
+	assertEqual(t, map[string]any{"weight": 20}, m["api.example.com"])
+
+	out := filepath.Join(t.TempDir(), "out.json")
+	if err = conf.SaveAs(context.TODO(), out); err != nil {
+		t.Fatalf("SaveAs failed: %v", err)
+	}
+	b, _ := os.ReadFile(out)
+	var saved map[string]any
+	assertTrue(t, json.Unmarshal(b, &saved) == nil)
+	ups := saved["upstreams"].(map[string]any)
+	assertEqual(t, float64(20), ups["api.example.com"].(map[string]any)["weight"])
+
+	again := New(WithLiteralKeys(true))
+	defer again.Close()
+	if _, err = again.Load(context.TODO(), WithProvider(&watchableS{data: string(b)})); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertEqual(t, 20, again.MustInt(`upstreams.api\.example\.com.weight`))
+	assertEqual(t, "debug", again.MustString(`props.java."org.slf4j.level"`))
+}
+
+func TestStoreS_MapKeysSplit(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+
+	err := conf.Merge("", map[string]any{
+		"a.b":                1,
+		`"api.example.com"`:  map[string]any{"weight": 10},
+		`x.10\.0\.0\.1.port`: 80,
+	})
+	assertTrue(t, err == nil, err)
+	assertEqual(t, 1, conf.MustInt("a.b"))
+	assertTrue(t, conf.Has("a"), "a flat dotted key is nested")
+	assertEqual(t, 10, conf.MustInt(`"api.example.com".weight`))
+	assertFalse(t, conf.Has("api"))
+	assertEqual(t, 80, conf.MustInt(`x."10.0.0.1".port`))
+
+	lit := New(WithLiteralKeys(true))
+	defer lit.Close()
+	err = lit.Merge("", map[string]any{"a.b": 1})
+	assertTrue(t, err == nil, err)
+	assertEqual(t, 1, lit.MustInt(`a\.b`))
+	assertFalse(t, lit.Has("a"))
+}
+
+func TestStoreS_ChangeDelimiter(t *testing.T) {
+	consul := New(WithDelimiter('/'), WithOriginTracking(true))
+	defer consul.Close()
+
+	src := newMapPvdr("consul", map[string]any{
+		"services/web/addr":          "10.0.0.1:80",
+		"services/web/tags/v1.2":     true,
+		"services/web.example.com/x": 1,
+	})
+	if _, err := consul.Load(context.TODO(), WithProvider(src)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertTrue(t, consul.SetComment("services/web/addr", "", "primary"))
+	unfreeze := consul.Freeze("services/web")
+
+	err := consul.ChangeDelimiter('.')
+	assertTrue(t, errors.Is(err, ErrReadOnly), "a frozen subtree rejects it")
+	unfreeze()
+	err = consul.WithPrefix("services").ChangeDelimiter('.')
+	assertTrue(t, err != nil, "a view rejects it")
+	err = consul.ReadOnly().ChangeDelimiter('.')
+	assertTrue(t, errors.Is(err, ErrReadOnly), "a read-only view rejects it")
+	assertEqual(t, '/', consul.Delimiter())
+
+	err = consul.ChangeDelimiter('.')
+	assertTrue(t, err == nil, err)
+	assertEqual(t, '.', consul.Delimiter())
+	assertEqual(t, "10.0.0.1:80", consul.MustString("services.web.addr"))
+	assertEqual(t, true, consul.MustBool(`services.web.tags."v1.2"`))
+	assertEqual(t, 1, consul.MustInt(`services.web\.example\.com.x`))
+	assertFalse(t, consul.Has("services/web/addr"))
+
+	chain := consul.Origin("services.web.addr")
+	assertEqual(t, 1, len(chain))
+	assertEqual(t, "consul", chain[0].Provider)
+
+	node, _, _, found := consul.Locate("services.web.addr", nil)
+	assertTrue(t, found)
+	assertEqual(t, "primary", node.Comment())
+
+	conf := New(WithLiteralKeys(true))
+	defer conf.Close()
+	err = conf.Merge("remote.services", consul.MustM("services"))
+	assertTrue(t, err == nil, err)
+	assertEqual(t, "10.0.0.1:80", conf.MustString("remote.services.web.addr"))
+	assertEqual(t, true, conf.MustBool(`remote.services.web.tags.v1\.2`))
+	assertEqual(t, 1, conf.MustInt(`remote.services."web.example.com".x`))
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ChangeBatch is a set of changes delivered by [Store.Events].
+type ChangeBatch struct {
+	Seq     uint64    // 1, 2, 3, ... for the batches of a channel
+	Time    time.Time // when the batch was taken
+	Changes []Delta   // coalesced changes, in the order of their first occurrence
+}
+
+// EventsOpt is the options for [Store.Events].
+type EventsOpt func(s *eventsS)
+
+const (
+	defaultEventsWindow = 100 * time.Millisecond
+	defaultEventsBuffer = 16
+)
+
+// WithEventsWindow sets the coalescing window, 100ms by default.
+// The changes made within the window after the first one are
+// delivered in one batch.
+func WithEventsWindow(window time.Duration) EventsOpt {
+	return func(s *eventsS) {
+		if window > 0 {
+			s.window = window
+		}
+	}
+}
+
+// WithEventsBuffer sets the capacity of the channel, 16 by
+// default.
+func WithEventsBuffer(size int) EventsOpt {
+	return func(s *eventsS) {
+		if size >= 0 {
+			s.buffer = size
+		}
+	}
+}
+
+// WithEventsPattern delivers the changes matching the pattern
+// only, see [Store.Watch] for the syntax. The default is "**",
+// all keys.
+func WithEventsPattern(pattern string) EventsOpt {
+	return func(s *eventsS) {
+		s.pattern = pattern
+	}
+}
+
+// Events returns a channel which delivers the changes in batches.
+//
+// A burst of changes, such as the reloading of a config file,
+// is coalesced into one batch: the changes made within a
+// window (see WithEventsWindow) after the first one are
+// collected, and the changes of a key are merged into one
+// Delta holding the first OldValue and the last NewValue. A key
+// created and then removed in the window is dropped. The renames
+// by Move and Rename aren't merged.
+//
+// The batches are delivered in order, with increasing Seq. The
+// delivery is asynchronous, a slow receiver never blocks Set or
+// Load. While the channel is full, the further changes are kept
+// and coalesced into the next batch.
+//
+// The channel is closed after ctx done.
+//
+//	for batch := range conf.Events(ctx, store.WithEventsWindow(200*time.Millisecond)) {
+//	    restartWorkers(batch.Changes)
+//	}
+func (s *storeS) Events(ctx context.Context, opts ...EventsOpt) <-chan ChangeBatch {
+	e := &eventsS{
+		window:  defaultEventsWindow,
+		buffer:  defaultEventsBuffer,
+		pattern: "**",
+		kick:    make(chan struct{}, 1),
+		index:   make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	out := make(chan ChangeBatch, e.buffer)
+	unsubscribe := s.Watch(e.pattern, e.push)
+	go e.run(ctx, out, unsubscribe)
+	return out
+}
+
+type eventsS struct {
+	window  time.Duration
+	buffer  int
+	pattern string
+
+	kick    chan struct{} // a change arrived
+	mu      sync.Mutex
+	pending []Delta
+	index   map[string]int // path -> the position in pending
+}
+
+func (s *eventsS) push(d Delta) {
+	s.mu.Lock()
+	s.coalesce(d)
+	s.mu.Unlock()
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// coalesce merges d into the pending change of the same key.
+func (s *eventsS) coalesce(d Delta) {
+	if d.Op == OpRename { // kept in order, the later changes start over
+		delete(s.index, d.OldPath)
+		delete(s.index, d.Path)
+		s.pending = append(s.pending, d)
+		return
+	}
+
+	i, ok := s.index[d.Path]
+	if !ok {
+		s.index[d.Path] = len(s.pending)
+		s.pending = append(s.pending, d)
+		return
+	}
+
+	p := &s.pending[i]
+	switch {
+	case p.Op == OpCreate && d.Op == OpRemove:
+		p.Op = 0 // dropped, see take
+		delete(s.index, d.Path)
+	case p.Op == OpCreate:
+		p.NewValue = d.NewValue
+	case p.Op == OpRemove && d.Op != OpRemove:
+		p.Op, p.NewValue = OpWrite, d.NewValue
+	default:
+		p.Op, p.NewValue = d.Op, d.NewValue
+	}
+}
+
+func (s *eventsS) take() (changes []Delta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.pending {
+		if d.Op != 0 {
+			changes = append(changes, d)
+		}
+	}
+	s.pending, s.index = nil, make(map[string]int)
+	return
+}
+
+func (s *eventsS) run(ctx context.Context, out chan<- ChangeBatch, unsubscribe func()) {
+	defer close(out)
+	defer unsubscribe()
+
+	var seq uint64
+	timer := time.NewTimer(s.window)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.kick:
+		}
+
+		timer.Reset(s.window)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		changes := s.take()
+		if len(changes) == 0 {
+			continue
+		}
+		seq++
+		select {
+		case out <- ChangeBatch{Seq: seq, Time: time.Now(), Changes: changes}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
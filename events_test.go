@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStoreS_Events(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.server.port", 7999)
+	conf.Set("app.debug", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := conf.Events(ctx, WithEventsWindow(50*time.Millisecond), WithEventsPattern("app.server.**"))
+
+	conf.Set("app.server.port", 8080)
+	conf.Set("app.server.port", 8081)
+	conf.Set("app.server.host", "localhost")
+	conf.Set("app.server.tmp", 1)
+	conf.Remove("app.server.tmp")
+	conf.Set("app.debug", true) // not matched
+
+	batch := <-ch
+	assertEqual(t, uint64(1), batch.Seq)
+	assertEqual(t, []Delta{
+		{Op: OpWrite, Path: "app.server.port", OldValue: 7999, NewValue: 8081},
+		{Op: OpCreate, Path: "app.server.host", NewValue: "localhost"},
+	}, batch.Changes)
+
+	conf.Remove("app.server.host")
+	conf.Set("app.server.host", "0.0.0.0")
+	batch = <-ch
+	assertEqual(t, uint64(2), batch.Seq)
+	assertEqual(t, []Delta{{Op: OpWrite, Path: "app.server.host", OldValue: "localhost", NewValue: "0.0.0.0"}}, batch.Changes)
+
+	cancel()
+	for range ch { //nolint:revive // drain until closed
+	}
+	conf.Set("app.server.port", 1) // unsubscribed, no panic
+}
+
+func TestStoreS_EventsSlowReceiver(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := conf.Events(ctx, WithEventsWindow(10*time.Millisecond), WithEventsBuffer(0))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			conf.Set("app.counter", i)
+			if i%100 == 0 {
+				time.Sleep(15 * time.Millisecond)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Set was blocked by the receiver")
+	}
+
+	var last any
+	var seq uint64
+	for last != 999 {
+		select {
+		case batch := <-ch:
+			assertEqual(t, seq+1, batch.Seq, "in order")
+			seq = batch.Seq
+			last = batch.Changes[len(batch.Changes)-1].NewValue
+		case <-time.After(time.Second):
+			t.Fatalf("the last change is lost, got %v", last)
+		}
+	}
+	assertTrue(t, seq < 1000, "coalesced")
+}
@@ -0,0 +1,353 @@
+package store
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hedzr/evendeep"
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// RegisterConverter registers a converter for type T, it's used
+// by Get, MustGet, GetSlice and GetMap to convert the stored
+// values, and the elements of them, to T:
+//
+//	type Level int
+//
+//	store.RegisterConverter(func(v any) (Level, error) {
+//	    switch fmt.Sprint(v) {
+//	    case "debug":
+//	        return LevelDebug, nil
+//	    ...
+//	    }
+//	    return 0, fmt.Errorf("unknown level %v", v)
+//	})
+//	lvl, err := store.Get[Level](conf, "app.logging.level")
+//
+// A converter registered later replaces the former one of the
+// same type.
+//
+// The types implementing encoding.TextUnmarshaler, such as
+// netip.Addr, big.Int and *regexp.Regexp, are converted from
+// strings without registering. The converters of *url.URL and
+// *time.Location are registered by default.
+func RegisterConverter[T any](fn func(v any) (T, error)) {
+	converters.Lock()
+	defer converters.Unlock()
+	converters.m[reflect.TypeOf((*T)(nil)).Elem()] = func(v any) (any, error) { return fn(v) }
+}
+
+var converters = struct {
+	sync.RWMutex
+	m map[reflect.Type]func(v any) (any, error)
+}{m: make(map[reflect.Type]func(v any) (any, error))}
+
+func init() {
+	RegisterConverter(func(v any) (*url.URL, error) { return url.Parse(converter.String(v)) })
+	RegisterConverter(func(v any) (*time.Location, error) { return time.LoadLocation(converter.String(v)) })
+}
+
+var converter = evendeep.Cvt{}
+
+// Get returns the value at path in type T, the stored value is
+// converted if necessary. T can be a basic type, a slice, a map,
+// a struct, or any type having a converter, see
+// RegisterConverter.
+//
+// For a struct, map or slice type, path can be a branch, the
+// subtree is converted.
+//
+// If the key is not found, the last of defaultVal is returned,
+// or else an error.
+func Get[T any](s Store, path string, defaultVal ...T) (ret T, err error) {
+	var v any
+	if v, err = lookup(s, path); err != nil {
+		if len(defaultVal) > 0 {
+			ret, err = defaultVal[len(defaultVal)-1], nil
+		}
+		return
+	}
+
+	var rv reflect.Value
+	if rv, err = convertValue(v, reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+		err = errors.New("cannot get %q", path).WithErrors(err)
+		return
+	}
+	ret = rv.Interface().(T)
+	return
+}
+
+// MustGet is the shortcut version of Get, it returns the zero
+// value of T, or the last of defaultVal, on error.
+func MustGet[T any](s Store, path string, defaultVal ...T) (ret T) {
+	var err error
+	if ret, err = Get(s, path, defaultVal...); err != nil && len(defaultVal) > 0 {
+		ret = defaultVal[len(defaultVal)-1]
+	}
+	return
+}
+
+// GetSlice returns the value at path as a []T. A scalar value is
+// treated as a slice of one element, and a branch flattened by
+// WithFlattenSlice, with the keys "0", "1", ..., is collected
+// in order.
+func GetSlice[T any](s Store, path string) (ret []T, err error) {
+	return Get[[]T](s, path)
+}
+
+// GetMap returns the value or the subtree at path as a
+// map[string]T.
+func GetMap[T any](s Store, path string) (ret map[string]T, err error) {
+	return Get[map[string]T](s, path)
+}
+
+// lookup returns the value at path, or the subtree as a nested map
+// if path is a branch. The values are interpolated and resolved as
+// the typed getters do.
+func lookup(s Store, path string) (v any, err error) {
+	if !radix.IsIndexPath(path, s.Delimiter()) {
+		node, branch, _, found := s.Locate(path, nil)
+		if !found || node == nil {
+			return nil, errors.New("%q not found", path).WithErrors(errors.NotFound)
+		}
+		if branch && !node.HasData() {
+			return subtree(s, path)
+		}
+	}
+	var found bool
+	if v, _, found, err = s.QueryResolved(path); !found {
+		return nil, errors.New("%q not found", path).WithErrors(errors.NotFound)
+	} else if err != nil {
+		return nil, errors.New("cannot resolve %q", path).WithErrors(err)
+	}
+	return
+}
+
+// subtree returns the leaves under the branch path as a nested map,
+// with the values resolved.
+func subtree(s Store, path string) (m map[string]any, err error) {
+	d := s.Delimiter()
+	prefix := s.Prefix()
+	depth := len(radix.SplitPath(path, d))
+	if prefix != "" {
+		depth += len(radix.SplitPath(prefix, d))
+		prefix += string(d)
+	}
+
+	m = make(map[string]any)
+	for key := range s.Leaves(path) {
+		keys := radix.SplitPath(key, d)
+		if len(keys) <= depth {
+			continue
+		}
+		v, _, _, e := s.QueryResolved(strings.TrimPrefix(key, prefix))
+		if e != nil {
+			return nil, errors.New("cannot resolve %q", key).WithErrors(e)
+		}
+		parent := m
+		for _, k := range keys[depth : len(keys)-1] {
+			child, ok := parent[k].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				parent[k] = child
+			}
+			parent = child
+		}
+		parent[keys[len(keys)-1]] = v
+	}
+	return
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// convertValue converts v to typ.
+func convertValue(v any, typ reflect.Type) (ret reflect.Value, err error) {
+	if v != nil && reflect.TypeOf(v) == typ {
+		return reflect.ValueOf(v), nil
+	}
+
+	converters.RLock()
+	fn, ok := converters.m[typ]
+	converters.RUnlock()
+	if ok {
+		var r any
+		if r, err = fn(v); err != nil {
+			return
+		}
+		if r == nil {
+			return reflect.Zero(typ), nil
+		}
+		return reflect.ValueOf(r).Convert(typ), nil
+	}
+
+	if str, yes := v.(string); yes {
+		if ret, ok, err = unmarshalText(str, typ); ok {
+			return
+		}
+	}
+
+	switch typ {
+	case durationType:
+		return reflect.ValueOf(converter.Duration(v)), nil
+	case timeType:
+		return reflect.ValueOf(converter.Time(v)), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		if v == nil {
+			return reflect.Zero(typ), nil
+		}
+		if rv := reflect.ValueOf(v); rv.Type().Implements(typ) {
+			return rv.Convert(typ), nil
+		}
+	case reflect.String:
+		return reflect.ValueOf(converter.String(v)).Convert(typ), nil
+	case reflect.Bool:
+		return reflect.ValueOf(converter.Bool(v)).Convert(typ), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(converter.Int(v)).Convert(typ), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.ValueOf(converter.Uint(v)).Convert(typ), nil
+	case reflect.Float32, reflect.Float64:
+		return reflect.ValueOf(converter.Float64(v)).Convert(typ), nil
+	case reflect.Slice:
+		return convertSlice(v, typ)
+	case reflect.Map:
+		if typ.Key().Kind() == reflect.String {
+			return convertMap(v, typ)
+		}
+	case reflect.Struct:
+		if m, yes := v.(map[string]any); yes {
+			p := reflect.New(typ)
+			if err = copyTo(m, p.Interface()); err == nil {
+				ret = p.Elem()
+			}
+			return
+		}
+	case reflect.Pointer:
+		if v == nil {
+			return reflect.Zero(typ), nil
+		}
+		var elem reflect.Value
+		if elem, err = convertValue(v, typ.Elem()); err == nil {
+			ret = reflect.New(typ.Elem())
+			ret.Elem().Set(elem)
+		}
+		return
+	}
+	return ret, errors.New("cannot convert %T to %v", v, typ)
+}
+
+// unmarshalText converts a string by encoding.TextUnmarshaler,
+// if typ or *typ implements it.
+func unmarshalText(str string, typ reflect.Type) (ret reflect.Value, ok bool, err error) {
+	switch {
+	case reflect.PointerTo(typ).Implements(textUnmarshalerType):
+		p := reflect.New(typ)
+		err = p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+		return p.Elem(), true, err
+	case typ.Kind() == reflect.Pointer && typ.Implements(textUnmarshalerType):
+		p := reflect.New(typ.Elem())
+		err = p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+		return p, true, err
+	}
+	return
+}
+
+func convertSlice(v any, typ reflect.Type) (ret reflect.Value, err error) {
+	var items []any
+	switch rv := reflect.ValueOf(v); {
+	case v == nil:
+		return reflect.Zero(typ), nil
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i).Interface())
+		}
+	case rv.Kind() == reflect.Map:
+		if items, err = indexedItems(v); err != nil {
+			return
+		}
+	case typ.Elem().Kind() == reflect.Uint8 && rv.Kind() == reflect.String:
+		return reflect.ValueOf([]byte(rv.String())).Convert(typ), nil
+	case typ.Elem().Kind() == reflect.String && rv.Kind() == reflect.String:
+		for _, it := range converter.StringSlice(v) { // "a,b,c"
+			items = append(items, it)
+		}
+	default:
+		items = []any{v}
+	}
+
+	ret = reflect.MakeSlice(typ, 0, len(items))
+	for i, it := range items {
+		var elem reflect.Value
+		if elem, err = convertValue(it, typ.Elem()); err != nil {
+			return ret, errors.New("cannot convert the element #%d", i).WithErrors(err)
+		}
+		ret = reflect.Append(ret, elem)
+	}
+	return
+}
+
+// indexedItems returns the values of a map with the keys "0",
+// "1", ..., in order. It's for the slices flattened by
+// WithFlattenSlice.
+func indexedItems(v any) (items []any, err error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, errors.New("cannot convert %T to a slice", v)
+	}
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		i, e := strconv.Atoi(k)
+		if e != nil {
+			return nil, errors.New("cannot convert a map to a slice, key %q is not an index", k)
+		}
+		keys = append(keys, i)
+	}
+	sort.Ints(keys)
+	for _, i := range keys {
+		items = append(items, m[strconv.Itoa(i)])
+	}
+	return
+}
+
+func convertMap(v any, typ reflect.Type) (ret reflect.Value, err error) {
+	if v == nil {
+		return reflect.Zero(typ), nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return ret, errors.New("cannot convert %T to %v", v, typ)
+	}
+
+	ret = reflect.MakeMapWithSize(typ, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key := converter.String(iter.Key().Interface())
+		var elem reflect.Value
+		if elem, err = convertValue(iter.Value().Interface(), typ.Elem()); err != nil {
+			return ret, errors.New("cannot convert the element %q", key).WithErrors(err)
+		}
+		ret.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), elem)
+	}
+	return
+}
+
+// copyTo fills a struct from a map, like [Store.To].
+func copyTo(m map[string]any, holder any) (err error) {
+	defer evendeep.DefaultCopyController.SaveFlagsAndRestore()()
+	return evendeep.DefaultCopyController.CopyTo(m, holder)
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+type testLevel int
+
+const (
+	testLevelInfo testLevel = iota
+	testLevelDebug
+)
+
+type testServer struct {
+	Host string
+	Port int
+}
+
+func TestGet(t *testing.T) {
+	RegisterConverter(func(v any) (testLevel, error) {
+		switch strings.ToLower(converter.String(v)) {
+		case "info":
+			return testLevelInfo, nil
+		case "debug":
+			return testLevelDebug, nil
+		}
+		return 0, errors.New("unknown level %v", v)
+	})
+
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.port", "8080")
+	conf.Set("app.timeout", "3s")
+	conf.Set("app.level", "Debug")
+	conf.Set("app.bad-level", "verbose")
+	conf.Set("app.addr", "10.0.0.1")
+	conf.Set("app.url", "https://example.com/x")
+	conf.Set("app.pattern", `^a+$`)
+	conf.Set("app.big", "123456789012345678901234567890")
+	conf.Set("app.tz", "UTC")
+	conf.Set("app.levels", []any{"info", "debug"})
+	conf.Set("app.server.host", "a")
+	conf.Set("app.server.port", 80)
+	conf.Set("app.backends", []any{
+		map[string]any{"host": "a", "port": 80},
+		map[string]any{"host": "b", "port": 81},
+	})
+	conf.Set("app.limits", map[string]any{"cpu": "1", "mem": 512})
+
+	port, err := Get[int](conf, "app.port")
+	assertTrue(t, err == nil)
+	assertEqual(t, 8080, port)
+	assertEqual(t, 3*time.Second, MustGet[time.Duration](conf, "app.timeout"))
+	assertEqual(t, testLevelDebug, MustGet[testLevel](conf, "app.level"))
+	_, err = Get[testLevel](conf, "app.bad-level")
+	assertTrue(t, err != nil, "the converter fails")
+	assertEqual(t, netip.MustParseAddr("10.0.0.1"), MustGet[netip.Addr](conf, "app.addr"))
+	assertEqual(t, "example.com", MustGet[*url.URL](conf, "app.url").Host)
+	assertTrue(t, MustGet[*regexp.Regexp](conf, "app.pattern").MatchString("aaa"))
+	b := MustGet[big.Int](conf, "app.big")
+	assertEqual(t, "123456789012345678901234567890", b.String())
+	assertEqual(t, time.UTC, MustGet[*time.Location](conf, "app.tz"))
+
+	levels, err := GetSlice[testLevel](conf, "app.levels")
+	assertTrue(t, err == nil)
+	assertEqual(t, []testLevel{testLevelInfo, testLevelDebug}, levels)
+	servers, err := GetSlice[testServer](conf, "app.backends")
+	assertTrue(t, err == nil)
+	assertEqual(t, []testServer{{"a", 80}, {"b", 81}}, servers)
+	assertEqual(t, testServer{"a", 80}, MustGet[testServer](conf, "app.server"), "from a branch")
+	assertEqual(t, &testServer{"a", 80}, MustGet[*testServer](conf, "app.server"))
+
+	limits, err := GetMap[int](conf, "app.limits")
+	assertTrue(t, err == nil)
+	assertEqual(t, map[string]int{"cpu": 1, "mem": 512}, limits)
+	server, err := GetMap[string](conf, "app.server")
+	assertTrue(t, err == nil)
+	assertEqual(t, map[string]string{"host": "a", "port": "80"}, server)
+
+	_, err = Get[int](conf, "app.missing")
+	assertTrue(t, errors.Is(err, errors.NotFound))
+	assertEqual(t, 9, MustGet(conf, "app.missing", 9))
+}
+
+func TestGetSlice_Flatten(t *testing.T) {
+	conf := New(WithFlattenSlice(true))
+	defer conf.Close()
+	_ = conf.Merge("app", map[string]any{"ports": []any{80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90}})
+	ports, err := GetSlice[int](conf, "app.ports")
+	assertTrue(t, err == nil)
+	assertEqual(t, 11, len(ports))
+	assertEqual(t, 90, ports[10])
+}
+
+func TestGet_Resolved(t *testing.T) {
+	conf := New(
+		WithInterpolation(true),
+		WithSecretResolver("fake", SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+			return "pw-" + ref, nil
+		})),
+	)
+	defer conf.Close()
+	conf.Set("app.home", "/srv")
+	conf.Set("app.logs", "${app.home}/logs")
+	conf.Set("app.srv.host", "${app.home}")
+	conf.Set("app.srv.tls.enabled", true)
+	conf.Set("app.srv.tls.password", "secret://fake/db")
+
+	assertEqual(t, "/srv/logs", MustGet[string](conf, "app.logs"))
+	assertEqual(t, "pw-db", MustGet[string](conf, "app.srv.tls.password"))
+
+	type config struct {
+		Host string
+		TLS  struct {
+			Enabled  bool
+			Password string
+		}
+	}
+	cfg, err := Get[config](conf, "app.srv")
+	assertTrue(t, err == nil)
+	assertEqual(t, "/srv", cfg.Host)
+	assertTrue(t, cfg.TLS.Enabled, "the nested branch")
+	assertEqual(t, "pw-db", cfg.TLS.Password)
+	assertEqual(t, cfg, MustGet[config](conf.WithPrefix("app"), "srv"), "with a prefix")
+
+	m, err := GetMap[any](conf, "app.srv")
+	assertTrue(t, err == nil)
+	assertEqual(t, map[string]any{"enabled": true, "password": "pw-db"}, m["tls"])
+}
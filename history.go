@@ -0,0 +1,205 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// VersionID identifies a snapshot in the history of a store.
+//
+// It's not named Version, which is the version of this module.
+type VersionID uint64
+
+// VersionInfo describes a snapshot in the history.
+type VersionInfo struct {
+	Version VersionID
+	Time    time.Time // when the snapshot was taken
+}
+
+// ReadOnlyStore is a store without the writing abilities, it is
+// returned by [Store.Snapshot].
+type ReadOnlyStore interface {
+	MustGet(path string) (data any)
+	Get(path string) (data any, found bool)
+	Has(path string) (found bool)
+	Locate(path string, kvpair radix.KVPair) (node radix.Node[any], branch, partialMatched, found bool)
+
+	radix.TypedGetters[any] // getters
+
+	GetDesc(path string) (desc string, err error)       // get description field directly
+	MustGetDesc(path string) (desc string)              // mustget description field directly
+	GetTag(path string) (tag any, err error)            // get tag field directly
+	MustGetTag(path string) (tag any)                   // mustget tag field directly
+	GetComment(path string) (comment string, err error) // get comment field directly
+	MustGetComment(path string) (comment string)        // mustget comment field directly
+
+	Dump() (text string)
+	Walk(path string, cb func(path, fragment string, node radix.Node[any]))
+
+	Prefix() string  // return current prefix string
+	Delimiter() rune // return current delimiter, generally it's dot ('.')
+}
+
+type readOnlyS struct{ ReadOnlyStore }
+
+// ErrVersionNotFound is returned by Rollback if the version has
+// been dropped from the history.
+var ErrVersionNotFound = errors.New("version not found")
+
+const defaultHistorySize = 16
+
+// WithHistory keeps the latest 'size' snapshots in a ring, and
+// takes a snapshot automatically after each loading and
+// reloading (by a watching provider).
+//
+// A snapshot is just the root of the tree at that moment, the
+// writings never modify a published root, so taking a snapshot
+// on a large tree is cheap.
+//
+// Without WithHistory, only the snapshots taken by
+// [Store.Snapshot] are kept, 16 at most.
+func WithHistory(size int) Opt {
+	return func(s *storeS) {
+		if size > 0 {
+			s.history.size = size
+		}
+		s.history.auto = true
+	}
+}
+
+// historyS is shared by a store and all of its prefixed views.
+type historyS struct {
+	mu   sync.Mutex
+	size int
+	auto bool // take snapshot after loading or reloading
+	seq  VersionID
+	ring []historyItem // the oldest one first
+}
+
+type historyItem struct {
+	VersionInfo
+	snapshot *radix.Snapshot[any]
+}
+
+func newHistory() *historyS { return &historyS{size: defaultHistorySize} }
+
+// push takes a snapshot of trie and appends it into the ring.
+func (s *historyS) push(trie radix.Trie[any]) (v VersionID, snapshot *radix.Snapshot[any]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot = trie.Snapshot()
+	s.seq++
+	v = s.seq
+	s.ring = append(s.ring, historyItem{VersionInfo{v, time.Now()}, snapshot})
+	if over := len(s.ring) - s.size; over > 0 {
+		s.ring = append(s.ring[:0:0], s.ring[over:]...)
+	}
+	return
+}
+
+func (s *historyS) autoPush(trie radix.Trie[any]) {
+	if s != nil && s.auto {
+		s.push(trie)
+	}
+}
+
+func (s *historyS) find(v VersionID) (snapshot *radix.Snapshot[any], ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, it := range s.ring {
+		if it.Version == v {
+			return it.snapshot, true
+		}
+	}
+	return
+}
+
+func (s *historyS) list() (vers []VersionInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, it := range s.ring {
+		vers = append(vers, it.VersionInfo)
+	}
+	return
+}
+
+// Snapshot takes a snapshot of the whole store and keeps it in
+// the history, so that you can Rollback to it later.
+//
+// The returned ReadOnlyStore holds the values at this moment,
+// it has the same prefix with this store.
+func (s *storeS) Snapshot() (v VersionID, ro ReadOnlyStore) {
+	var snapshot *radix.Snapshot[any]
+	v, snapshot = s.history.push(s.Trie)
+	trie := snapshot.Trie()
+	trie.SetPrefix(s.Prefix())
+	ns := s.dupS(trie)
+	ns.origins, ns.history = nil, nil
+	return v, &readOnlyS{ns}
+}
+
+// History returns the versions kept in the history ring, the
+// oldest one first.
+//
+// To revert to the config from 5 minutes ago:
+//
+//	for _, v := range slices.Backward(conf.History()) {
+//	    if v.Time.Before(time.Now().Add(-5 * time.Minute)) {
+//	        err = conf.Rollback(v.Version)
+//	        break
+//	    }
+//	}
+func (s *storeS) History() (vers []VersionInfo) {
+	return s.history.list()
+}
+
+// Rollback restores the whole store to a version, including the
+// Desc, Comment, Tag fields and the modified states.
+//
+// A snapshot of the current tree is taken before restoring, so
+// Rollback itself can be reverted.
+//
+// Rollback fails with a [ReadOnlyError] on a read-only store, or
+// if it would change a frozen subtree.
+//
+// OnNewHandler, OnChangeHandler and OnDeleteHandler are called
+// back for the changed keys, after the tree restored. The paths
+// passed to the handlers are full paths.
+func (s *storeS) Rollback(v VersionID) (err error) {
+	if s.readOnly {
+		return &ReadOnlyError{Op: "rollback", Path: s.Prefix()}
+	}
+	snapshot, ok := s.history.find(v)
+	if !ok {
+		return errors.New("cannot rollback to version %v", v).WithErrors(ErrVersionNotFound)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	root := s.dupS(s.Trie.WithPrefixReplaced())
+	changes := diffLeaves(collectLeaves(root, ""), collectLeaves(s.dupS(snapshot.Trie()), ""))
+	for _, d := range changes {
+		if err = s.checkWritableFull("rollback", d.Path, false); err != nil {
+			return
+		}
+	}
+	s.history.push(s.Trie)
+	s.Trie.Restore(snapshot)
+
+	if s.origins != nil {
+		for _, d := range changes {
+			if d.Op == OpRemove {
+				s.origins.forget(d.Path, s.Delimiter())
+			} else {
+				s.origins.record(d.Path, s.writer())
+			}
+		}
+	}
+	s.notify(changes)
+	return
+}
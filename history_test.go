@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStoreS_Snapshot(t *testing.T) {
+	var events []string
+	conf := newBasicStore(
+		WithOnChangeHandlers(func(path string, value, oldValue any, mergingMapOrLoading bool) {
+			events = append(events, "change "+path)
+		}),
+		WithOnNewHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "new "+path)
+		}),
+		WithOnDeleteHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "delete "+path)
+		}),
+	)
+	defer conf.Close()
+	conf.SetComment("app.server.start", "desc", "comment")
+
+	v1, ro := conf.WithPrefix("app").Snapshot()
+	assertEqual(t, 5, ro.MustInt("server.start"))
+	assertEqual(t, "comment", ro.MustGetComment("server.start"))
+	_, ok := ro.(Store)
+	assertFalse(t, ok, "a snapshot should not be writable")
+
+	conf.Set("app.server.start", 6)
+	conf.SetComment("app.server.start", "", "")
+	conf.Set("app.server.port", 7999)
+	conf.Remove("app.debug")
+	assertEqual(t, 5, ro.MustInt("server.start"))
+
+	events = nil
+	if err := conf.Rollback(v1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	assertEqual(t, 5, conf.MustInt("app.server.start"))
+	assertEqual(t, "comment", conf.MustGetComment("app.server.start"))
+	assertEqual(t, false, conf.MustBool("app.debug", true))
+	assertFalse(t, conf.Has("app.server.port"))
+	assertEqual(t, []string{"new app.debug", "delete app.server.port", "change app.server.start"}, events)
+
+	h := conf.History()
+	assertEqual(t, 2, len(h))
+	assertEqual(t, v1, h[0].Version)
+	if err := conf.Rollback(h[1].Version); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	assertEqual(t, 7999, conf.MustInt("app.server.port"), "rollback should be revertable")
+
+	err := conf.Rollback(100)
+	assertTrue(t, errors.Is(err, ErrVersionNotFound), "expecting ErrVersionNotFound")
+}
+
+func TestWithHistory(t *testing.T) {
+	conf := New(WithHistory(2))
+	defer conf.Close()
+	ctx := context.TODO()
+
+	for _, port := range []int{7999, 8080, 9090} {
+		if _, err := conf.Load(ctx, WithProvider(newMapPvdr("maps", map[string]any{"app.port": port}))); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+	}
+	h := conf.History()
+	assertEqual(t, 2, len(h), "the history should be bounded")
+	if err := conf.Rollback(h[0].Version); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	assertEqual(t, 8080, conf.MustInt("app.port"))
+}
+
+func TestStoreS_RollbackProtected(t *testing.T) {
+	conf := newBasicStore()
+	defer conf.Close()
+
+	v1, _ := conf.Snapshot()
+	conf.Set("app.server.start", 6)
+	conf.Set("app.debug", true)
+
+	var roe *ReadOnlyError
+	err := conf.ReadOnly().Rollback(v1)
+	assertTrue(t, errors.As(err, &roe), "a read-only store should not rollback")
+
+	unfreeze := conf.Freeze("app.server")
+	err = conf.Rollback(v1)
+	assertTrue(t, errors.As(err, &roe), "rollback should not change a frozen subtree")
+	assertEqual(t, 6, conf.MustInt("app.server.start"))
+	assertEqual(t, true, conf.MustBool("app.debug"), "a rejected rollback should change nothing")
+	assertEqual(t, 1, len(conf.History()), "a rejected rollback should not be recorded")
+
+	unfreeze()
+	if err = conf.Rollback(v1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	assertEqual(t, 5, conf.MustInt("app.server.start"))
+}
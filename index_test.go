@@ -0,0 +1,45 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestStoreS_IndexPath(t *testing.T) {
+	for _, flatten := range []bool{false, true} {
+		conf := New(WithFlattenSlice(flatten))
+		err := conf.Merge("app", map[string]any{
+			"backends": []any{
+				map[string]any{"host": "a", "port": 80},
+				map[string]any{"host": "b", "port": 81},
+				map[string]any{"host": "c", "port": 82},
+			},
+		})
+		assertTrue(t, err == nil, err)
+
+		var deltas []Delta
+		conf.Watch("app.**", func(d Delta) { deltas = append(deltas, d) })
+
+		assertEqual(t, "c", conf.MustGet("app.backends[2].host"), "flatten", flatten)
+		assertEqual(t, "c", conf.MustString("app.backends[-1].host"), "flatten", flatten)
+		assertEqual(t, []string{"a", "b", "c"}, MustGet[[]string](conf, "app.backends[*].host"), "flatten", flatten)
+		assertEqual(t, 81, MustGet[int](conf, "app.backends[1].port"), "flatten", flatten)
+		assertTrue(t, conf.Has("app.backends[0]"))
+		assertFalse(t, conf.Has("app.backends[3]"))
+
+		conf.Set("app.backends[1].host", "bb")
+		assertEqual(t, "bb", conf.MustGet("app.backends[1].host"), "flatten", flatten)
+		assertEqual(t, 1, len(deltas))
+		assertEqual(t, "app.backends[1].host", deltas[0].Path)
+		assertEqual(t, "b", deltas[0].OldValue)
+
+		assertTrue(t, conf.Remove("app.backends[0]"), "flatten", flatten)
+		assertEqual(t, []string{"bb", "c"}, MustGet[[]string](conf, "app.backends[*].host"), "flatten", flatten)
+		assertEqual(t, 2, len(deltas))
+		assertEqual(t, OpRemove, deltas[1].Op)
+		assertEqual(t, "app.backends[0]", deltas[1].Path)
+
+		ns := conf.WithPrefix("app")
+		assertEqual(t, 82, MustGet[int](ns, "backends[-1].port"), "flatten", flatten)
+		conf.Close()
+	}
+}
@@ -0,0 +1,629 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logz "github.com/hedzr/logg/slog"
+
+	"github.com/hedzr/store/radix"
+)
+
+// JournalSync is the fsync policy of the journal, see
+// WithJournalSync.
+type JournalSync int
+
+const (
+	// JournalSyncAlways fsyncs the journal after each mutation. It's
+	// the default policy.
+	JournalSyncAlways JournalSync = iota
+	// JournalSyncInterval fsyncs the journal periodically, see
+	// WithJournalSyncInterval. The mutations in the last interval
+	// may be lost on a crash.
+	JournalSyncInterval
+	// JournalSyncNone leaves the flushing to the OS.
+	JournalSyncNone
+)
+
+const (
+	journalLogFile      = "journal.log"
+	journalSnapshotFile = "snapshot.jsonl"
+
+	defaultJournalSyncInterval = time.Second
+	defaultJournalCompactEvery = 1000
+)
+
+// JournalOpt is the options for WithJournal.
+type JournalOpt func(s *journalS)
+
+// WithJournalSync sets the fsync policy, JournalSyncAlways by
+// default.
+func WithJournalSync(policy JournalSync) JournalOpt {
+	return func(s *journalS) {
+		s.policy = policy
+	}
+}
+
+// WithJournalSyncInterval sets JournalSyncInterval policy with the
+// interval, 1s by default.
+func WithJournalSyncInterval(interval time.Duration) JournalOpt {
+	return func(s *journalS) {
+		s.policy = JournalSyncInterval
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+// WithJournalCompactEvery compacts the journal into the snapshot
+// file after every n mutations, 1000 by default. A zero n disables
+// the automatic compaction, see [Store.CompactJournal].
+func WithJournalCompactEvery(n int) JournalOpt {
+	return func(s *journalS) {
+		s.compactEvery = n
+	}
+}
+
+// WithJournal keeps the user mutations in a write-ahead journal
+// under dir, so they survive a restart.
+//
+// Set, Remove, Merge, SetComment, SetTag, and the commits of Tx
+// are appended to dir/journal.log, the loadings and the updates
+// from the watching providers are not. The journal is compacted
+// into dir/snapshot.jsonl periodically, see
+// WithJournalCompactEvery.
+//
+// The journal is replayed at once by New, and again after each
+// Load, so the runtime mutations stay on top of the loaded
+// sources:
+//
+//	conf := store.New(store.WithJournal("/var/lib/app/journal"))
+//	_, err := conf.Load(ctx, store.WithProvider(file.New("app.yml")))
+//	conf.Set("app.feature.x", true) // kept after restart
+//
+// A torn entry at the tail of journal.log, such as one being
+// written on a crash, is dropped on opening.
+//
+// The values of the builtin types, time.Duration, time.Time and the
+// slices and maps of them are journaled with their types, so an int
+// is replayed as an int, not a float64. The values of other types
+// are replayed as the plain JSON values.
+//
+// It's separate from the write-back of modified keys by
+// [Writeable.Save], which rewrites whole source files.
+func WithJournal(dir string, opts ...JournalOpt) Opt {
+	return func(s *storeS) {
+		j := &journalS{
+			dir:          dir,
+			interval:     defaultJournalSyncInterval,
+			compactEvery: defaultJournalCompactEvery,
+			state:        make(map[string]*journalEntry),
+		}
+		for _, opt := range opts {
+			opt(j)
+		}
+		s.journal = j
+	}
+}
+
+// CompactJournal writes the effective mutations into the snapshot
+// file, and truncates the journal. It's a no-op without
+// WithJournal.
+func (s *storeS) CompactJournal() (err error) {
+	if s.journal == nil {
+		return
+	}
+	s.journal.mu.Lock()
+	defer s.journal.mu.Unlock()
+	return s.journal.compact()
+}
+
+// openJournal opens the journal and replays it, it's called by
+// newStore after the options applied.
+func (s *storeS) openJournal() {
+	j := s.journal
+	j.delim = string(s.Delimiter())
+	if err := j.open(); err != nil {
+		logz.Error("[store] cannot open journal", "dir", j.dir, "err", err)
+		s.journal = nil
+		return
+	}
+	s.closers = append(s.closers, j)
+	s.replayJournal()
+}
+
+// replayJournal applies the journal to the store, without
+// journaling them again.
+func (s *storeS) replayJournal() {
+	if s.journal == nil {
+		return
+	}
+	root := s.dupS(s.Trie.WithPrefixReplaced())
+	atomic.StoreInt32(&root.loading, 1)
+	for _, e := range s.journal.entries() {
+		switch e.Op {
+		case journalSet:
+			root.setKV(e.Path, e.Value, true, nil)
+		case journalRemove:
+			root.Remove(e.Path)
+		case journalComment:
+			root.Trie.SetComment(e.Path, e.Desc, e.Comment)
+		case journalTag:
+			root.Trie.SetTag(e.Path, e.Value)
+		}
+	}
+}
+
+// journaling tests if the mutations of the view should be
+// journaled.
+func (s *storeS) journaling() bool {
+	return s.journal != nil && !s.inLoading() && !s.fromProvider
+}
+
+// journalDelta records a change by its full path.
+func (s *storeS) journalDelta(d Delta) {
+	if d.Op == OpRename {
+		s.journal.record(&journalEntry{Op: journalRemove, Path: strings.TrimSuffix(d.OldPath, string(s.Delimiter()))})
+	}
+	e := &journalEntry{Op: journalSet, Path: strings.TrimSuffix(d.Path, string(s.Delimiter())), Value: d.NewValue}
+	if d.Op == OpRemove {
+		e.Op, e.Value = journalRemove, nil
+	}
+	s.journal.record(e)
+}
+
+// SetComment sets the description and comment of the key at path.
+func (s *storeS) SetComment(path, description, comment string) (ok bool) {
+	if !s.writable("set comment", path, false) {
+		return
+	}
+	if ok = s.Trie.SetComment(path, description, comment); ok && s.journaling() {
+		s.journal.record(&journalEntry{Op: journalComment, Path: s.join(s.Prefix(), path), Desc: description, Comment: comment})
+	}
+	return
+}
+
+// SetTag sets the tag of the key at path.
+func (s *storeS) SetTag(path string, tags any) (ok bool) {
+	if !s.writable("set tag", path, false) {
+		return
+	}
+	if ok = s.Trie.SetTag(path, tags); ok && s.journaling() {
+		s.journal.record(&journalEntry{Op: journalTag, Path: s.join(s.Prefix(), path), Value: tags})
+	}
+	return
+}
+
+//
+
+const (
+	journalSet     = "set"
+	journalRemove  = "remove"
+	journalComment = "comment"
+	journalTag     = "tag"
+)
+
+type journalEntry struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Path    string    `json:"path"` // full path
+	Value   any       `json:"value,omitempty"`
+	Desc    string    `json:"desc,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+// key is the key of the entry in the effective state, the set and
+// remove entries of a path replace each other.
+func (e *journalEntry) key() string {
+	if e.Op == journalComment || e.Op == journalTag {
+		return e.Path + "\x00" + e.Op
+	}
+	return e.Path
+}
+
+// MarshalJSON writes the value with its type, see typedValue.
+func (e *journalEntry) MarshalJSON() ([]byte, error) {
+	type plain journalEntry
+	tv, err := newTypedValue(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	p := plain(*e)
+	p.Value = nil
+	return json.Marshal(struct {
+		plain
+		Typed *typedValue `json:"typed,omitempty"`
+	}{p, tv})
+}
+
+// UnmarshalJSON restores the typed value. The entries written by
+// the older versions keep their plain JSON value.
+func (e *journalEntry) UnmarshalJSON(data []byte) (err error) {
+	type plain journalEntry
+	var aux struct {
+		plain
+		Typed *typedValue `json:"typed"`
+	}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+	*e = journalEntry(aux.plain)
+	if aux.Typed != nil {
+		e.Value, err = aux.Typed.value()
+	}
+	return
+}
+
+// typedValue is a journaled value with the name of its type. The
+// items of []any and map[string]any are typedValue's too.
+type typedValue struct {
+	Type  string          `json:"t,omitempty"` // empty for the plain JSON values
+	Value json.RawMessage `json:"v"`
+}
+
+var (
+	typeOfList = reflect.TypeOf([]any(nil))
+	typeOfMap  = reflect.TypeOf(map[string]any(nil))
+
+	// journalTypes are the types restored by typedValue.
+	journalTypes = func() map[string]reflect.Type {
+		m := make(map[string]reflect.Type)
+		for _, v := range []any{
+			false, "", int(0), int8(0), int16(0), int32(0), int64(0),
+			uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+			float32(0), float64(0), time.Duration(0), time.Time{},
+			[]bool(nil), []string(nil), []int(nil), []int64(nil),
+			[]uint(nil), []uint64(nil), []float64(nil),
+			[]time.Duration(nil), map[string]string(nil),
+		} {
+			t := reflect.TypeOf(v)
+			m[t.String()] = t
+		}
+		return m
+	}()
+)
+
+func newTypedValue(v any) (tv *typedValue, err error) {
+	if v == nil {
+		return
+	}
+	var items any
+	t := reflect.TypeOf(v)
+	switch vv := v.(type) {
+	case []any:
+		list := make([]*typedValue, len(vv))
+		for i, it := range vv {
+			if list[i], err = newTypedValue(it); err != nil {
+				return
+			}
+		}
+		items = list
+	case map[string]any:
+		m := make(map[string]*typedValue, len(vv))
+		for k, it := range vv {
+			if m[k], err = newTypedValue(it); err != nil {
+				return
+			}
+		}
+		items = m
+	default:
+		items = v
+		if _, ok := journalTypes[t.String()]; !ok {
+			t = nil
+		}
+	}
+
+	tv = new(typedValue)
+	if t != nil {
+		tv.Type = t.String()
+	}
+	tv.Value, err = json.Marshal(items)
+	return
+}
+
+func (tv *typedValue) value() (v any, err error) {
+	if tv == nil {
+		return
+	}
+	switch tv.Type {
+	case "":
+		err = json.Unmarshal(tv.Value, &v)
+	case typeOfList.String():
+		var items []*typedValue
+		if err = json.Unmarshal(tv.Value, &items); err != nil {
+			return
+		}
+		list := make([]any, len(items))
+		for i, it := range items {
+			if list[i], err = it.value(); err != nil {
+				return
+			}
+		}
+		v = list
+	case typeOfMap.String():
+		var items map[string]*typedValue
+		if err = json.Unmarshal(tv.Value, &items); err != nil {
+			return
+		}
+		m := make(map[string]any, len(items))
+		for k, it := range items {
+			if m[k], err = it.value(); err != nil {
+				return
+			}
+		}
+		v = m
+	default:
+		t, ok := journalTypes[tv.Type]
+		if !ok {
+			err = json.Unmarshal(tv.Value, &v)
+			return
+		}
+		p := reflect.New(t)
+		if err = json.Unmarshal(tv.Value, p.Interface()); err == nil {
+			v = p.Elem().Interface()
+		}
+	}
+	return
+}
+
+type journalS struct {
+	dir          string
+	policy       JournalSync
+	interval     time.Duration
+	compactEvery int
+	delim        string
+
+	mu       sync.Mutex
+	file     *os.File
+	seq      uint64
+	appended int  // since the last compaction
+	dirty    bool // not fsync'ed yet
+	state    map[string]*journalEntry
+	done     chan struct{}
+}
+
+func (s *journalS) open() (err error) {
+	if err = os.MkdirAll(s.dir, 0o700); err != nil {
+		return
+	}
+
+	var snapshot []*journalEntry
+	if snapshot, _, err = readJournal(filepath.Join(s.dir, journalSnapshotFile)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	for _, e := range snapshot {
+		s.apply(e)
+	}
+
+	logFile := filepath.Join(s.dir, journalLogFile)
+	var entries []*journalEntry
+	var good int64
+	if entries, good, err = readJournal(logFile); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	base := s.seq
+	for _, e := range entries {
+		if e.Seq > base { // the older ones are in the snapshot already
+			s.apply(e)
+			s.appended++
+		}
+	}
+
+	if s.file, err = os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY, 0o600); err != nil {
+		return
+	}
+	if err = s.file.Truncate(good); err != nil { // drop the torn tail
+		return
+	}
+	if _, err = s.file.Seek(good, io.SeekStart); err != nil {
+		return
+	}
+
+	if s.policy == JournalSyncInterval {
+		s.done = make(chan struct{})
+		go s.syncLoop()
+	}
+	return
+}
+
+// readJournal reads the entries from a file, and returns the size
+// of the valid part of it.
+func readJournal(path string) (entries []*journalEntry, good int64, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, e := r.ReadBytes('\n')
+		if e != nil { // EOF, a line without '\n' is torn
+			break
+		}
+		entry := new(journalEntry)
+		if json.Unmarshal(line, entry) != nil {
+			logz.Warn("[store] broken journal entry dropped", "file", path, "offset", good)
+			break
+		}
+		entries = append(entries, entry)
+		good += int64(len(line))
+	}
+	return
+}
+
+// record appends an entry to the journal.
+func (s *journalS) record(e *journalEntry) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return
+	}
+
+	e.Seq, e.Time = s.seq+1, time.Now()
+	line, err := json.Marshal(e)
+	if err != nil {
+		logz.Error("[store] cannot journal the mutation", "path", e.Path, "err", err)
+		return
+	}
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		logz.Error("[store] cannot write journal", "path", e.Path, "err", err)
+		return
+	}
+	switch s.policy {
+	case JournalSyncAlways:
+		if err = s.file.Sync(); err != nil {
+			logz.Error("[store] cannot sync journal", "err", err)
+		}
+	case JournalSyncInterval:
+		s.dirty = true
+	}
+
+	s.apply(e)
+	s.appended++
+	if s.compactEvery > 0 && s.appended >= s.compactEvery {
+		if err = s.compact(); err != nil {
+			logz.Error("[store] cannot compact journal", "err", err)
+		}
+	}
+}
+
+// apply merges an entry into the effective state.
+func (s *journalS) apply(e *journalEntry) {
+	if e.Seq > s.seq {
+		s.seq = e.Seq
+	}
+	if e.Op == journalRemove {
+		prefix := e.Path + s.delim
+		for k, it := range s.state {
+			if it.Path == e.Path || strings.HasPrefix(it.Path, prefix) {
+				delete(s.state, k)
+			}
+		}
+	}
+	s.state[e.key()] = e
+}
+
+// rekey re-keys the effective state with a new delimiter, and
+// compacts it into the snapshot, so the entries under the old
+// delimiter are dropped.
+func (s *journalS) rekey(from, to rune) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := make(map[string]*journalEntry, len(s.state))
+	for _, e := range s.state {
+		e.Path = radix.RekeyPath(e.Path, from, to)
+		state[e.key()] = e
+	}
+	s.state, s.delim = state, string(to)
+	return s.compact()
+}
+
+// entries returns the effective entries in order.
+func (s *journalS) entries() (list []*journalEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sorted()
+}
+
+func (s *journalS) sorted() (list []*journalEntry) {
+	list = make([]*journalEntry, 0, len(s.state))
+	for _, e := range s.state {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Seq < list[j].Seq })
+	return
+}
+
+// compact writes the state into the snapshot file, and truncates
+// the journal. A crash in between is safe, the entries in the
+// journal older than the snapshot are skipped on opening.
+func (s *journalS) compact() (err error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range s.sorted() {
+		if err = enc.Encode(e); err != nil {
+			return
+		}
+	}
+
+	tmp := filepath.Join(s.dir, journalSnapshotFile+".tmp")
+	if err = writeFileSync(tmp, buf.Bytes()); err != nil {
+		return
+	}
+	if err = os.Rename(tmp, filepath.Join(s.dir, journalSnapshotFile)); err != nil {
+		return
+	}
+
+	if err = s.file.Truncate(0); err == nil {
+		_, err = s.file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = s.file.Sync()
+	}
+	s.appended, s.dirty = 0, false
+	return
+}
+
+func writeFileSync(path string, data []byte) (err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600); err != nil {
+		return
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return
+}
+
+func (s *journalS) syncLoop() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && s.file != nil {
+				if err := s.file.Sync(); err != nil {
+					logz.Error("[store] cannot sync journal", "err", err)
+				}
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close flushes and closes the journal.
+func (s *journalS) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+	if s.file != nil {
+		_ = s.file.Sync()
+		_ = s.file.Close()
+		s.file = nil
+	}
+}
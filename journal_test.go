@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreS_Journal(t *testing.T) {
+	dir := t.TempDir()
+	src := `{"app":{"server":{"port":80,"host":"a"},"name":"demo"}}`
+
+	conf := New(WithJournal(dir))
+	if _, err := conf.Load(context.TODO(), WithProvider(&watchableS{data: src})); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	conf.Set("app.server.port", 8080)
+	conf.Remove("app.server.host")
+	_ = conf.Merge("app", map[string]any{"feature": map[string]any{"x": true}})
+	conf.SetComment("app.name", "the name", "")
+	_ = conf.Tx(func(tx Store) error {
+		tx.Set("app.mode", "prod")
+		return nil
+	})
+	_ = conf.Tx(func(tx Store) error {
+		tx.Set("app.discarded", 1)
+		return ErrTxEmpty
+	})
+	conf.Close()
+
+	// restart
+	conf = New(WithJournal(dir))
+	defer conf.Close()
+	if _, err := conf.Load(context.TODO(), WithProvider(&watchableS{data: src})); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertEqual(t, 8080, conf.MustInt("app.server.port"), "replayed on top of the loaded source")
+	assertFalse(t, conf.Has("app.server.host"))
+	assertTrue(t, conf.MustBool("app.feature.x"))
+	assertEqual(t, "prod", conf.MustString("app.mode"))
+	assertFalse(t, conf.Has("app.discarded"))
+	assertEqual(t, "the name", conf.MustGetDesc("app.name"))
+	assertEqual(t, "demo", conf.MustString("app.name"), "the loaded keys are not journaled")
+}
+
+func TestStoreS_JournalCompact(t *testing.T) {
+	dir := t.TempDir()
+	conf := New(WithJournal(dir, WithJournalCompactEvery(3), WithJournalSync(JournalSyncNone)))
+	for i := 0; i < 5; i++ {
+		conf.Set("app.counter", i)
+	}
+	conf.Set("app.tmp", 1)
+	conf.Remove("app.tmp")
+	conf.Close()
+
+	snapshot, _, err := readJournal(filepath.Join(dir, journalSnapshotFile))
+	assertTrue(t, err == nil)
+	assertTrue(t, len(snapshot) > 0, "compacted")
+	entries, _, _ := readJournal(filepath.Join(dir, journalLogFile))
+	assertTrue(t, len(entries) < 3)
+
+	// a torn tail, such as on a crash
+	f, err := os.OpenFile(filepath.Join(dir, journalLogFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	assertTrue(t, err == nil)
+	_, _ = f.WriteString(`{"seq":100,"op":"set","path":"app.torn","val`)
+	_ = f.Close()
+
+	conf = New(WithJournal(dir))
+	defer conf.Close()
+	assertEqual(t, 4, conf.MustInt("app.counter"))
+	assertFalse(t, conf.Has("app.tmp"))
+	assertFalse(t, conf.Has("app.torn"))
+	conf.Set("app.after", true)
+	assertTrue(t, conf.CompactJournal() == nil)
+
+	conf2 := New(WithJournal(dir))
+	defer conf2.Close()
+	assertTrue(t, conf2.MustBool("app.after"))
+}
+
+func TestStoreS_JournalTypes(t *testing.T) {
+	dir := t.TempDir()
+	values := map[string]any{
+		"app.port":    8080,
+		"app.ratio":   1.5,
+		"app.debug":   true,
+		"app.hosts":   []string{"a", "b"},
+		"app.listen":  []int{80, 443},
+		"app.timeout": 3 * time.Second,
+		"app.mixed":   []any{1, "x", nil, []string{"y"}},
+		"app.labels":  map[string]any{"n": int64(1), "s": "v"},
+	}
+
+	conf := New(WithJournal(dir))
+	for k, v := range values {
+		conf.Set(k, v)
+	}
+	before := conf.Dup()
+	conf.Close()
+
+	check := func(conf Store) {
+		for k, v := range values {
+			assertEqual(t, v, conf.MustGet(k), k)
+		}
+		assertEqual(t, 8080, MustGet[int](conf, "app.port"))
+		assertEqual(t, []string{"a", "b"}, MustGet[[]string](conf, "app.hosts"))
+		assertEqual(t, 0, len(Diff(before, conf, "")))
+	}
+
+	conf = New(WithJournal(dir))
+	check(conf)
+	assertTrue(t, conf.CompactJournal() == nil)
+	conf.Close()
+
+	conf = New(WithJournal(dir))
+	defer conf.Close()
+	check(conf)
+}
+
+func TestStoreS_JournalChangeDelimiter(t *testing.T) {
+	dir := t.TempDir()
+	conf := New(WithJournal(dir))
+	conf.Set("app.host", "a")
+	conf.SetComment("app.host", "the host", "")
+	conf.Set("app.port", 80)
+	conf.Remove("app.port")
+	assertTrue(t, conf.ChangeDelimiter('/') == nil)
+	conf.Set("app/name", "demo")
+	conf.Close()
+
+	for _, file := range []string{journalSnapshotFile, journalLogFile} {
+		entries, _, err := readJournal(filepath.Join(dir, file))
+		assertTrue(t, err == nil)
+		for _, e := range entries {
+			assertFalse(t, strings.Contains(e.Path, "."), e.Path)
+		}
+	}
+
+	conf = New(WithDelimiter('/'), WithJournal(dir))
+	defer conf.Close()
+	assertEqual(t, "a", conf.MustString("app/host"))
+	assertEqual(t, "the host", conf.MustGetDesc("app/host"))
+	assertEqual(t, "demo", conf.MustString("app/name"))
+	assertFalse(t, conf.Has("app/port"))
+	assertFalse(t, conf.Has("app.host"))
+}
@@ -3,8 +3,11 @@ package store
 import (
 	"context"
 	"encoding/json"
-	"io"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -34,6 +37,7 @@ type SaveAsOption struct {
 	comment  bool
 	codec    Codec
 	provider Provider
+	position string
 }
 
 func WithSaveAsComment(includeComment bool) SaveAsOpt {
@@ -48,30 +52,140 @@ func WithSaveAsProvider(p Provider) SaveAsOpt {
 	return func(s *SaveAsOption) { s.provider = p }
 }
 
+// WithSaveAsPosition exports the subtree at 'position' instead
+// of the whole tree. The keys in the output are relative to it.
+func WithSaveAsPosition(position string) SaveAsOpt {
+	return func(s *SaveAsOption) { s.position = position }
+}
+
+// SaveAs writes the whole store (or a subtree, see
+// WithSaveAsPosition) into 'outfile'.
+//
+// The codec is picked in this order: WithSaveAsCodec, the codec
+// bound to WithSaveAsProvider, the codec of the Loader, and the
+// codec registered for the extension of 'outfile' (see
+// RegisterCodec). So a Loader with an encrypting codec never
+// saves the plain text into a ".json" file.
+//
+// If a provider is given, the encoded data will be written by
+// it. Or else 'outfile' will be created or truncated.
+//
+// WithSaveAsComment(true) (it's the default) tells SaveAs to
+// emit Desc and Comment fields of nodes, if the codec
+// implements CodecEx.
 func (s *storeS) SaveAs(ctx context.Context, outfile string, opts ...SaveAsOpt) (err error) {
+	return s.saveAs(ctx, outfile, nil, opts...)
+}
+
+// saveAs is SaveAs, 'codec' is the codec of a Loader, or nil.
+func (s *storeS) saveAs(ctx context.Context, outfile string, codec Codec, opts ...SaveAsOpt) (err error) {
 	saver := &SaveAsOption{comment: true}
 	for _, opt := range opts {
 		opt(saver)
 	}
-	// // if saver.provider == nil {
-	// // 	saver.provider = file.New(outfile)
-	// // }
-	// if saver.provider != nil {
-	// 	if saver.codec == nil {
-	// 		saver.codec = saver.provider.GetCodec()
-	// 	} else {
-	// 		saver.provider.WithCodec(saver.codec)
-	// 	}
-	// 	// if saver.position == "" {
-	// 	// 	saver.position = saver.provider.GetPosition()
-	// 	// } else {
-	// 	// 	saver.provider.WithPosition(saver.position)
-	// 	// }
-	// }
-	// // saver.provider.Wr
+	if saver.provider != nil {
+		if saver.codec == nil {
+			saver.codec = saver.provider.GetCodec()
+		} else {
+			saver.provider.WithCodec(saver.codec)
+		}
+	}
+	if saver.codec == nil {
+		saver.codec = codec
+	}
+	if saver.codec == nil {
+		if getter, ok := CodecByExt(filepath.Ext(outfile)); ok {
+			saver.codec = getter()
+		}
+	}
+	if saver.codec == nil {
+		return errors.New("cannot save as %q", outfile).WithErrors(ErrCodecNotFound)
+	}
+
+	logz.DebugContext(ctx, "full-store saving as", "file", outfile, "position", saver.position)
+
+	var data []byte
+	if data, err = s.marshalForSave(saver); err != nil {
+		return
+	}
+
+	if saver.provider == nil {
+		if err = ctx.Err(); err == nil {
+			err = os.WriteFile(outfile, data, 0644)
+		}
+		return
+	}
+
+	err = writeProvider(ctx, saver.provider, data)
 	return
 }
 
+func (s *storeS) marshalForSave(saver *SaveAsOption) (data []byte, err error) {
+	if saver.comment {
+		if cex, ok := saver.codec.(CodecEx); ok {
+			return cex.MarshalEx(s.exportValPkg(saver.position))
+		}
+	}
+
+	var m map[string]any
+	if m, err = s.GetM(saver.position, WithoutFlattenKeys[any](true), WithRawValues[any](true)); err != nil {
+		return
+	}
+	if m == nil {
+		m = make(map[string]any)
+	}
+	return saver.codec.Marshal(m)
+}
+
+// exportValPkg collects the leaves under 'position' as a nested
+// map. The value of a branch entry is a map[string]ValPkg, which
+// is the same shape that CodecEx.UnmarshalEx returns.
+func (s *storeS) exportValPkg(position string) (m map[string]ValPkg) {
+	m = make(map[string]ValPkg)
+	delim := string(s.Delimiter())
+	base := s.join(s.Prefix(), position)
+	s.Trie.Walk("", func(path, fragment string, node radix.Node[any]) {
+		if path == "" || strings.HasSuffix(path, delim) || node.IsBranch() {
+			return
+		}
+		rel := path
+		if base != "" {
+			if path == base {
+				keys := radix.SplitPath(path, s.Delimiter())
+				rel = radix.EscapeKey(keys[len(keys)-1], s.Delimiter())
+			} else if strings.HasPrefix(path, base+delim) {
+				rel = path[len(base)+1:]
+			} else {
+				return
+			}
+		}
+		putValPkg(m, radix.SplitPath(rel, s.Delimiter()), ValPkg{
+			Value:   node.Data(),
+			Desc:    node.Description(),
+			Comment: node.Comment(),
+			Tag:     node.Tag(),
+		})
+	})
+	return
+}
+
+func putValPkg(m map[string]ValPkg, keys []string, vp ValPkg) {
+	k := keys[0]
+	if len(keys) == 1 {
+		m[k] = vp
+		return
+	}
+	sub, ok := m[k].Value.(map[string]ValPkg)
+	if !ok {
+		if _, exists := m[k]; exists {
+			return // a leaf is holding this key already
+		}
+		sub = make(map[string]ValPkg)
+		m[k] = ValPkg{Value: sub}
+	}
+	putValPkg(sub, keys[1:], vp)
+}
+
 // Load loads an external data source by the specified Provider,
 // a Codec parser is optional.
 //
@@ -89,6 +203,9 @@ func (s *storeS) SaveAs(ctx context.Context, outfile string, opts ...SaveAsOpt)
 //	   t.Fatalf("failed: %v", err)
 //	}
 func (s *storeS) Load(ctx context.Context, opts ...LoadOpt) (wr Writeable, err error) {
+	if s.readOnly {
+		return nil, &ReadOnlyError{Op: "load", Path: s.Prefix()}
+	}
 	if atomic.CompareAndSwapInt32(&s.loading, 0, 1) {
 		defer func() { atomic.CompareAndSwapInt32(&s.loading, 1, 0) }()
 
@@ -98,39 +215,83 @@ func (s *storeS) Load(ctx context.Context, opts ...LoadOpt) (wr Writeable, err e
 			*loader.copy = loader
 		}
 
-		var data map[string]ValPkg
-		var bin map[string]any
-		data, bin, err = loader.tryLoad(ctx) // load dataset from source via loader
-		if err != nil {
+		var ok bool
+		load := func() (err error) {
+			lctx, cancel := loader.withTimeout(ctx)
+			defer cancel()
+			ok, err = loader.load(lctx)
 			return
 		}
-
-		// merge dataset into store
-		prefix := loader.Prefix()
-		ok := false
-		if data != nil {
-			if err = loader.loadMapDedicated(data, prefix, true); err != nil {
-				return
-			}
-			ok = true
+		if s.origins != nil && loader.provider != nil {
+			// the writings of loader, and its reloads, are attributed
+			// to its layer by a view
+			loader.origin = s.origins.newLayer(loader.provider)
+			st := loader.storeS.dupS(loader.storeS.Trie)
+			st.parent, st.origin = loader.storeS, &loader.origin
+			loader.storeS = st
 		}
-		if bin != nil {
-			if err = loader.loadMap(bin, prefix, true, nil); err != nil {
-				return
-			}
-			ok = true
+		if err = load(); err != nil {
+			return
 		}
 
 		if ok {
+			s.replayJournal() // keep the runtime mutations on top
+			s.history.autoPush(s.Trie)
 			wr = loader
 			if !loader.noWatch {
-				loader.startWatch(ctx, loader)
+				s.startWatch(ctx, loader) // the closers go to s, not to the view of loader
 			}
 		}
 	}
 	return
 }
 
+// load reads the dataset from source and merges it into store.
+//
+// With the schemas, the dataset is checked on a fork of the tree
+// before merging, so an invalid dataset changes nothing and fires
+// no events. See checkLoaded.
+func (s *Loader) load(ctx context.Context) (ok bool, err error) {
+	var data map[string]ValPkg
+	var bin map[string]any
+	data, bin, err = s.tryLoad(ctx) // load dataset from source via loader
+	if err != nil {
+		return
+	}
+
+	if err = s.checkLoaded(data, bin); err != nil {
+		return
+	}
+	if _, watchable := s.provider.(Watchable); watchable && s.allowWatch && !s.noWatch {
+		s.leaves = s.sourceLeaves(data, bin) // for reloading, see applyReload
+	}
+	if ok, err = s.mergeLoaded(data, bin); ok && err == nil && len(s.schemas) > 0 {
+		s.applySchemaDefaults()
+	}
+	return
+}
+
+// mergeLoaded merges the dataset loaded from source into store.
+func (s *storeS) mergeLoaded(data map[string]ValPkg, bin map[string]any) (ok bool, err error) {
+	prefix := s.Prefix()
+	if s.merge.strategy == MergeReplaceSubtree {
+		s.removeSubtrees(prefix, data, bin)
+	}
+	if data != nil {
+		if err = s.loadMapDedicated(data, prefix, true); err != nil {
+			return
+		}
+		ok = true
+	}
+	if bin != nil {
+		if err = s.loadMap(bin, prefix, true, nil); err != nil {
+			return
+		}
+		ok = true
+	}
+	return
+}
+
 // func (s *storeS) Save(ctx context.Context, wr Writeable, opts ...LoadOpt) (err error) {
 // 	if atomic.CompareAndSwapInt32(&s.saving, 0, 1) {
 // 		defer func() { atomic.CompareAndSwapInt32(&s.saving, 1, 0) }()
@@ -161,7 +322,7 @@ func (s *storeS) loadMapAny(m map[any]any, position string, creating bool, onSet
 	defer ec.Defer(&err)
 	cvt := evendeep.Cvt{}
 	for k, v := range m {
-		s.loadMapByValueType(ec, position, cvt.String(k), v, creating, onSet)
+		s.loadMapByValueType(ec, position, s.mapKey(cvt.String(k)), v, creating, onSet)
 	}
 	return
 }
@@ -170,15 +331,26 @@ func (s *storeS) loadMap(m map[string]any, position string, creating bool, onSet
 	ec := errors.New()
 	defer ec.Defer(&err)
 	for k, v := range m {
-		s.loadMapByValueType(ec, position, k, v, creating, onSet)
+		s.loadMapByValueType(ec, position, s.mapKey(k), v, creating, onSet)
 	}
 	return
 }
 
+// mapKey returns the path of a map key, which is nested if the
+// key holds the delimiters. See WithLiteralKeys.
+func (s *storeS) mapKey(k string) string {
+	if s.literalKeys {
+		return radix.EscapeKey(k, s.Delimiter())
+	}
+	return radix.NormalizePath(k, s.Delimiter())
+}
+
 func privateSetter(ss *storeS, position, k string, v any, creating bool, onSet lmOnSet) {
 	set := ss.WithPrefixReplaced(position).(*storeS)
 	defer func() { atomic.StoreInt32(&set.loading, 0) }()
-	set.setKV(k, v, creating, onSet)
+	if v, ok := set.mergeValue(k, v); ok {
+		set.setKV(k, v, creating, onSet)
+	}
 }
 
 func (s *storeS) loadMapByValueType(ec errors.Error, position, k string, v any, creating bool, onSet lmOnSet) {
@@ -186,6 +358,10 @@ func (s *storeS) loadMapByValueType(ec errors.Error, position, k string, v any,
 	case ValPkg:
 		s.loadMapByValueType(ec, position, k, vv.Value, creating, onSet)
 	case map[string]any:
+		if s.isMapLeaf(s.join(position, k)) {
+			privateSetter(s, position, k, v, creating, onSet)
+			break
+		}
 		ec.Attach(s.loadMap(vv, s.join(position, k), creating, onSet))
 	case map[any]any:
 		ec.Attach(s.loadMapAny(vv, s.join(position, k), creating, onSet))
@@ -253,6 +429,12 @@ func (s *storeS) loadMapByValueType(ec errors.Error, position, k string, v any,
 		// }); ok {
 		// 	cc.setKV(k, v, creating, onSet)
 		// }
+	case nil:
+		if s.merge.deleteOnNull {
+			s.WithPrefixReplaced(position).Remove(k)
+			break
+		}
+		privateSetter(s, position, k, v, creating, onSet)
 	default:
 		privateSetter(s, position, k, v, creating, onSet)
 
@@ -302,7 +484,8 @@ func (s *storeS) startWatch(ctx context.Context, loader *Loader) {
 		return
 	}
 	if w, ok := loader.provider.(Watchable); ok {
-		if err := w.Watch(ctx, s.applyExternalChanges); err != nil {
+		cb := func(event any, err error) { loader.applyExternalChanges(ctx, event, err) }
+		if err := w.Watch(ctx, cb); err != nil {
 			logz.Error("[Watcher.StartWatch.ERROR]", "err", err)
 		} else {
 			s.closers = append(s.closers, w)
@@ -310,17 +493,6 @@ func (s *storeS) startWatch(ctx context.Context, loader *Loader) {
 	}
 }
 
-func (s *storeS) applyExternalChanges(event any, err error) {
-	if err != nil {
-		logz.Error("[Watcher.ERROR]", "err", err)
-		return
-	}
-
-	if fse, ok := event.(Change); ok {
-		s.applyChanges(fse)
-	}
-}
-
 func (s *storeS) applyChanges(ev Change) {
 	// if err := s.Load(WithProvider(ev.Provider())); err != nil {
 	// 	logz.Error("[Watcher.applyChanges]", "err", err)
@@ -356,6 +528,7 @@ func (s *storeS) applyChanges(ev Change) {
 			logz.Debug("renamed/chmod'ed: ", key, val, "event", ev.Op())
 		}
 	}
+	s.history.autoPush(s.Trie)
 }
 
 //
@@ -367,6 +540,7 @@ func (s *storeS) applyChanges(ev Change) {
 func newLoader(st *storeS, opts ...LoadOpt) *Loader {
 	loader := &Loader{
 		storeS:   st,
+		owner:    st,
 		codec:    nil,
 		provider: nil,
 	}
@@ -389,6 +563,14 @@ func newLoader(st *storeS, opts ...LoadOpt) *Loader {
 		}
 	}
 
+	if loader.strategy != nil {
+		// the merging reads the strategy from the store, so the
+		// loader writes through a view carrying it
+		st := loader.storeS.dupS(loader.storeS.Trie)
+		st.merge = mergeS{strategy: *loader.strategy}
+		loader.storeS = st
+	}
+
 	if loader.copy != nil {
 		*loader.copy = loader
 	}
@@ -404,6 +586,16 @@ type Loader struct {
 	provider Provider
 	noWatch  bool
 	copy     **Loader
+	origin   Origin // the layer of this loader, see WithOriginTracking
+
+	owner        *storeS        // the store which Load was invoked on, holds the closers
+	strategy     *MergeStrategy // see WithMergeStrategy
+	leaves       map[string]any // the keys loaded from the source, for reloading
+	removePolicy RemovePolicy
+	removeGrace  time.Duration
+	removeTimer  *time.Timer // see RemoveWait
+	reloadMu     sync.Mutex
+	timeout      time.Duration // see WithTimeout
 }
 
 type LoadOpt func(*Loader) // options for loadS
@@ -495,6 +687,12 @@ func WithoutFlattenKeys[T any](b bool) radix.MOpt[T] {
 	return radix.WithoutFlattenKeys[T](b)
 }
 
+// WithRawValues returns the stored values without interpolation,
+// see WithInterpolation.
+func WithRawValues[T any](b bool) radix.MOpt[T] {
+	return radix.WithRawValues[T](b)
+}
+
 // tryLoad inspect the provider's api, try reading settings in the best way.
 //
 // See also [storeS.Load].
@@ -503,10 +701,8 @@ func (s *Loader) tryLoad(ctx context.Context) (data map[string]ValPkg, bin map[s
 		return
 	}
 
-	_ = ctx
-
 	// try Read() at first
-	data, err = s.provider.Read()
+	data, err = readProvider(ctx, s.provider)
 	if err == nil {
 		return // Read ok, return the data directly
 	}
@@ -517,10 +713,13 @@ func (s *Loader) tryLoad(ctx context.Context) (data map[string]ValPkg, bin map[s
 		// the 2nd is OnceProvider and/or StreamProvider
 		switch fp := s.provider.(type) {
 		case OnceProvider:
-			b, err = fp.ReadBytes()
+			b, err = readProviderBytes(ctx, fp)
 		case StreamProvider:
 			err = nil
 			for {
+				if err = ctx.Err(); err != nil {
+					return
+				}
 				k, eol := fp.Next()
 				if eol {
 					break
@@ -544,69 +743,18 @@ func (s *Loader) tryLoad(ctx context.Context) (data map[string]ValPkg, bin map[s
 	return
 }
 
+// SaveAs writes the whole store into 'outfile'.
+//
+// It works like [storeS.SaveAs], but the loader's codec is used
+// if no codec can be picked from the options or 'outfile'.
 func (s *Loader) SaveAs(ctx context.Context, outfile string, opts ...SaveAsOpt) (err error) {
-	saver := &SaveAsOption{comment: true}
-	for _, opt := range opts {
-		opt(saver)
-	}
-	if saver.provider != nil {
-		if saver.codec == nil {
-			saver.codec = saver.provider.GetCodec()
-		} else {
-			saver.provider.WithCodec(saver.codec)
-		}
-		// if saver.position == "" {
-		// 	saver.position = saver.provider.GetPosition()
-		// } else {
-		// 	saver.provider.WithPosition(saver.position)
-		// }
-	}
-
-	_ = ctx
-	if saver.codec != nil && saver.provider != nil {
-		// logz.InfoContext(ctx, "full-store saving", "position", saver.position)
-		var m map[string]any
-		logz.DebugContext(ctx, "full-store saving as", "src", saver.provider)
-		if m, err = s.GetM("",
-			// WithFilter(func(node radix.Node[any]) bool {
-			// 	return node.Modified() // && !strings.HasPrefix(node.Key(), "app.cmd.")
-			// }),
-			// // WithKeepPrefix[any](true),
-			WithoutFlattenKeys[any](true),
-		); err == nil && m != nil && len(m) > 0 {
-			logz.DebugContext(ctx, "full-store exported", "src", saver.provider)
-			var data []byte
-
-			// if cex, ok := saver.codec.(interface {
-			// 	MarshalEx(m map[string]ValPkg) (data []byte, err error)
-			// 	UnmarshalEx(b []byte) (data map[string]ValPkg, err error)
-			// }); ok {
-			// 	var m= make(map[string]ValPkg)
-			// 	data, err = cex.MarshalEx(m)
-			// }
-
-			if data, err = saver.codec.Marshal(m); err == nil {
-				switch fp := saver.provider.(type) {
-				case OnceProvider:
-					err = fp.Write(data)
-				default:
-					err = ErrNotImplemented
-				}
-
-				if errors.Is(err, ErrNotImplemented) {
-					if wr, ok := saver.provider.(io.Writer); ok {
-						_, err = wr.Write(data)
-					}
-				}
-			}
-		}
-	}
-	return
+	return s.storeS.saveAs(ctx, outfile, s.codec, opts...)
 }
 
 func (s *Loader) Save(ctx context.Context) (err error) { return s.trySave(ctx) }
 func (s *Loader) trySave(ctx context.Context) (err error) {
-	_ = ctx
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 	if s.codec != nil && s.provider != nil {
 		// logz.InfoContext(ctx, "Write-Back", "position", s.position)
 		var m map[string]any
@@ -617,22 +765,12 @@ func (s *Loader) trySave(ctx context.Context) (err error) {
 			}),
 			// WithKeepPrefix[any](true),
 			WithoutFlattenKeys[any](true),
+			WithRawValues[any](true),
 		); err == nil && m != nil && len(m) > 0 {
 			logz.DebugContext(ctx, "Write-Back checked and invoking", "src", s.provider)
 			var data []byte
 			if data, err = s.codec.Marshal(m); err == nil {
-				switch fp := s.provider.(type) {
-				case OnceProvider:
-					err = fp.Write(data)
-				default:
-					err = ErrNotImplemented
-				}
-
-				if errors.Is(err, ErrNotImplemented) {
-					if wr, ok := s.provider.(io.Writer); ok {
-						_, err = wr.Write(data)
-					}
-				}
+				err = writeProvider(ctx, s.provider, data)
 			}
 		}
 	}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type valPkgCodec struct {
+	jsonCodec
+	got map[string]ValPkg
+}
+
+func (c *valPkgCodec) MarshalEx(m map[string]ValPkg) (data []byte, err error) {
+	c.got = m
+	return []byte("{}"), nil
+}
+
+func (c *valPkgCodec) UnmarshalEx(b []byte) (data map[string]ValPkg, err error) {
+	return nil, ErrNotImplemented
+}
+
+// prefixedCodec is jsonCodec marking its output.
+type prefixedCodec struct{ jsonCodec }
+
+func (c prefixedCodec) Marshal(m map[string]any) (data []byte, err error) {
+	if data, err = c.jsonCodec.Marshal(m); err == nil {
+		data = append([]byte("#marked\n"), data...)
+	}
+	return
+}
+
+func (c prefixedCodec) Unmarshal(b []byte) (data map[string]any, err error) {
+	return c.jsonCodec.Unmarshal(bytes.TrimPrefix(b, []byte("#marked\n")))
+}
+
+func TestStoreS_SaveAs(t *testing.T) {
+	conf := newBasicStore()
+	defer conf.Close()
+	ctx := context.TODO()
+	dir := t.TempDir()
+
+	t.Run("codec by ext", func(t *testing.T) {
+		out := filepath.Join(dir, "out.json")
+		if err := conf.SaveAs(ctx, out); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		var m map[string]any
+		b, _ := os.ReadFile(out)
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatalf("bad output: %v\n%s", err, b)
+		}
+		app := m["app"].(map[string]any)
+		assertEqual(t, "/tmp/1.log", app["logging"].(map[string]any)["file"])
+		assertEqual(t, float64(5), app["server"].(map[string]any)["start"])
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+		out := filepath.Join(dir, "logging.json")
+		if err := conf.SaveAs(ctx, out, WithSaveAsPosition("app.logging")); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		var m map[string]any
+		b, _ := os.ReadFile(out)
+		_ = json.Unmarshal(b, &m)
+		assertEqual(t, "/tmp/1.log", m["file"])
+		assertEqual(t, float64(6), m["rotate"])
+	})
+
+	t.Run("prefixed view", func(t *testing.T) {
+		out := filepath.Join(dir, "view.json")
+		if err := conf.WithPrefix("app.logging").SaveAs(ctx, out); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		var m map[string]any
+		b, _ := os.ReadFile(out)
+		_ = json.Unmarshal(b, &m)
+		assertEqual(t, "/tmp/1.log", m["file"])
+		assertEqual(t, float64(6), m["rotate"])
+		_, ok := m["app"]
+		assertFalse(t, ok, "the keys out of the prefix are not saved")
+	})
+
+	t.Run("loader codec", func(t *testing.T) {
+		src := &watchableS{data: "#marked\n{\"svc\":{\"port\":80}}"}
+		wr, err := conf.Load(ctx, WithProvider(src), WithCodec(prefixedCodec{}), WithStorePrefix("ext"))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		assertEqual(t, 80, conf.MustInt("ext.svc.port"))
+		out := filepath.Join(dir, "loader.json")
+		if err = wr.(*Loader).SaveAs(ctx, out); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		b, _ := os.ReadFile(out)
+		assertTrue(t, bytes.HasPrefix(b, []byte("#marked\n")), "the codec of the loader wins over the ext")
+	})
+
+	t.Run("unknown ext", func(t *testing.T) {
+		err := conf.SaveAs(ctx, filepath.Join(dir, "out.unknown"))
+		assertTrue(t, err != nil, "expecting an error for unknown codec")
+	})
+
+	t.Run("with comment", func(t *testing.T) {
+		conf.SetComment("app.logging.file", "log file", "remarks")
+		codec := &valPkgCodec{}
+		out := filepath.Join(dir, "out.ex")
+		if err := conf.SaveAs(ctx, out, WithSaveAsCodec(codec)); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		logging := codec.got["app"].Value.(map[string]ValPkg)["logging"].Value.(map[string]ValPkg)
+		assertEqual(t, "log file", logging["file"].Desc)
+		assertEqual(t, "remarks", logging["file"].Comment)
+		assertEqual(t, "/tmp/1.log", logging["file"].Value)
+
+		codec.got = nil
+		if err := conf.SaveAs(ctx, out, WithSaveAsCodec(codec), WithSaveAsComment(false)); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		assertTrue(t, codec.got == nil, "MarshalEx should not be used")
+	})
+}
+
+func TestStoreS_SaveAsKeepsTemplates(t *testing.T) {
+	conf := New(WithInterpolation(true))
+	defer conf.Close()
+	conf.Set("app.home", "/opt/app")
+	conf.Set("app.log.dir", "${app.home}/logs")
+	assertEqual(t, "/opt/app/logs", conf.WithPrefix("app").MustString("log.dir"))
+
+	out := filepath.Join(t.TempDir(), "out.json")
+	if err := conf.SaveAs(context.TODO(), out); err != nil {
+		t.Fatalf("SaveAs failed: %v", err)
+	}
+	b, _ := os.ReadFile(out)
+	var m map[string]any
+	_ = json.Unmarshal(b, &m)
+	assertEqual(t, "${app.home}/logs", m["app"].(map[string]any)["log"].(map[string]any)["dir"])
+}
@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"reflect"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// MergeStrategy tells how the loaded values are merged with the
+// existing ones, see WithMergeStrategy and [Store.LoadAll].
+type MergeStrategy int
+
+const (
+	// MergeOverride overwrites the existing leaves, and merges
+	// the maps key by key. It's the default strategy.
+	MergeOverride MergeStrategy = iota
+	// MergeKeepExisting sets the leaves which don't exist yet,
+	// the existing values are kept.
+	MergeKeepExisting
+	// MergeAppendSlices works like MergeOverride, but a slice is
+	// appended to the existing slice instead of replacing it.
+	MergeAppendSlices
+	// MergeDeepMaps works like MergeOverride, but a map is merged
+	// deeply into the existing map value of a leaf, instead of
+	// being loaded as a subtree beside it.
+	MergeDeepMaps
+	// MergeReplaceSubtree removes the existing subtree at the
+	// position of the source before loading it. Without a
+	// position, the subtrees of the top-level keys of the source
+	// are removed.
+	MergeReplaceSubtree
+	// MergePrependSlices works like MergeAppendSlices, but a slice
+	// is inserted before the existing slice.
+	MergePrependSlices
+	// MergeUnionByKey works like MergeOverride, but a slice of
+	// maps is merged with the existing one element by element.
+	// The elements having the same key field, "id" or "name" by
+	// default, are merged deeply, the others are appended.
+	MergeUnionByKey
+)
+
+var mergeStrategyNames = map[MergeStrategy]string{
+	MergeOverride:       "override",
+	MergeKeepExisting:   "keep-existing",
+	MergeAppendSlices:   "append-slices",
+	MergeDeepMaps:       "deep-merge-maps",
+	MergeReplaceSubtree: "replace-subtree",
+	MergePrependSlices:  "prepend-slices",
+	MergeUnionByKey:     "union-by-key",
+}
+
+// defaultUnionKeys are the key fields for MergeUnionByKey.
+var defaultUnionKeys = []string{"id", "name"}
+
+// mergeS holds the merge semantics of a view, for loading and
+// merging.
+type mergeS struct {
+	strategy     MergeStrategy
+	unionKeys    []string // for MergeUnionByKey, defaultUnionKeys if empty
+	deleteOnNull bool
+}
+
+// MergeOpt is the options for [Store.Merge].
+type MergeOpt func(s *mergeS)
+
+// WithMergeAppend appends a slice to the existing slice instead
+// of replacing it.
+func WithMergeAppend() MergeOpt {
+	return func(s *mergeS) { s.strategy = MergeAppendSlices }
+}
+
+// WithMergePrepend inserts a slice before the existing slice
+// instead of replacing it.
+func WithMergePrepend() MergeOpt {
+	return func(s *mergeS) { s.strategy = MergePrependSlices }
+}
+
+// WithMergeUnionByKey merges a slice of maps with the existing one
+// by the key fields. An element is merged deeply into the existing
+// element which has the same value of the first key field found
+// in it, or else it's appended. The key fields are "id" and "name"
+// by default.
+//
+//	// existing: [{name: web, port: 80}, {name: db, port: 5432}]
+//	_ = conf.Merge("app", map[string]any{"services": []any{
+//	    map[string]any{"name": "web", "port": 8080},
+//	    map[string]any{"name": "cache", "port": 6379},
+//	}}, store.WithMergeUnionByKey())
+//	// result: [{name: web, port: 8080}, {name: db, port: 5432}, {name: cache, port: 6379}]
+func WithMergeUnionByKey(keys ...string) MergeOpt {
+	return func(s *mergeS) {
+		s.strategy, s.unionKeys = MergeUnionByKey, keys
+	}
+}
+
+// WithMergeReplace replaces the existing subtrees of the top-level
+// keys of the merging map, instead of merging into them.
+func WithMergeReplace() MergeOpt {
+	return func(s *mergeS) { s.strategy = MergeReplaceSubtree }
+}
+
+// WithMergeDeleteOnNull removes the node for a nil value, such as
+// `key: null` of an overlay file, instead of setting it to nil.
+func WithMergeDeleteOnNull() MergeOpt {
+	return func(s *mergeS) { s.deleteOnNull = true }
+}
+
+func (m MergeStrategy) String() string {
+	if s, ok := mergeStrategyNames[m]; ok {
+		return s
+	}
+	return "MergeStrategy(?)"
+}
+
+// WithMergeStrategy sets how the loaded values are merged with
+// the existing ones.
+func WithMergeStrategy(strategy MergeStrategy) LoadOpt {
+	return func(s *Loader) {
+		s.strategy = &strategy
+	}
+}
+
+// Source bundles the options for loading a data source, see
+// [Store.LoadAll].
+type Source struct {
+	Provider Provider
+	Codec    Codec         // optional, the codec bound to Provider is used if nil
+	Position string        // where the data is mounted in the store, see WithStorePrefix
+	Strategy MergeStrategy // how the data is merged with the loaded sources
+	Opts     []LoadOpt     // more options for Load
+}
+
+// LoadAll loads the sources in order. Each source is merged into
+// the store by its Strategy, so the later sources can override,
+// complement or extend the former ones:
+//
+//	err := conf.LoadAll(ctx,
+//	    store.Source{Provider: file.New("defaults.yml")},
+//	    store.Source{Provider: file.New("plugins.yml"), Strategy: store.MergeAppendSlices},
+//	    store.Source{Provider: env.New(), Strategy: store.MergeOverride},
+//	)
+//
+// A failed source doesn't stop the loading of the others, the
+// errors are returned together in one error container.
+func (s *storeS) LoadAll(ctx context.Context, sources ...Source) (err error) {
+	ec := errors.New("cannot load all sources")
+	defer ec.Defer(&err)
+	for i, src := range sources {
+		if src.Provider == nil {
+			ec.Attach(errors.New("source #%d has no provider", i))
+			continue
+		}
+		opts := []LoadOpt{WithProvider(src.Provider)}
+		if src.Codec != nil {
+			opts = append(opts, WithCodec(src.Codec))
+		}
+		if src.Position != "" {
+			opts = append(opts, WithStorePrefix(src.Position))
+		}
+		opts = append(opts, WithMergeStrategy(src.Strategy))
+		opts = append(opts, src.Opts...)
+		if _, e := s.Load(ctx, opts...); e != nil {
+			ec.Attach(errors.New("cannot load source #%d (%v)", i, src.Provider).WithErrors(e))
+		}
+	}
+	return
+}
+
+// mergeValue merges v with the existing value at path by the
+// strategy. It returns false if v should be dropped.
+func (s *storeS) mergeValue(path string, v any) (ret any, ok bool) {
+	if s.merge.strategy == MergeOverride || s.merge.strategy == MergeReplaceSubtree {
+		return v, true
+	}
+
+	old, branch, found, _ := s.Trie.Query(path, nil)
+	if !found || branch {
+		return v, true
+	}
+	switch s.merge.strategy {
+	case MergeKeepExisting:
+		return nil, false
+	case MergeAppendSlices:
+		return appendSlices(old, v), true
+	case MergePrependSlices:
+		if reflect.TypeOf(old) == reflect.TypeOf(v) {
+			return appendSlices(v, old), true
+		}
+		return appendSlices(appendSlices([]any(nil), v), old), true
+	case MergeUnionByKey:
+		return unionByKey(old, v, s.merge.unionKeys), true
+	case MergeDeepMaps:
+		if om, yes := old.(map[string]any); yes {
+			if nm, yes := v.(map[string]any); yes {
+				return deepMergeMap(om, nm), true
+			}
+		}
+	}
+	return v, true
+}
+
+// isMapLeaf tests if the value at path is a map leaf, so that
+// MergeDeepMaps should merge a map into it.
+func (s *storeS) isMapLeaf(path string) bool {
+	if s.merge.strategy != MergeDeepMaps {
+		return false
+	}
+	old, branch, found, _ := s.Trie.Query(path, nil)
+	_, yes := old.(map[string]any)
+	return found && !branch && yes
+}
+
+// removeSubtrees drops the existing subtree at position, or the
+// subtrees of the top-level keys of the loaded data, for
+// MergeReplaceSubtree.
+func (s *storeS) removeSubtrees(position string, data map[string]ValPkg, bin map[string]any) {
+	set := s.WithPrefixReplaced().(*storeS)
+	if position != "" {
+		set.Remove(position)
+		return
+	}
+	for k := range data {
+		set.Remove(k)
+	}
+	for k := range bin {
+		set.Remove(k)
+	}
+}
+
+// appendSlices appends b to a if both are slices. The result has
+// the type of a if the element types are the same, or else it's
+// a []any.
+func appendSlices(a, b any) any {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != reflect.Slice || vb.Kind() != reflect.Slice {
+		return b
+	}
+	if va.Type() == vb.Type() {
+		return reflect.AppendSlice(reflect.AppendSlice(reflect.MakeSlice(va.Type(), 0, va.Len()+vb.Len()), va), vb).Interface()
+	}
+	ret := make([]any, 0, va.Len()+vb.Len())
+	for i := 0; i < va.Len(); i++ {
+		ret = append(ret, va.Index(i).Interface())
+	}
+	for i := 0; i < vb.Len(); i++ {
+		ret = append(ret, vb.Index(i).Interface())
+	}
+	return ret
+}
+
+// unionByKey merges the slice of maps b into a, see
+// WithMergeUnionByKey. b is returned if any of them isn't a slice
+// of maps.
+func unionByKey(a, b any, keys []string) any {
+	am, ok1 := toMaps(a)
+	bm, ok2 := toMaps(b)
+	if !ok1 || !ok2 {
+		return b
+	}
+	if len(keys) == 0 {
+		keys = defaultUnionKeys
+	}
+
+	ret := append(make([]map[string]any, 0, len(am)+len(bm)), am...)
+	for _, m := range bm {
+		i := indexByKey(ret, m, keys)
+		if i < 0 {
+			ret = append(ret, m)
+		} else {
+			ret[i] = deepMergeMap(ret[i], m)
+		}
+	}
+
+	if _, yes := a.([]map[string]any); yes {
+		return ret
+	}
+	r := make([]any, len(ret))
+	for i, m := range ret {
+		r[i] = m
+	}
+	return r
+}
+
+// indexByKey returns the position of the element in list which has
+// the same value of the first key field found in m, or -1.
+func indexByKey(list []map[string]any, m map[string]any, keys []string) int {
+	for _, key := range keys {
+		id, ok := m[key]
+		if !ok {
+			continue
+		}
+		for i, it := range list {
+			if v, ok := it[key]; ok && reflect.DeepEqual(v, id) {
+				return i
+			}
+		}
+		return -1
+	}
+	return -1
+}
+
+// toMaps converts a []map[string]any or a []any of map[string]any.
+func toMaps(v any) (ret []map[string]any, ok bool) {
+	switch vv := v.(type) {
+	case []map[string]any:
+		return vv, true
+	case []any:
+		ret = make([]map[string]any, 0, len(vv))
+		for _, it := range vv {
+			m, yes := it.(map[string]any)
+			if !yes {
+				return nil, false
+			}
+			ret = append(ret, m)
+		}
+		return ret, true
+	}
+	return nil, false
+}
+
+// deepMergeMap returns a new map with the entries of b merged into
+// a, recursively.
+func deepMergeMap(a, b map[string]any) map[string]any {
+	ret := make(map[string]any, len(a)+len(b))
+	for k, v := range a {
+		ret[k] = v
+	}
+	for k, v := range b {
+		if om, ok := ret[k].(map[string]any); ok {
+			if nm, ok := v.(map[string]any); ok {
+				ret[k] = deepMergeMap(om, nm)
+				continue
+			}
+		}
+		ret[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStoreS_LoadAll(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.name", "demo")
+
+	err := conf.LoadAll(context.TODO(),
+		Source{Provider: &watchableS{data: `{"app":{"name":"base","plugins":["auth"],"server":{"port":7999,"host":"a"}}}`}, Strategy: MergeKeepExisting},
+		Source{Provider: &watchableS{data: `{"app":{"plugins":["cache","trace"]}}`}, Strategy: MergeAppendSlices},
+		Source{Provider: &watchableS{data: `{"port":8080}`}, Position: "app.server", Strategy: MergeReplaceSubtree},
+		Source{Provider: &watchableS{data: `{"tags":["x"]}`}, Position: "app.meta"},
+	)
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	assertEqual(t, "demo", conf.MustString("app.name"), "kept by keep-existing")
+	assertEqual(t, []any{"auth", "cache", "trace"}, conf.MustGet("app.plugins"))
+	assertEqual(t, 8080, conf.MustInt("app.server.port"))
+	assertFalse(t, conf.Has("app.server.host"), "replaced by replace-subtree")
+	assertEqual(t, []any{"x"}, conf.MustGet("app.meta.tags"))
+
+	err = conf.LoadAll(context.TODO(),
+		Source{},
+		Source{Provider: &watchableS{data: `{"app":{"debug":true}}`}},
+	)
+	assertTrue(t, err != nil, "a source without provider")
+	assertTrue(t, conf.MustBool("app.debug"), "the later sources are still loaded")
+}
+
+func TestStoreS_MergeDeepMaps(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.limits", map[string]any{"cpu": 1, "mem": map[string]any{"max": 512}})
+
+	err := conf.LoadAll(context.TODO(),
+		Source{Provider: &watchableS{data: `{"app":{"limits":{"mem":{"min":64}}}}`}, Strategy: MergeDeepMaps},
+	)
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	assertEqual(t, map[string]any{"cpu": 1, "mem": map[string]any{"max": 512, "min": float64(64)}}, conf.MustGet("app.limits"))
+}
+
+func TestAppendSlices(t *testing.T) {
+	assertEqual(t, []string{"a", "b"}, appendSlices([]string{"a"}, []string{"b"}))
+	assertEqual(t, []any{"a", 1}, appendSlices([]string{"a"}, []int{1}))
+	assertEqual(t, "b", appendSlices("a", "b"))
+}
+
+func TestStoreS_MergeOpts(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.plugins", []any{"auth"})
+	conf.Set("app.hosts", []string{"b"})
+	conf.Set("app.services", []any{
+		map[string]any{"name": "web", "port": 80},
+		map[string]any{"name": "db", "port": 5432},
+	})
+	conf.Set("app.debug", true)
+	conf.Set("app.server.port", 7999)
+	conf.Set("app.server.host", "a")
+
+	err := conf.Merge("app", map[string]any{"plugins": []any{"cache"}}, WithMergeAppend())
+	assertTrue(t, err == nil)
+	assertEqual(t, []any{"auth", "cache"}, conf.MustGet("app.plugins"))
+
+	err = conf.Merge("app", map[string]any{"hosts": []string{"a"}}, WithMergePrepend())
+	assertTrue(t, err == nil)
+	assertEqual(t, []string{"a", "b"}, conf.MustGet("app.hosts"))
+
+	err = conf.Merge("app", map[string]any{"services": []any{
+		map[string]any{"name": "web", "port": 8080},
+		map[string]any{"name": "cache", "port": 6379},
+	}}, WithMergeUnionByKey())
+	assertTrue(t, err == nil)
+	assertEqual(t, []any{
+		map[string]any{"name": "web", "port": 8080},
+		map[string]any{"name": "db", "port": 5432},
+		map[string]any{"name": "cache", "port": 6379},
+	}, conf.MustGet("app.services"))
+
+	err = conf.Merge("app", map[string]any{"server": map[string]any{"port": 8080}}, WithMergeReplace())
+	assertTrue(t, err == nil)
+	assertEqual(t, 8080, conf.MustInt("app.server.port"))
+	assertFalse(t, conf.Has("app.server.host"))
+
+	err = conf.Merge("app", map[string]any{"debug": nil, "plugins": nil}, WithMergeDeleteOnNull())
+	assertTrue(t, err == nil)
+	assertFalse(t, conf.Has("app.debug"))
+	assertFalse(t, conf.Has("app.plugins"))
+
+	err = conf.Merge("app", map[string]any{"plugins": []any{"trace"}})
+	assertTrue(t, err == nil)
+	assertEqual(t, []any{"trace"}, conf.MustGet("app.plugins"), "replaced by default")
+}
+
+func TestUnionByKey(t *testing.T) {
+	a := []map[string]any{{"id": 1, "v": "a"}, {"v": "no id"}}
+	b := []any{map[string]any{"id": 1, "w": "b"}, map[string]any{"id": 2}}
+	assertEqual(t, []map[string]any{{"id": 1, "v": "a", "w": "b"}, {"v": "no id"}, {"id": 2}}, unionByKey(a, b, nil))
+	assertEqual(t, []any{"x"}, unionByKey(a, []any{"x"}, nil), "not a slice of maps")
+	assertEqual(t, 2, len(unionByKey(a, []any{map[string]any{"v": "a", "x": 1}}, []string{"v"}).([]map[string]any)))
+}
+
+type closeCountS struct {
+	*watchableS
+	closed int
+}
+
+func (s *closeCountS) Close() { s.closed++ }
+
+func TestStoreS_LoadAllCloseWatchers(t *testing.T) {
+	conf := New(WithWatchEnable(true))
+	p1 := &closeCountS{watchableS: &watchableS{data: `{"app":{"plugins":["auth"]}}`}}
+	p2 := &closeCountS{watchableS: &watchableS{data: `{"plugins":["cache"]}`}}
+	err := conf.LoadAll(context.TODO(),
+		Source{Provider: p1},
+		Source{Provider: p2, Position: "app", Strategy: MergeAppendSlices},
+	)
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	assertEqual(t, []any{"auth", "cache"}, conf.MustGet("app.plugins"))
+
+	conf.Close()
+	assertEqual(t, 1, p1.closed, "the watcher should be closed by the store")
+	assertEqual(t, 1, p2.closed, "the watcher should be closed by the store")
+}
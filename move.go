@@ -0,0 +1,120 @@
+package store
+
+import (
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// Move relocates the subtree at src to dst, the values go along
+// with their Desc, Comment, Tag fields, the modified states, the
+// pending TTLs and the origins.
+//
+//	err := conf.Move("app.server", "app.servers.default")
+//
+// dst must not exist. The frozen subtrees can't be moved, nor
+// be moved into.
+//
+// Each moved key is reported once, as an OpRename delta to the
+// watchers (see Watch and Events) and the journal. The
+// OnDeleteHandler and OnNewHandler are called back for the old
+// and new paths. The paths passed to the handlers are full
+// paths.
+func (s *storeS) Move(src, dst string) (err error) {
+	return s.relocate("move", src, dst)
+}
+
+// Copy duplicates the subtree at src to dst, like Move, but the
+// source is kept. The pending TTLs are copied with the remaining
+// durations.
+//
+// The copied keys are reported as OpCreate deltas, and the
+// OnNewHandler are called back for them.
+func (s *storeS) Copy(src, dst string) (err error) {
+	return s.relocate("copy", src, dst)
+}
+
+// Rename changes the last segment of path to newLeafName, the
+// subtree is moved as Move does.
+//
+//	err := conf.Rename("app.server", "http") // app.server.* => app.http.*
+//
+// newLeafName is a key, the delimiters in it are escaped.
+func (s *storeS) Rename(path, newLeafName string) (err error) {
+	if path == "" || newLeafName == "" {
+		return errors.New("cannot rename %q to %q", path, newLeafName)
+	}
+	delim := s.Delimiter()
+	keys := radix.SplitPath(path, delim)
+	keys[len(keys)-1] = newLeafName
+	for i, k := range keys {
+		keys[i] = radix.EscapeKey(k, delim)
+	}
+	return s.relocate("move", path, joinPath(string(delim), keys...))
+}
+
+func (s *storeS) relocate(op, src, dst string) (err error) {
+	delim := s.Delimiter()
+	src, dst = radix.NormalizePath(src, delim), radix.NormalizePath(dst, delim)
+	if op == "move" {
+		if err = s.checkWritable(op, src, true); err != nil {
+			return
+		}
+	}
+	if err = s.checkWritable(op, dst, true); err != nil {
+		return
+	}
+	if len(s.schemas) > 0 {
+		full, to := s.join(s.Prefix(), src), s.join(s.Prefix(), dst)
+		for key, val := range s.Leaves(src) {
+			if err = s.checkSchema(to+key[len(full):], val, true); err != nil && s.schemaStrict {
+				return
+			}
+		}
+		err = nil
+	}
+
+	type movedS struct {
+		from, to string
+		data     any
+		node     radix.Node[any]
+	}
+	var moved []movedS
+	cb := func(from, to radix.Node[any]) {
+		if to.HasData() {
+			moved = append(moved, movedS{from: from.Key(), to: to.Key(), data: to.Data(), node: to})
+		}
+	}
+	if op == "move" {
+		err = s.Trie.Move(src, dst, cb)
+	} else {
+		err = s.Trie.Copy(src, dst, cb)
+	}
+	if err != nil {
+		return
+	}
+
+	journaling := s.journaling()
+	for _, m := range moved {
+		if s.origins != nil {
+			s.origins.relocate(m.from, m.to, op == "copy")
+		}
+		d := Delta{Op: OpCreate, Path: m.to, NewValue: m.data}
+		if op == "move" {
+			d.Op, d.OldPath, d.OldValue = OpRename, m.from, m.data
+			s.tryOnDelete(m.from, true, m.data, nil, nil)
+		}
+		s.tryOnSet(m.to, true, nil, m.data, true)
+		if journaling {
+			s.journalDelta(d)
+			if desc, comment := m.node.Description(), m.node.Comment(); desc != "" || comment != "" {
+				s.journal.record(&journalEntry{Op: journalComment, Path: m.to, Desc: desc, Comment: comment})
+			}
+			if tag := m.node.Tag(); tag != nil {
+				s.journal.record(&journalEntry{Op: journalTag, Path: m.to, Value: tag})
+			}
+		}
+		s.watchers.fire(d, delim)
+	}
+	return
+}
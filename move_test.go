@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStoreS_Move(t *testing.T) {
+	var events []string
+	conf := New(
+		WithOriginTracking(true),
+		WithOnNewHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "new "+path)
+		}),
+		WithOnDeleteHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "delete "+path)
+		}),
+	)
+	defer conf.Close()
+
+	if _, err := conf.Load(context.TODO(), WithProvider(newMapPvdr("defaults", map[string]any{
+		"app.server.port": 80,
+		"app.server.host": "localhost",
+	}))); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertTrue(t, conf.SetComment("app.server.port", "the port", "remarks"))
+	conf.Set("app.server.host", "0.0.0.0")
+
+	var deltas []Delta
+	conf.Watch("app.server.*", func(d Delta) { deltas = append(deltas, d) })
+	events = nil
+
+	err := conf.Move("app.server", "app.http")
+	assertTrue(t, err == nil, err)
+	assertFalse(t, conf.Has("app.server.port"))
+	assertEqual(t, 80, conf.MustInt("app.http.port"))
+	assertEqual(t, "0.0.0.0", conf.MustString("app.http.host"))
+	assertEqual(t, "the port", conf.MustGetDesc("app.http.port"))
+	assertEqual(t, "remarks", conf.MustGetComment("app.http.port"))
+	node, _, _, _ := conf.Locate("app.http.host", nil)
+	assertTrue(t, node.Modified(), "the modified state goes along")
+
+	assertEqual(t, 2, len(deltas), "one OpRename per key, matched by the old path")
+	for _, d := range deltas {
+		assertEqual(t, OpRename, d.Op)
+		assertEqual(t, d.OldValue, d.NewValue)
+	}
+	assertEqual(t, 4, len(events), "a delete and new pair per key")
+
+	chain := conf.Origin("app.http.port")
+	assertEqual(t, 1, len(chain))
+	assertEqual(t, "defaults", chain[0].Provider)
+	assertEqual(t, 0, len(conf.Origin("app.server.port")))
+
+	err = conf.WithPrefix("app").Rename("http", "web")
+	assertTrue(t, err == nil, err)
+	assertEqual(t, 80, conf.MustInt("app.web.port"))
+	err = conf.Rename("app.web.host", "bind.addr")
+	assertTrue(t, err == nil, err)
+	assertEqual(t, "0.0.0.0", conf.MustString(`app.web."bind.addr"`))
+
+	assertTrue(t, conf.Move("app.none", "app.x") != nil)
+	assertTrue(t, conf.Move("app.web", "app.web.inner") != nil)
+	assertTrue(t, conf.Copy("app.web.port", `app.web.bind\.addr`) != nil, "exists")
+
+	unfreeze := conf.Freeze("app.web")
+	assertTrue(t, errors.Is(conf.Move("app.web", "app.http"), ErrReadOnly))
+	assertTrue(t, errors.Is(conf.Copy("app.web.port", "app.web.port2"), ErrReadOnly))
+	assertTrue(t, conf.Copy("app.web", "app.http") == nil, "copying from a frozen subtree")
+	unfreeze()
+	assertEqual(t, 80, conf.MustInt("app.http.port"))
+	assertEqual(t, "remarks", conf.MustGetComment("app.http.port"))
+	assertEqual(t, 80, conf.MustInt("app.web.port"))
+}
+
+func TestStoreS_MoveEvents(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.a.x", 1)
+	conf.Set("app.a.y", 2)
+	conf.Set("app.b", 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := conf.Events(ctx, WithEventsWindow(50*time.Millisecond))
+
+	assertTrue(t, conf.Rename("app.b", "c") == nil)
+	conf.Set("app.c", 4)
+	assertTrue(t, conf.Copy("app.c", "app.d") == nil)
+
+	batch := <-ch
+	assertEqual(t, []Delta{
+		{Op: OpRename, Path: "app.c", OldPath: "app.b", OldValue: 3, NewValue: 3},
+		{Op: OpWrite, Path: "app.c", OldValue: 3, NewValue: 4},
+		{Op: OpCreate, Path: "app.d", NewValue: 4},
+	}, batch.Changes)
+
+	patch, err := JSONPatch(batch.Changes[:1], '.')
+	assertTrue(t, err == nil, err)
+	assertEqual(t, `[{"op":"move","path":"/app/c","from":"/app/b"}]`, string(patch))
+
+	other := New()
+	defer other.Close()
+	other.Set("app.b", 3)
+	assertTrue(t, other.Apply(batch.Changes) == nil)
+	assertFalse(t, other.Has("app.b"))
+	assertEqual(t, 4, other.MustInt("app.c"))
+	assertEqual(t, 4, other.MustInt("app.d"))
+}
+
+func TestStoreS_MoveTTLAndJournal(t *testing.T) {
+	dir := t.TempDir()
+	conf := New(WithJournal(dir))
+	conf.Set("app.session.token", "abc")
+	conf.Set("app.session.user", "joe")
+	assertTrue(t, conf.SetTag("app.session.user", "admin"))
+	conf.SetTTL("app.session.token", 150*time.Millisecond, nil)
+	time.Sleep(20 * time.Millisecond)
+
+	assertTrue(t, conf.Move("app.session", "app.sess") == nil)
+	time.Sleep(200 * time.Millisecond)
+	assertEqual(t, nil, conf.MustGet("app.sess.token"), "the ttl goes along")
+	assertEqual(t, "joe", conf.MustString("app.sess.user"))
+	conf.Close()
+
+	// restart
+	conf = New(WithJournal(dir))
+	defer conf.Close()
+	assertFalse(t, conf.Has("app.session.user"))
+	assertEqual(t, "joe", conf.MustString("app.sess.user"))
+	assertEqual(t, "admin", conf.MustGetTag("app.sess.user"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	stderr "errors"
 	"io"
+	"iter"
 	"time"
 
 	"gopkg.in/hedzr/errors.v3"
@@ -71,6 +72,19 @@ type Store interface {
 	MustGet(path string) (data any)
 
 	// Get the value at path point 'path'.
+	//
+	// The path can index into a slice, stored in a leaf or
+	// flattened by WithFlattenSlice, such as "app.servers[2].host"
+	// or "app.servers[-1]". A wildcard path, such as
+	// "app.servers[*].host", gets a []any of the matched values.
+	// Set, Remove and Has accept the same paths.
+	//
+	// A key holding the delimiter, such as a hostname, can be
+	// quoted or escaped: `upstreams."api.example.com".weight` and
+	// `upstreams.api\.example\.com.weight` are the same path. The
+	// map keys loaded by Merge or a codec are split by the
+	// delimiter unless they are quoted or escaped, see
+	// [WithLiteralKeys].
 	Get(path string) (data any, found bool)
 
 	// Set sets key('path') and value pair into storeS.
@@ -80,7 +94,77 @@ type Store interface {
 	Remove(path string) (removed bool)
 
 	// Merge a map at path point 'pathAt'.
-	Merge(pathAt string, data map[string]any) (err error)
+	Merge(pathAt string, data map[string]any, opts ...MergeOpt) (err error)
+
+	// Move relocates the subtree at src to dst, with the Desc,
+	// Comment, Tag fields, the modified states and the TTLs. The
+	// moved keys are reported as OpRename.
+	Move(src, dst string) (err error)
+	// Copy duplicates the subtree at src to dst, like Move.
+	Copy(src, dst string) (err error)
+	// Rename changes the last segment of path, see Move.
+	//
+	//	err := conf.Rename("app.server", "http") // app.server.* => app.http.*
+	Rename(path, newLeafName string) (err error)
+
+	// Apply replays the deltas, which generally come from [Diff].
+	Apply(deltas []Delta) (err error)
+
+	// Tx runs fn in a transaction. The writings in fn are staged
+	// and published at once after fn returned nil. If fn returns
+	// an error, the staged changes are discarded.
+	Tx(fn func(tx Store) error) (err error)
+
+	// Snapshot takes a snapshot of the whole store and keeps it
+	// in the history. See also WithHistory.
+	Snapshot() (v VersionID, ro ReadOnlyStore)
+
+	// History returns the versions kept in the history ring, the
+	// oldest one first.
+	History() (vers []VersionInfo)
+
+	// Rollback restores the whole store to a version in the
+	// history, and fires the change events.
+	Rollback(v VersionID) (err error)
+
+	// LoadAll loads the sources in order, each one is merged into
+	// the store by its MergeStrategy.
+	LoadAll(ctx context.Context, sources ...Source) (err error)
+
+	// ActivateProfiles overlays the profile subtrees, such as
+	// base.profiles.dev, onto the base path in order.
+	ActivateProfiles(base string, names ...string) (err error)
+	// ActiveProfiles returns the profiles activated on the base path.
+	ActiveProfiles(base string) (names []string)
+	// RawView returns a snapshot of the subtree at base without the
+	// overlays of the activated profiles.
+	RawView(base string) (raw Store)
+
+	// ReadOnly returns a view rejecting all writings.
+	ReadOnly() (ro Store)
+	// Freeze protects the subtree at path from the writings of all
+	// views, and returns a function to unfreeze it.
+	Freeze(path string, opts ...FreezeOpt) (unfreeze func())
+	// Writable reports a *ReadOnlyError if the key at path cannot
+	// be written by the view.
+	Writable(path string) (err error)
+
+	// CompactJournal writes the effective mutations into the
+	// snapshot file of the journal, see WithJournal.
+	CompactJournal() (err error)
+
+	// Watch subscribes the changes of the keys matching a glob
+	// pattern, such as "app.server.*" or "app.**.timeout", and
+	// returns a function to unsubscribe.
+	Watch(pattern string, fn WatchFunc) (unsubscribe func())
+
+	// Events returns a channel which delivers the changes in
+	// debounced and coalesced batches, until ctx done.
+	Events(ctx context.Context, opts ...EventsOpt) <-chan ChangeBatch
+
+	// Validate checks the whole store against the schemas
+	// attached by WithSchema.
+	Validate() (err error)
 
 	// Has tests if the given path exists
 	Has(path string) (found bool)
@@ -168,6 +252,18 @@ type Store interface {
 	// Dump prints internal data tree for debugging
 	Dump() (text string)
 
+	// Explain dumps all values with their sources, see
+	// WithOriginTracking.
+	Explain() (text string)
+
+	// Origin returns the provenance chain of a key, in writing
+	// order. The last one is the effective source. A layer appears
+	// once in the chain, at its latest writing.
+	//
+	// It returns nil if origin tracking is disabled, see
+	// WithOriginTracking.
+	Origin(path string) (chain []Origin)
+
 	// Clone makes a clone copy for this store
 	Clone() (newStore Store)
 
@@ -189,6 +285,27 @@ type Store interface {
 	// Walk("app.") walks from the "app." node.
 	Walk(path string, cb func(path, fragment string, node radix.Node[any]))
 
+	// All returns an iterator over the nodes under prefix, in
+	// lexicographic order of their full keys, see [radix.Trie.All].
+	//
+	// The iterators, All, Leaves, Keys and Children, take a
+	// snapshot of the keys when the iteration starts, so the loop
+	// body can Set or Remove freely, and break at any time:
+	//
+	//	for key, val := range conf.Leaves("app.server") {
+	//	    fmt.Println(key, val)
+	//	}
+	All(prefix string) iter.Seq2[string, radix.Node[any]]
+	// Leaves returns an iterator over the full keys and values of
+	// the leaves under prefix.
+	Leaves(prefix string) iter.Seq2[string, any]
+	// Keys returns an iterator over the full keys of the leaves
+	// under prefix.
+	Keys(prefix string) iter.Seq[string]
+	// Children returns an iterator over the direct children of
+	// path, such as "app.server" and "app.debug" for "app".
+	Children(path string) iter.Seq2[string, radix.Node[any]]
+
 	// WithPrefix makes a lightweight copy from current storeS.
 	//
 	// The new copy is enough light so that you can always use
@@ -235,7 +352,19 @@ type Store interface {
 
 	Prefix() string              // return current prefix string
 	Delimiter() rune             // return current delimiter, generally it's dot ('.')
-	SetDelimiter(delimiter rune) // setter. Change it at runtime doesn't update old delimiter inside tree nodes.
+	SetDelimiter(delimiter rune) // setter. Change it at runtime doesn't update old delimiter inside tree nodes, see ChangeDelimiter.
+
+	// ChangeDelimiter re-keys the whole store with a new
+	// delimiter, and sets it. The delimiters inside the keys are
+	// escaped, so a tree using '/', such as one from consul, can
+	// be mounted into a dotted store:
+	//
+	//	err := consulConf.ChangeDelimiter('.')
+	//	err = conf.Merge("remote.services", consulConf.MustM("services"))
+	//
+	// It's for the root store only, a view reports an error. The
+	// journal is re-keyed and compacted too, see WithJournal.
+	ChangeDelimiter(delimiter rune) (err error)
 
 	// N makes a clone with trie.RecursiveNone mode.
 	//
@@ -277,8 +406,11 @@ type Store interface {
 	// modified flag.
 	WithinLoading(fn func())
 
-	// Save the [Store] as a file
-	// SaveAs(ctx context.Context, file string, opts ...SaveAsOpt) (err error)
+	// SaveAs writes the whole [Store], or a subtree of it, into a file.
+	//
+	// The codec is picked from the file extension if WithSaveAsCodec
+	// is absent. See also RegisterCodec.
+	SaveAs(ctx context.Context, file string, opts ...SaveAsOpt) (err error)
 }
 
 // Dumpable interface identify an object can be represented as a string for debugging.
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hedzr/store/radix"
+)
+
+// WithOriginTracking enables provenance tracking.
+//
+// Once enabled, each key records the provider and the load
+// order (the layer) which wrote it. Use [Store.Origin] to
+// retrieve the chain, or [Store.Explain] to dump all values with
+// their sources.
+//
+// A store loads its defaults from maps.New, then a yaml file,
+// then env.New and flags.New. After loading, Origin("app.port")
+// tells which one of them was the winner.
+func WithOriginTracking(b bool) Opt {
+	return func(s *storeS) {
+		if b {
+			if s.origins == nil {
+				s.origins = newOrigins()
+			}
+		} else {
+			s.origins = nil
+		}
+	}
+}
+
+// Origin records who wrote a key.
+type Origin struct {
+	Provider string    // the name of provider, its String() if it is a fmt.Stringer
+	Layer    int       // the load order of the provider, starts from 1. 0 means a runtime writing by user
+	Time     time.Time // when the key was written
+}
+
+// IsRuntime reports whether the key was written by user at
+// runtime (via Set, Merge, ...) rather than loaded from a provider.
+func (o Origin) IsRuntime() bool { return o.Layer == 0 }
+
+func (o Origin) String() string {
+	if o.IsRuntime() {
+		return "(runtime)"
+	}
+	return "#" + strconv.Itoa(o.Layer) + ":" + o.Provider
+}
+
+func providerName(p Provider) string {
+	if p == nil {
+		return ""
+	}
+	if ss, ok := p.(fmt.Stringer); ok {
+		return ss.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// originsS is shared by a store and all of its prefixed views.
+type originsS struct {
+	mu     sync.RWMutex
+	layers int
+	m      map[string][]Origin
+}
+
+func newOrigins() *originsS {
+	return &originsS{m: make(map[string][]Origin)}
+}
+
+// newLayer allocates the next load order for a provider.
+func (s *originsS) newLayer(p Provider) Origin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layers++
+	return Origin{Provider: providerName(p), Layer: s.layers}
+}
+
+// record appends the origin of a writing to key. A key keeps the
+// latest record of each layer only, so its chain is bounded by
+// the count of providers, however many times it's reloaded.
+func (s *originsS) record(key string, o Origin) {
+	o.Time = time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.m[key]
+	for i, it := range c {
+		if it.Layer == o.Layer {
+			c = append(c[:i:i], c[i+1:]...)
+			break
+		}
+	}
+	s.m[key] = append(c, o)
+}
+
+// forget removes the records of 'key' and its children.
+func (s *originsS) forget(key string, delimiter rune) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, key)
+	prefix := strings.TrimSuffix(key, string(delimiter)) + string(delimiter)
+	for k := range s.m {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.m, k)
+		}
+	}
+}
+
+func (s *originsS) chain(key string) (ret []Origin) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c := s.m[key]; len(c) > 0 {
+		ret = make([]Origin, len(c))
+		copy(ret, c)
+	}
+	return
+}
+
+// rekey converts the keys delimited by 'from' to 'to'.
+func (s *originsS) rekey(from, to rune) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make(map[string][]Origin, len(s.m))
+	for k, v := range s.m {
+		m[radix.RekeyPath(k, from, to)] = v
+	}
+	s.m = m
+}
+
+// relocate carries the records of key 'from' to 'to', they are
+// kept at 'from' too for a copying.
+func (s *originsS) relocate(from, to string, keep bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.m[from]; ok {
+		s.m[to] = append([]Origin(nil), c...)
+		if !keep {
+			delete(s.m, from)
+		}
+	}
+}
+
+func (s *originsS) dup() *originsS {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := &originsS{layers: s.layers, m: make(map[string][]Origin, len(s.m))}
+	for k, v := range s.m {
+		n.m[k] = append([]Origin(nil), v...)
+	}
+	return n
+}
+
+// writer returns the origin of the writings via this view, a
+// runtime writing if it's not a view of a loader.
+func (s *storeS) writer() (o Origin) {
+	if s.origin != nil {
+		o = *s.origin
+	}
+	return
+}
+
+// Origin returns the provenance chain of a key, in writing order.
+// The last one is the effective source. A layer appears once in
+// the chain, at its latest writing.
+//
+// It returns nil if origin tracking is disabled (see
+// WithOriginTracking), or the key was never written.
+func (s *storeS) Origin(path string) (chain []Origin) {
+	if s.origins == nil {
+		return
+	}
+	return s.origins.chain(s.join(s.Prefix(), path))
+}
+
+// Explain dumps all values with their sources, for debugging
+// the overrides among providers.
+//
+// Each line looks like:
+//
+//	app.server.port = 7999    <= #3:env, #1:maps
+//
+// The sources are listed from the effective one to the
+// oldest one.
+func (s *storeS) Explain() (text string) {
+	var sb strings.Builder
+	for path, val := range s.Trie.WithPrefixReplaced().Leaves("") {
+		_, _ = sb.WriteString(path)
+		_, _ = sb.WriteString(" = ")
+		_, _ = sb.WriteString(fmt.Sprint(val))
+		if s.origins != nil {
+			if c := s.origins.chain(path); len(c) > 0 {
+				_, _ = sb.WriteString("    <= ")
+				for i := len(c) - 1; i >= 0; i-- {
+					_, _ = sb.WriteString(c[i].String())
+					if i > 0 {
+						_, _ = sb.WriteString(", ")
+					}
+				}
+			}
+		}
+		_ = sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// mapPvdr is a minimal Provider for testing.
+type mapPvdr struct {
+	name string
+	m    map[string]ValPkg
+}
+
+func newMapPvdr(name string, kv map[string]any) *mapPvdr {
+	s := &mapPvdr{name: name, m: make(map[string]ValPkg)}
+	for k, v := range kv {
+		s.m[k] = ValPkg{Value: v}
+	}
+	return s
+}
+
+func (s *mapPvdr) String() string                         { return s.name }
+func (s *mapPvdr) Read() (m map[string]ValPkg, err error) { return s.m, nil }
+func (s *mapPvdr) GetCodec() (codec Codec)                { return }
+func (s *mapPvdr) GetPosition() (pos string)              { return }
+func (s *mapPvdr) WithCodec(codec Codec)                  {}
+func (s *mapPvdr) WithPosition(pos string)                {}
+
+func TestStoreS_Origin(t *testing.T) {
+	conf := New(WithOriginTracking(true))
+	defer conf.Close()
+	ctx := context.TODO()
+
+	for _, p := range []*mapPvdr{
+		newMapPvdr("defaults", map[string]any{"app.port": 7999, "app.host": "localhost"}),
+		newMapPvdr("env", map[string]any{"app.port": 8080}),
+	} {
+		if _, err := conf.Load(ctx, WithProvider(p)); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+	}
+
+	chain := conf.Origin("app.port")
+	assertEqual(t, 2, len(chain))
+	assertEqual(t, "defaults", chain[0].Provider)
+	assertEqual(t, 1, chain[0].Layer)
+	assertEqual(t, "env", chain[1].Provider)
+	assertEqual(t, 2, chain[1].Layer)
+
+	chain = conf.WithPrefix("app").Origin("host")
+	assertEqual(t, 1, len(chain))
+	assertEqual(t, "defaults", chain[0].Provider)
+
+	conf.Set("app.port", 9090)
+	chain = conf.Origin("app.port")
+	assertEqual(t, 3, len(chain))
+	assertTrue(t, chain[2].IsRuntime(), "expecting a runtime origin")
+
+	text := conf.Explain()
+	t.Logf("\n%v", text)
+	assertTrue(t, strings.Contains(text, "app.port = 9090    <= (runtime), #2:env, #1:defaults"), "bad explain output")
+
+	dup := conf.Dup()
+	conf.Remove("app.port")
+	assertEqual(t, 0, len(conf.Origin("app.port")))
+	assertEqual(t, 3, len(dup.Origin("app.port")))
+
+	assertEqual(t, 0, len(New().Origin("app.port")), "tracking is disabled by default")
+}
+
+func TestStoreS_OriginBounded(t *testing.T) {
+	conf := New(WithOriginTracking(true), WithWatchEnable(true))
+	defer conf.Close()
+	src := &watchableS{data: `{"app":{"port":1}}`}
+	if _, err := conf.Load(context.TODO(), WithProvider(src)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for i := 2; i < 10; i++ {
+		conf.Set("app.port", 0)
+		src.change(OpWrite, `{"app":{"port":`+string(rune('0'+i))+`}}`)
+	}
+	assertEqual(t, 9, conf.MustInt("app.port"))
+	chain := conf.Origin("app.port")
+	assertEqual(t, 2, len(chain), "one record per layer")
+	assertTrue(t, chain[0].IsRuntime(), "the runtime writing is older")
+	assertEqual(t, 1, chain[1].Layer, "the reload is the latest")
+}
+
+func TestStoreS_OriginRuntimeWhileLoading(t *testing.T) {
+	conf := New(WithOriginTracking(true))
+	defer conf.Close()
+	// a runtime writing from a watcher, while the provider is loading
+	unsubscribe := conf.Watch("app.port", func(d Delta) { conf.Set("app.user", "u") })
+	defer unsubscribe()
+	if _, err := conf.Load(context.TODO(), WithProvider(newMapPvdr("defaults", map[string]any{"app.port": 7999}))); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertEqual(t, "defaults", conf.Origin("app.port")[0].Provider)
+	chain := conf.Origin("app.user")
+	assertEqual(t, 1, len(chain))
+	assertTrue(t, chain[0].IsRuntime(), "a runtime writing should not be attributed to the loading provider")
+}
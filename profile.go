@@ -0,0 +1,179 @@
+package store
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// ProfilesKey is the key under a base path holding the profiles,
+// such as "app.profiles.dev" for the base "app".
+const ProfilesKey = "profiles"
+
+// ProfilesEnvVar is the environment variable to select the
+// profiles, in comma-separated form, see [Store.ActivateProfiles].
+const ProfilesEnvVar = "STORE_PROFILES"
+
+// profilesS holds the state of the activated profiles, per base
+// path.
+type profilesS struct {
+	mu    sync.Mutex
+	bases map[string]*profileS
+}
+
+type profileS struct {
+	names     []string
+	raw       map[string]any // the leaves without overlays
+	effective map[string]any // the leaves after the last activation
+}
+
+func newProfiles() *profilesS {
+	return &profilesS{bases: make(map[string]*profileS)}
+}
+
+// ActivateProfiles overlays the profile subtrees onto the base
+// path in order, the later profiles win.
+//
+// The profiles live under base, such as:
+//
+//	app:
+//	  server: {port: 80, debug: false}
+//	  profiles:
+//	    dev:  {server: {debug: true}}
+//	    prod: {server: {port: 443}}
+//
+// ActivateProfiles("app", "dev") sets app.server.debug to true. A
+// later call switches the profiles: the overlays of the former
+// ones are reverted, and the change events are fired for the keys
+// whose effective value changed only. Calling it without names
+// selects the profiles by the env var STORE_PROFILES, such as
+// "dev,local"; or deactivates all profiles if it's empty.
+//
+// The store holds the effective values, RawView returns the
+// values without overlays. The changes made by Set after an
+// activation are kept by the later activations.
+//
+// If a key to be changed cannot be written by the view, see
+// ReadOnly and Freeze, nothing is changed and a *ReadOnlyError is
+// returned.
+func (s *storeS) ActivateProfiles(base string, names ...string) (err error) {
+	if len(names) == 0 {
+		names = profilesFromEnv()
+	}
+
+	var profiles []map[string]any
+	for _, name := range names {
+		path := s.join(base, ProfilesKey, name)
+		leaves := collectLeaves(s, path)
+		if len(leaves) == 0 {
+			return errors.New("profile %q not found at %q", name, path)
+		}
+		profiles = append(profiles, leaves)
+	}
+
+	s.profiles.mu.Lock()
+	defer s.profiles.mu.Unlock()
+
+	key := s.join(s.Prefix(), base)
+	p, ok := s.profiles.bases[key]
+	cur := s.baseLeaves(base)
+	if !ok {
+		p = &profileS{raw: cur, effective: cur}
+		s.profiles.bases[key] = p
+	} else {
+		p.syncRaw(cur)
+	}
+
+	effective := make(map[string]any, len(p.raw))
+	for k, v := range p.raw {
+		effective[k] = v
+	}
+	for i, leaves := range profiles {
+		from := s.join(base, ProfilesKey, names[i])
+		for k, v := range leaves {
+			effective[s.join(base, strings.TrimPrefix(k[len(from):], string(s.Delimiter())))] = v
+		}
+	}
+
+	if err = s.Apply(diffLeaves(cur, effective)); err != nil {
+		return
+	}
+	p.names, p.effective = append([]string(nil), names...), effective
+	s.history.autoPush(s.Trie)
+	return
+}
+
+// ActiveProfiles returns the profiles activated on the base path.
+func (s *storeS) ActiveProfiles(base string) (names []string) {
+	s.profiles.mu.Lock()
+	defer s.profiles.mu.Unlock()
+	if p, ok := s.profiles.bases[s.join(s.Prefix(), base)]; ok {
+		names = append(names, p.names...)
+	}
+	return
+}
+
+// RawView returns a snapshot of the subtree at base without the
+// overlays of the activated profiles. The subtree itself is the
+// effective view.
+func (s *storeS) RawView(base string) (raw Store) {
+	st := newStore(WithDelimiter(s.Delimiter()), WithFlattenSlice(s.flattenSlice))
+	leaves := s.baseLeaves(base)
+	s.profiles.mu.Lock()
+	if p, ok := s.profiles.bases[s.join(s.Prefix(), base)]; ok {
+		p.syncRaw(leaves)
+		leaves = p.raw
+	}
+	s.profiles.mu.Unlock()
+	for k, v := range leaves {
+		st.Set(k, v)
+	}
+	for k, v := range collectLeaves(s, s.join(base, ProfilesKey)) {
+		st.Set(k, v)
+	}
+	return st
+}
+
+// baseLeaves returns the leaves under base, except the profiles.
+func (s *storeS) baseLeaves(base string) (leaves map[string]any) {
+	leaves = collectLeaves(s, base)
+	profiles := s.join(base, ProfilesKey) + string(s.Delimiter())
+	for k := range leaves {
+		if strings.HasPrefix(k, profiles) {
+			delete(leaves, k)
+		}
+	}
+	return
+}
+
+// syncRaw merges the changes made since the last activation, by
+// Set or Remove, into the raw leaves.
+func (p *profileS) syncRaw(cur map[string]any) {
+	raw := make(map[string]any, len(p.raw))
+	for k, v := range p.raw {
+		raw[k] = v
+	}
+	for k, v := range cur {
+		if ev, ok := p.effective[k]; !ok || !reflect.DeepEqual(ev, v) {
+			raw[k] = v
+		}
+	}
+	for k := range p.effective {
+		if _, ok := cur[k]; !ok {
+			delete(raw, k)
+		}
+	}
+	p.raw, p.effective = raw, cur
+}
+
+func profilesFromEnv() (names []string) {
+	for _, name := range strings.Split(os.Getenv(ProfilesEnvVar), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return
+}
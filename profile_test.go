@@ -0,0 +1,94 @@
+package store
+
+import (
+	"testing"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+func newProfilesStore() Store {
+	conf := New()
+	conf.Set("app.server.port", 80)
+	conf.Set("app.server.debug", false)
+	conf.Set("app.name", "demo")
+	conf.Set("app.profiles.dev.server.debug", true)
+	conf.Set("app.profiles.dev.server.trace", "on")
+	conf.Set("app.profiles.prod.server.port", 443)
+	return conf
+}
+
+func TestStoreS_ActivateProfiles(t *testing.T) {
+	conf := newProfilesStore()
+	defer conf.Close()
+
+	var deltas []Delta
+	conf.Watch("app.**", func(d Delta) { deltas = append(deltas, d) })
+
+	if err := conf.ActivateProfiles("app", "dev"); err != nil {
+		t.Fatalf("ActivateProfiles failed: %v", err)
+	}
+	assertEqual(t, []string{"dev"}, conf.ActiveProfiles("app"))
+	assertTrue(t, conf.MustBool("app.server.debug"))
+	assertEqual(t, "on", conf.MustString("app.server.trace"))
+	assertEqual(t, []string{"app.server.debug", "app.server.trace"}, deltaPaths(deltas))
+
+	// switch, the overlays of dev are reverted
+	deltas = nil
+	conf.Set("app.name", "changed")
+	deltas = nil
+	if err := conf.ActivateProfiles("app", "prod"); err != nil {
+		t.Fatalf("ActivateProfiles failed: %v", err)
+	}
+	assertEqual(t, []string{"app.server.debug", "app.server.port", "app.server.trace"}, deltaPaths(deltas))
+	assertFalse(t, conf.MustBool("app.server.debug"))
+	assertFalse(t, conf.Has("app.server.trace"))
+	assertEqual(t, 443, conf.MustInt("app.server.port"))
+	assertEqual(t, "changed", conf.MustString("app.name"), "the changes by Set are kept")
+
+	raw := conf.RawView("app")
+	assertEqual(t, 80, raw.MustInt("app.server.port"))
+	assertEqual(t, "changed", raw.MustString("app.name"))
+	assertEqual(t, 443, raw.MustInt("app.profiles.prod.server.port"))
+
+	// deactivate
+	if err := conf.ActivateProfiles("app"); err != nil {
+		t.Fatalf("ActivateProfiles failed: %v", err)
+	}
+	assertEqual(t, 0, len(conf.ActiveProfiles("app")))
+	assertEqual(t, 80, conf.MustInt("app.server.port"))
+
+	assertTrue(t, conf.ActivateProfiles("app", "missing") != nil)
+}
+
+func TestStoreS_ActivateProfilesFromEnv(t *testing.T) {
+	conf := newProfilesStore()
+	defer conf.Close()
+
+	t.Setenv(ProfilesEnvVar, "dev, prod")
+	if err := conf.ActivateProfiles("app"); err != nil {
+		t.Fatalf("ActivateProfiles failed: %v", err)
+	}
+	assertEqual(t, []string{"dev", "prod"}, conf.ActiveProfiles("app"))
+	assertTrue(t, conf.MustBool("app.server.debug"))
+	assertEqual(t, 443, conf.MustInt("app.server.port"))
+}
+
+func TestStoreS_ActivateProfilesProtected(t *testing.T) {
+	conf := newProfilesStore()
+	defer conf.Close()
+
+	err := conf.ReadOnly().ActivateProfiles("app", "dev")
+	assertTrue(t, errors.Is(err, ErrReadOnly), "read-only view")
+	assertEqual(t, 0, len(conf.ActiveProfiles("app")))
+	assertFalse(t, conf.MustBool("app.server.debug"))
+
+	unfreeze := conf.Freeze("app.server.trace")
+	err = conf.ActivateProfiles("app", "dev")
+	assertTrue(t, errors.Is(err, ErrReadOnly), "frozen key")
+	assertEqual(t, 0, len(conf.ActiveProfiles("app")))
+	assertFalse(t, conf.MustBool("app.server.debug"), "nothing is applied")
+
+	unfreeze()
+	assertTrue(t, conf.ActivateProfiles("app", "dev") == nil)
+	assertEqual(t, []string{"dev"}, conf.ActiveProfiles("app"))
+}
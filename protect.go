@@ -0,0 +1,315 @@
+package store
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logz "github.com/hedzr/logg/slog"
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// ErrReadOnly is the identifier of *ReadOnlyError.
+//
+//	if errors.Is(err, store.ErrReadOnly) { ... }
+var ErrReadOnly = errors.New("read-only")
+
+// ReadOnlyError is returned for a writing to a read-only view, or
+// to a frozen subtree.
+type ReadOnlyError struct {
+	Op   string // "set", "merge", "remove", "settl", "update", "move", "copy", "load", "tx", "rollback", "change delimiter", ...
+	Path string // the full dotted path of the key
+}
+
+func (e *ReadOnlyError) Error() string {
+	return "cannot " + e.Op + " " + e.Path + ": read-only"
+}
+
+func (e *ReadOnlyError) Is(target error) bool { return target == ErrReadOnly } //nolint:errorlint
+
+// FreezeOpt is the options for [Store.Freeze].
+type FreezeOpt func(s *frozenS)
+
+// WithProviderUpdates permits the watching providers to update
+// the frozen subtree, and the later Load calls to load into it.
+func WithProviderUpdates(allow bool) FreezeOpt {
+	return func(s *frozenS) {
+		s.allowProvider = allow
+	}
+}
+
+// ReadOnly returns a view rejecting all writings, by Set, Merge,
+// Remove, SetTTL, Update, Load, Tx, Rollback, and so on. The views
+// derived from it, such as by WithPrefix, are read-only too.
+//
+// It's for handing the store to a library, which only reads its
+// own section:
+//
+//	lib.Init(conf.WithPrefix("lib").ReadOnly())
+//
+// The methods which return an error report a *ReadOnlyError, the
+// others don't write and log it as a warning. See also Writable.
+func (s *storeS) ReadOnly() (ro Store) {
+	st := s.dupS(s.Trie)
+	st.readOnly = true
+	return st
+}
+
+// Freeze protects the subtree at path from the writings of all
+// views of the store, and returns a function to unfreeze it.
+//
+// By default, the updates from the watching providers to the
+// subtree are rejected too, see WithProviderUpdates.
+//
+// The methods which return an error report a *ReadOnlyError, the
+// others don't write and log it as a warning. See also Writable.
+func (s *storeS) Freeze(path string, opts ...FreezeOpt) (unfreeze func()) {
+	f := &frozenS{path: s.join(s.Prefix(), path)}
+	for _, opt := range opts {
+		opt(f)
+	}
+	s.guards.add(f)
+	var once sync.Once
+	return func() { once.Do(func() { s.guards.remove(f) }) }
+}
+
+// Writable reports a *ReadOnlyError if the key at path cannot be
+// written by the view.
+func (s *storeS) Writable(path string) (err error) {
+	return s.checkWritable("set", path, false)
+}
+
+// checkWritable tests if the key at path can be written. With
+// subtree, the frozen subtrees under path are taken into account,
+// for the removing.
+func (s *storeS) checkWritable(op, path string, subtree bool) (err error) {
+	if !s.readOnly && s.guards.empty() {
+		return
+	}
+	return s.checkWritableFull(op, s.join(s.Prefix(), path), subtree)
+}
+
+// checkWritableFull is checkWritable for a full path, which is
+// not relative to the prefix.
+func (s *storeS) checkWritableFull(op, full string, subtree bool) (err error) {
+	if s.readOnly {
+		return &ReadOnlyError{Op: op, Path: full}
+	}
+	if !s.guards.empty() && s.guards.rejects(full, string(s.Delimiter()), s.inLoading() || s.fromProvider, subtree) {
+		return &ReadOnlyError{Op: op, Path: full}
+	}
+	return
+}
+
+// writable is checkWritable for the methods which don't return
+// an error, the rejection is logged.
+func (s *storeS) writable(op, path string, subtree bool) bool {
+	return s.logRejected(s.checkWritable(op, path, subtree))
+}
+
+func (s *storeS) logRejected(err error) bool {
+	if err != nil {
+		logz.Warn("[store] writing rejected", "err", err)
+		return false
+	}
+	return true
+}
+
+// providerView returns a view of s for the updates from the
+// watching providers, see WithProviderUpdates.
+func (s *storeS) providerView() (st *storeS) {
+	st = s.dupS(s.Trie)
+	st.parent = s
+	st.fromProvider = true
+	return
+}
+
+// SetEx is advanced version of Set, see [radix.Trie.SetEx].
+func (s *storeS) SetEx(path string, data any, cb radix.OnSetEx[any]) (oldData any) {
+	if !s.writable("set", path, false) {
+		return
+	}
+	return s.Trie.SetEx(path, data, cb)
+}
+
+// The writing methods promoted from radix.Trie are overridden
+// below, so that a read-only view or a frozen subtree cannot be
+// written through them.
+
+// Insert is Set without the events, see [radix.Trie.Insert].
+func (s *storeS) Insert(path string, data any) (oldData any) {
+	if !s.writable("set", path, false) {
+		return
+	}
+	return s.Trie.Insert(path, data)
+}
+
+// SetNode sets the all node fields at once, see [radix.Trie.SetNode].
+func (s *storeS) SetNode(path string, data any, tag any, descriptionAndComments ...string) (ret radix.Node[any], oldData any) {
+	if !s.writable("set", path, false) {
+		return
+	}
+	return s.Trie.SetNode(path, data, tag, descriptionAndComments...)
+}
+
+// SetEmpty clears the Data field, see [radix.Trie.SetEmpty].
+func (s *storeS) SetEmpty(path string) (oldData any) {
+	if !s.writable("set", path, false) {
+		return
+	}
+	return s.Trie.SetEmpty(path)
+}
+
+// Modify updates a copy of the node at path, see [radix.Trie.Modify].
+func (s *storeS) Modify(path string, fn func(node radix.Node[any])) (node radix.Node[any], ok bool) {
+	if !s.writable("update", path, false) {
+		return
+	}
+	return s.Trie.Modify(path, fn)
+}
+
+// SetTTLFast sets a ttl for a located node, see [radix.Trie.SetTTLFast].
+func (s *storeS) SetTTLFast(node radix.Node[any], ttl time.Duration, cb radix.OnTTLRinging[any]) (state int) {
+	if node == nil || !s.logRejected(s.checkWritableFull("settl", node.Key(), false)) {
+		return -1
+	}
+	return s.Trie.SetTTLFast(node, ttl, cb)
+}
+
+// SetDelimiter sets the delimiter of the whole tree, see
+// [radix.Trie.SetDelimiter].
+func (s *storeS) SetDelimiter(delimiter rune) {
+	if !s.logRejected(s.checkWritableFull("set delimiter", "", true)) {
+		return
+	}
+	s.Trie.SetDelimiter(delimiter)
+}
+
+// Batch writes the whole tree at once, see [radix.Trie.Batch].
+func (s *storeS) Batch(fn func(tx radix.Trie[any]) (err error)) (err error) {
+	if err = s.checkWritableFull("batch", "", true); err != nil {
+		return
+	}
+	return s.Trie.Batch(fn)
+}
+
+// Restore replaces the whole tree with a snapshot, see
+// [radix.Trie.Restore]. Use Rollback to fire the events.
+func (s *storeS) Restore(snapshot *radix.Snapshot[any]) {
+	if !s.logRejected(s.checkWritableFull("restore", "", true)) {
+		return
+	}
+	s.Trie.Restore(snapshot)
+}
+
+// SetInterpolation is shared by all views, see [radix.Trie.SetInterpolation].
+func (s *storeS) SetInterpolation(enabled bool) {
+	if !s.configurable("set interpolation") {
+		return
+	}
+	s.Trie.SetInterpolation(enabled)
+}
+
+// RegisterResolver is shared by all views, see [radix.Trie.RegisterResolver].
+func (s *storeS) RegisterResolver(scheme string, resolver radix.Resolver) {
+	if !s.configurable("register resolver") {
+		return
+	}
+	s.Trie.RegisterResolver(scheme, resolver)
+}
+
+// RegisterValueResolver is shared by all views, see
+// [radix.Trie.RegisterValueResolver].
+func (s *storeS) RegisterValueResolver(prefix string, resolver radix.Resolver) {
+	if !s.configurable("register resolver") {
+		return
+	}
+	s.Trie.RegisterValueResolver(prefix, resolver)
+}
+
+// configurable tests the settings shared by all views, which
+// are not bound to a path, so the frozen subtrees don't matter.
+func (s *storeS) configurable(op string) bool {
+	if s.readOnly {
+		return s.logRejected(&ReadOnlyError{Op: op, Path: s.Prefix()})
+	}
+	return true
+}
+
+// guardsS holds the frozen subtrees, shared with the prefixed
+// views.
+type guardsS struct {
+	count  atomic.Int32
+	mu     sync.RWMutex
+	frozen []*frozenS
+}
+
+type frozenS struct {
+	path          string // full path
+	allowProvider bool
+}
+
+func newGuards() *guardsS { return &guardsS{} }
+
+func (s *guardsS) empty() bool { return s == nil || s.count.Load() == 0 }
+
+func (s *guardsS) add(f *frozenS) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frozen = append(s.frozen, f)
+	s.count.Store(int32(len(s.frozen)))
+}
+
+func (s *guardsS) remove(f *frozenS) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, it := range s.frozen {
+		if it == f {
+			s.frozen = append(s.frozen[:i], s.frozen[i+1:]...)
+			break
+		}
+	}
+	s.count.Store(int32(len(s.frozen)))
+}
+
+// rejects tests if the full path is in a frozen subtree, or with
+// subtree, holds a frozen subtree.
+func (s *guardsS) rejects(path, delim string, provider, subtree bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.frozen {
+		if provider && f.allowProvider {
+			continue
+		}
+		if f.path == "" || path == f.path || strings.HasPrefix(path, f.path+delim) {
+			return true
+		}
+		if subtree && (path == "" || strings.HasPrefix(f.path, path+delim)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMergeable tests the leaves of data to be merged at pathAt.
+func (s *storeS) checkMergeable(pathAt string, data map[string]any) (err error) {
+	if !s.readOnly && s.guards.empty() {
+		return
+	}
+	for k, v := range data {
+		path := s.join(pathAt, s.mapKey(k))
+		if m, ok := v.(map[string]any); ok {
+			if err = s.checkMergeable(path, m); err != nil {
+				return
+			}
+			continue
+		}
+		if err = s.checkWritable("merge", path, false); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+func TestStoreS_ReadOnly(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("lib.name", "x")
+	conf.Set("app.port", 80)
+
+	ro := conf.WithPrefix("lib").ReadOnly()
+	assertEqual(t, "x", ro.MustString("name"))
+
+	ro.Set("name", "y")
+	assertEqual(t, "x", conf.MustString("lib.name"))
+	assertFalse(t, ro.Remove("name"))
+	assertEqual(t, -1, ro.SetTTL("name", time.Second, nil))
+	ro.Update("name", func(node radix.Node[any], old any) { t.Fatal("should not be updated") })
+
+	err := ro.Merge("", map[string]any{"name": "z"})
+	assertTrue(t, errors.Is(err, ErrReadOnly))
+	var roe *ReadOnlyError
+	assertTrue(t, errors.As(err, &roe))
+	assertEqual(t, "lib.name", roe.Path)
+
+	assertTrue(t, errors.Is(ro.WithPrefix("sub").Writable("k"), ErrReadOnly), "derived views are read-only")
+	_, err = ro.Load(context.TODO(), WithProvider(&watchableS{data: `{}`}))
+	assertTrue(t, errors.Is(err, ErrReadOnly))
+	assertTrue(t, conf.Writable("lib.name") == nil, "the store itself is writable")
+}
+
+func TestStoreS_ReadOnlyPromoted(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("lib.name", "x")
+	conf.SetComment("lib.name", "desc", "comment")
+	v, _ := conf.Snapshot()
+	conf.Set("lib.name", "y")
+
+	ro := conf.WithPrefix("lib").ReadOnly()
+	assertFalse(t, ro.SetComment("name", "", ""), "SetComment should be rejected")
+	assertFalse(t, ro.SetTag("name", 1), "SetTag should be rejected")
+	assertEqual(t, "comment", conf.MustGetComment("lib.name"))
+	assertEqual(t, nil, conf.MustGetTag("lib.name"))
+
+	node, _, _, _ := conf.Locate("lib.name", nil)
+	assertEqual(t, -1, ro.SetTTLFast(node, time.Millisecond, nil))
+	ro.SetDelimiter('/')
+	assertEqual(t, '.', conf.Delimiter())
+
+	assertTrue(t, errors.Is(ro.Rollback(v), ErrReadOnly))
+	assertTrue(t, errors.Is(ro.Tx(func(tx Store) error { return nil }), ErrReadOnly))
+	assertTrue(t, errors.Is(ro.Move("name", "name2"), ErrReadOnly))
+	assertTrue(t, errors.Is(ro.ChangeDelimiter('/'), ErrReadOnly))
+	assertEqual(t, "y", conf.MustString("lib.name"))
+
+	trie := ro.(*storeS) // the promoted methods
+	trie.Insert("name", "z")
+	trie.SetNode("name", "z", nil)
+	trie.SetEmpty("name")
+	trie.Modify("name", func(node radix.Node[any]) { t.Fatal("should not be modified") })
+	assertTrue(t, errors.Is(trie.Batch(func(tx radix.Trie[any]) error { return nil }), ErrReadOnly))
+	trie.Restore(conf.(*storeS).Trie.Snapshot())
+	trie.SetInterpolation(true)
+	trie.RegisterResolver("x", func(name string) (string, error) { return "", nil })
+	assertEqual(t, "y", conf.MustString("lib.name"))
+
+	conf.Set("app.secret", "${x:name}")
+	assertEqual(t, "${x:name}", conf.MustString("app.secret"), "the resolvers are not changed")
+
+	unfreeze := conf.Freeze("lib")
+	defer unfreeze()
+	assertFalse(t, conf.SetComment("lib.name", "", ""), "frozen")
+	assertFalse(t, conf.SetTag("lib.name", 1), "frozen")
+	assertTrue(t, errors.Is(conf.(*storeS).Batch(func(tx radix.Trie[any]) error { return nil }), ErrReadOnly), "holds a frozen subtree")
+}
+
+func TestStoreS_Freeze(t *testing.T) {
+	conf := New(WithWatchEnable(true))
+	defer conf.Close()
+	src := &watchableS{data: `{"app":{"server":{"port":80},"name":"demo"}}`}
+	if _, err := conf.Load(context.TODO(), WithProvider(src)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	unfreeze := conf.Freeze("app.server")
+	conf.Set("app.server.port", 8080)
+	assertEqual(t, 80, conf.MustInt("app.server.port"))
+	conf.WithPrefix("app").Set("server.host", "a")
+	assertFalse(t, conf.Has("app.server.host"), "frozen in all views")
+	assertFalse(t, conf.Remove("app"), "holds a frozen subtree")
+	assertTrue(t, errors.Is(conf.Merge("app", map[string]any{"server": map[string]any{"port": 1}}), ErrReadOnly))
+	assertTrue(t, conf.Merge("app", map[string]any{"name": "x"}) == nil, "outside of the frozen subtree")
+	assertTrue(t, errors.Is(conf.Tx(func(tx Store) error {
+		tx.Set("app.server.port", 1)
+		return tx.Writable("app.server.port")
+	}), ErrReadOnly))
+
+	// provider updates
+	src.cb(&changeS{op: OpWrite, pairs: [][2]any{{"app.server.port", 81}}}, nil)
+	assertEqual(t, 80, conf.MustInt("app.server.port"), "rejected by default")
+	unfreeze()
+	unfreeze = conf.Freeze("app.server", WithProviderUpdates(true))
+	defer unfreeze()
+	src.cb(&changeS{op: OpWrite, pairs: [][2]any{{"app.server.port", 82}}}, nil)
+	assertEqual(t, 82, conf.MustInt("app.server.port"), "permitted")
+	conf.Set("app.server.port", 8080)
+	assertEqual(t, 82, conf.MustInt("app.server.port"))
+}
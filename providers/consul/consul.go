@@ -150,14 +150,21 @@ func (s *pvdr) Reader() (r store.Reader, err error) {
 }
 
 func (s *pvdr) Read() (data map[string]store.ValPkg, err error) {
+	return s.ReadContext(context.Background())
+}
+
+// ReadContext reads the keys from consul, the request will be
+// cancelled once ctx done.
+func (s *pvdr) ReadContext(ctx context.Context) (data map[string]store.ValPkg, err error) {
 	var kv = s.Client.KV()
 	var pairs api.KVPairs
 	var pair *api.KVPair
+	var q = (&api.QueryOptions{}).WithContext(ctx)
 
 	data = make(map[string]store.ValPkg)
 
 	if s.recursive {
-		pairs, _, err = kv.List(s.position, nil)
+		pairs, _, err = kv.List(s.position, q)
 		if err != nil {
 			return
 		}
@@ -204,10 +211,13 @@ func (s *pvdr) Read() (data map[string]store.ValPkg, err error) {
 		return
 	}
 
-	pair, _, err = kv.Get(s.position, nil)
+	pair, _, err = kv.Get(s.position, q)
 	if err != nil {
 		return
 	}
+	if pair == nil {
+		return
+	}
 
 	if s.processMeta {
 		m := make(map[string]any)
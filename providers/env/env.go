@@ -34,6 +34,8 @@ type pvdr struct {
 	underline2dot bool
 }
 
+func (s *pvdr) String() string { return "env" }
+
 type Opt func(s *pvdr)
 
 func WithCodec(codec store.Codec) Opt {
@@ -167,7 +167,13 @@ func (s *pvdr) NormalizeKey(key string) string {
 }
 
 func (s *pvdr) Read() (data map[string]store.ValPkg, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), s.config.DialTimeout)
+	return s.ReadContext(context.Background())
+}
+
+// ReadContext reads the keys from etcd, the request will be
+// cancelled once ctx done, or the DialTimeout elapsed.
+func (s *pvdr) ReadContext(ctx context.Context) (data map[string]store.ValPkg, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.DialTimeout)
 	defer cancel()
 
 	var resp *clientv3.GetResponse
@@ -22,13 +22,14 @@ func (s *changeS) Op() store.Op             { return s.lastOp }
 func (s *changeS) Has(op store.Op) bool     { return uint64(s.lastOp)&uint64(op) != 0 }
 func (s *changeS) Timestamp() time.Time     { return s.lastEventTime }
 func (s *changeS) Provider() store.Provider { return s.provider }
-func (s *changeS) Next() (key string, val any, ok bool) {
-	if s.idx == 0 {
-		key, val, ok = s.realPath, s.realPath, true
-		s.idx++
-	}
-	return
-}
+
+// Next yields nothing, the store reloads the whole file for a
+// file change, see Reload.
+func (s *changeS) Next() (key string, val any, ok bool) { return }
+
+// Reload tells the store to re-read the file and apply the
+// key-level differences, see store.Reloadable.
+func (s *changeS) Reload() bool { return true }
 func (s *changeS) Set() {
 	s.idx = 0
 }
@@ -3,7 +3,6 @@ package file
 import (
 	"context"
 	"errors"
-	"fmt"
 	"os"
 	"path/filepath"
 	"sync/atomic"
@@ -40,6 +39,18 @@ func WithWatchEnabled(b bool) Opt {
 	}
 }
 
+// WithDebounce sets how long the watcher waits for the writings
+// to a file settling before reporting a change, 50ms by default.
+//
+// A file saved in place, such as by os.WriteFile, is truncated
+// and then written, so its first event comes with a partial
+// content.
+func WithDebounce(d time.Duration) Opt {
+	return func(s *pvdr) {
+		s.debounce = d
+	}
+}
+
 func WithWriteBackEnabled(b bool) Opt {
 	return func(s *pvdr) {
 		s.writeEnabled = b
@@ -53,10 +64,13 @@ type pvdr struct {
 	watchEnabled bool
 	writeEnabled bool
 	watching     int32
+	debounce     time.Duration
 	codec        store.Codec
 	prefix       string
 }
 
+const defaultDebounce = 50 * time.Millisecond
+
 func (s *pvdr) String() string { return s.file }
 
 func (s *pvdr) Count() int {
@@ -98,15 +112,25 @@ func (s *pvdr) Read() (data map[string]store.ValPkg, err error) {
 }
 
 func (s *pvdr) ReadBytes() (data []byte, err error) {
-	data, err = os.ReadFile(s.file)
+	return s.ReadBytesContext(context.Background())
+}
+
+func (s *pvdr) ReadBytesContext(ctx context.Context) (data []byte, err error) {
+	if err = ctx.Err(); err == nil {
+		data, err = os.ReadFile(s.file)
+	}
 	return
 }
 
 func (s *pvdr) Write(data []byte) (err error) {
-	if s.writeEnabled {
-		err = os.WriteFile(s.file, data, 0644)
-	} else {
+	return s.WriteContext(context.Background(), data)
+}
+
+func (s *pvdr) WriteContext(ctx context.Context, data []byte) (err error) {
+	if !s.writeEnabled {
 		err = store.ErrWritableDisabled
+	} else if err = ctx.Err(); err == nil {
+		err = os.WriteFile(s.file, data, 0644)
 	}
 	return
 }
@@ -152,14 +176,22 @@ func (s *pvdr) Watch(ctx context.Context, cb func(event any, err error)) (err er
 
 func (s *pvdr) watchRunner(ctx context.Context, cb func(event any, err error), w *fsnotify.Watcher, realPath string) {
 	var (
-		// lastEvent     string
-		// lastEventTime time.Time
 		lastChange = changeS{provider: s}
+		pending    *time.Timer // the trailing edge of the writings
 	)
 
 	if !atomic.CompareAndSwapInt32(&s.watching, 0, 1) {
 		return
 	}
+	debounce := s.debounce
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+	defer func() {
+		if pending != nil {
+			pending.Stop()
+		}
+	}()
 
 	var ok bool
 	var err error
@@ -176,11 +208,6 @@ loop:
 				break loop
 			}
 
-			// Use a simple timer to buffer events as certain events fire
-			// multiple times on some platforms.
-			if event.String() == lastChange.lastEvent && time.Since(lastChange.lastEventTime) < time.Millisecond*5 {
-				continue
-			}
 			lastChange.lastEvent = event.String()
 			lastChange.lastEventTime = time.Now()
 
@@ -192,10 +219,21 @@ loop:
 				continue
 			}
 
-			// The file was removed.
-			if event.Op&fsnotify.Remove != 0 {
-				cb(nil, fmt.Errorf("file %s was removed", event.Name))
-				break loop
+			// The file was removed or renamed, keep watching for
+			// its recreation. The store decides what to do with the
+			// loaded keys, see store.WithRemovePolicy.
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				lastChange.lastOp = store.OpRemove
+				if event.Op&fsnotify.Rename != 0 {
+					lastChange.lastOp = store.OpRename
+				}
+				if pending != nil {
+					pending.Stop() // nothing to read
+					pending = nil
+				}
+				ch := lastChange
+				cb(&ch, nil)
+				continue
 			}
 
 			// Resolve symlink to get the real path, in case the symlink's
@@ -212,9 +250,19 @@ loop:
 			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
 				continue
 			}
+			lastChange.lastOp = store.OpWrite
+			if event.Op&fsnotify.Create != 0 {
+				lastChange.lastOp = store.OpCreate
+			}
 
-			// Trigger event.
-			cb(lastChange, nil)
+			// Trigger event once the writings settled. The events
+			// firing multiple times on some platforms are merged
+			// too.
+			ch := lastChange
+			if pending != nil {
+				pending.Stop()
+			}
+			pending = time.AfterFunc(debounce, func() { cb(&ch, nil) })
 
 		// There's an error.
 		case err, ok = <-w.Errors:
@@ -32,6 +32,8 @@ type pvdr struct {
 	underline2dot bool
 }
 
+func (s *pvdr) String() string { return "flags" }
+
 type Opt func(s *pvdr)
 
 func WithCodec(codec store.Codec) Opt {
@@ -38,6 +38,8 @@ func WithPosition(prefix string) Opt {
 	}
 }
 
+func (s *pvdr) String() string { return s.path }
+
 func (s *pvdr) GetCodec() (codec store.Codec) { return s.codec }
 func (s *pvdr) GetPosition() (pos string)     { return s.prefix }
 func (s *pvdr) WithCodec(codec store.Codec)   { s.codec = codec }
@@ -64,6 +64,8 @@ type pvdr struct {
 	watching  int32
 }
 
+func (s *pvdr) String() string { return "maps" }
+
 func (s *pvdr) GetCodec() (codec store.Codec) { return s.codec }
 func (s *pvdr) GetPosition() (pos string)     { return s.prefix }
 func (s *pvdr) WithCodec(codec store.Codec)   { s.codec = codec }
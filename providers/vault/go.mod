@@ -7,3 +7,17 @@ replace github.com/hedzr/store => ../..
 // replace github.com/hedzr/store/codecs/json => ../../codecs/json
 
 // replace github.com/hedzr/store/codecs/yaml => ../../codecs/yaml
+
+require (
+	github.com/hedzr/store v1.4.3
+	gopkg.in/hedzr/errors.v3 v3.3.5
+)
+
+require (
+	github.com/hedzr/evendeep v1.4.3 // indirect
+	github.com/hedzr/is v0.9.3 // indirect
+	github.com/hedzr/logg v0.9.3 // indirect
+	golang.org/x/net v0.56.0 // indirect
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/term v0.44.0 // indirect
+)
@@ -1,3 +1,132 @@
+// Package vault resolves the secrets from the KV v2 secrets
+// engine of HashiCorp Vault, for store.WithSecretResolver.
+//
+//	conf := store.New(store.WithSecretResolver("vault", vault.NewResolver(
+//	    vault.WithAddress("https://vault:8200"),
+//	    vault.WithToken(token),
+//	)))
+//	conf.Set("db.password", "secret://vault/kv/db#password")
+//	pwd := conf.MustString("db.password") // reads field "password" of kv/db
 package vault
 
-// todo vault provider
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store"
+)
+
+// NewResolver returns a store.SecretResolver for the references
+// like "<mount>/<path>#<field>".
+//
+// The address, token and namespace are taken from the
+// environment variables VAULT_ADDR, VAULT_TOKEN and
+// VAULT_NAMESPACE by default.
+func NewResolver(opts ...Opt) *resolverS {
+	s := &resolverS{
+		addr:      os.Getenv("VAULT_ADDR"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		client:    http.DefaultClient,
+	}
+	if s.addr == "" {
+		s.addr = "http://127.0.0.1:8200"
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type Opt func(s *resolverS)
+
+type resolverS struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+var _ store.SecretResolver = (*resolverS)(nil) // assertion helper
+
+func WithAddress(addr string) Opt {
+	return func(s *resolverS) {
+		s.addr = addr
+	}
+}
+
+func WithToken(token string) Opt {
+	return func(s *resolverS) {
+		s.token = token
+	}
+}
+
+func WithNamespace(namespace string) Opt {
+	return func(s *resolverS) {
+		s.namespace = namespace
+	}
+}
+
+func WithHTTPClient(client *http.Client) Opt {
+	return func(s *resolverS) {
+		s.client = client
+	}
+}
+
+// Resolve reads the secret "<mount>/<path>" and returns its
+// field. For "kv/db#password", it requests /v1/kv/data/db and
+// picks the field "password".
+func (s *resolverS) Resolve(ctx context.Context, ref string) (value string, err error) {
+	path, field := store.SplitSecretRef(ref)
+	mount, name, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || field == "" {
+		return "", errors.New("invalid vault secret reference %q, want <mount>/<path>#<field>", ref)
+	}
+
+	u := strings.TrimRight(s.addr, "/") + "/v1/" + url.PathEscape(mount) + "/data/" + name
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, u, nil); err != nil {
+		return
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", errors.New("vault secret %q not found", path).WithErrors(errors.NotFound)
+	case resp.StatusCode != http.StatusOK:
+		return "", errors.New("cannot read vault secret %q: %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", errors.New("cannot decode vault secret %q", path).WithErrors(err)
+	}
+	v, ok := body.Data.Data[field]
+	if !ok {
+		return "", errors.New("field %q not found in vault secret %q", field, path).WithErrors(errors.NotFound)
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hedzr/store"
+)
+
+func newKV2Server(t *testing.T, token string, secrets map[string]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		body, ok := secrets[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolver(t *testing.T) {
+	srv := newKV2Server(t, "root", map[string]string{
+		"/v1/kv/data/db": `{"data":{"data":{"password":"p@ss","port":5432},"metadata":{"version":3}}}`,
+	})
+	r := NewResolver(WithAddress(srv.URL), WithToken("root"))
+
+	for _, c := range []struct {
+		ref, want string
+		wantErr   bool
+	}{
+		{"kv/db#password", "p@ss", false},
+		{"kv/db#port", "5432", false},
+		{"kv/db#user", "", true},
+		{"kv/nothing#password", "", true},
+		{"kv/db", "", true},
+	} {
+		v, err := r.Resolve(context.TODO(), c.ref)
+		if (err != nil) != c.wantErr || v != c.want {
+			t.Fatalf("Resolve(%q) = %q, %v; want %q, error: %v", c.ref, v, err, c.want, c.wantErr)
+		}
+	}
+
+	if _, err := NewResolver(WithAddress(srv.URL), WithToken("bad")).Resolve(context.TODO(), "kv/db#password"); err == nil {
+		t.Fatal("expecting permission denied error")
+	}
+}
+
+func TestResolverWithStore(t *testing.T) {
+	srv := newKV2Server(t, "root", map[string]string{
+		"/v1/kv/data/db": `{"data":{"data":{"password":"p@ss"}}}`,
+	})
+	conf := store.New(store.WithSecretResolver("vault", NewResolver(WithAddress(srv.URL), WithToken("root"))))
+	defer conf.Close()
+	conf.Set("db.password", "secret://vault/kv/db#password")
+
+	if v := conf.MustString("db.password"); v != "p@ss" {
+		t.Fatalf("want p@ss, got %q", v)
+	}
+	if v := conf.MustGet("db.password"); v != "secret://vault/kv/db#password" {
+		t.Fatalf("the raw value should be kept, got %v", v)
+	}
+}
@@ -13,6 +13,10 @@ import (
 
 // TypedGetters makes a formal specification for Trie[any]
 type TypedGetters[T any] interface {
+	// QueryResolved is Query with the interpolation and the value
+	// resolvers applied, as the typed getters do.
+	QueryResolved(path string) (data T, branch, found bool, err error)
+
 	GetString(path string, defaultVal ...string) (ret string, err error)        // extract data field to its string representation
 	MustString(path string, defaultVal ...string) (ret string)                  // extract data field to its string representation
 	GetStringSlice(path string, defaultVal ...string) (ret []string, err error) // extract data field to its string slice representation
@@ -343,6 +347,7 @@ func (s *trieS[T]) GetR(path string, defaultVal ...map[string]any) (ret map[stri
 	var (
 		found, partialMatched, branch bool
 		nodeX                         *nodeS[T]
+		interp                        = s.interp.isActive()
 	)
 
 	if path == "" || path == "." || path == "(root)" {
@@ -354,9 +359,9 @@ func (s *trieS[T]) GetR(path string, defaultVal ...map[string]any) (ret map[stri
 		// ret[node.pathS] = node.data
 
 		ret = make(map[string]any)
-		s.root.Walk(func(path, fragment string, node Node[T]) {
-			if (path == "" || !s.simpleEndsWith(path, s.delimiter)) && !node.IsBranch() {
-				ret[path] = node.Data()
+		s.tree.load().Walk(func(path, fragment string, node Node[T]) {
+			if (path == "" || !s.simpleEndsWith(path, s.Delimiter())) && !node.IsBranch() {
+				ret[path] = s.leafData(node, interp, &err)
 			}
 		})
 		return
@@ -367,7 +372,7 @@ func (s *trieS[T]) GetR(path string, defaultVal ...map[string]any) (ret map[stri
 		_, _, ret = branch, partialMatched, make(map[string]any)
 
 		nodeX.Walk(func(path, fragment string, node Node[T]) {
-			if !s.simpleEndsWith(path, s.delimiter) && !node.IsBranch() {
+			if !s.simpleEndsWith(path, s.Delimiter()) && !node.IsBranch() {
 				// For a trie like:
 				//
 				//     app.                          <B>
@@ -389,7 +394,7 @@ func (s *trieS[T]) GetR(path string, defaultVal ...map[string]any) (ret map[stri
 				// like 'app.dump' or 'app.dump.to'.
 				//
 				// See also TestStore_GetR()
-				ret[path] = node.Data()
+				ret[path] = s.leafData(node, interp, &err)
 			}
 		})
 		logz.Debug("[GetR] ", "ret", ret)
@@ -412,29 +417,34 @@ func (s *trieS[T]) GetM(path string, opts ...MOpt[T]) (ret map[string]any, err e
 	var (
 		found, partialMatched, branch bool
 		nodeX                         *nodeS[T]
+		interp                        = s.interp.isActive()
 	)
 
+	path = NormalizePath(path, s.Delimiter()) //nolint:revive
 	if path == "" || path == "." || path == "(root)" {
 		ret = make(map[string]any)
 		putter := prefixPutter[T]{}
 		for _, opt := range opts {
 			opt(&putter)
 		}
-		prelen := -1
-		if l := len(s.Prefix()); l > 0 {
-			prelen = l + 1 // s.prefix + '.'
+		var base string // the keys out of the prefix are skipped
+		if p := s.Prefix(); p != "" {
+			base = p + string(s.Delimiter())
 		}
-		s.root.Walk(func(path, fragment string, node Node[T]) {
-			if (path == "" || !s.simpleEndsWith(path, s.delimiter)) && !node.IsBranch() {
+		s.tree.load().Walk(func(path, fragment string, node Node[T]) {
+			if (path == "" || !s.simpleEndsWith(path, s.Delimiter())) && !node.IsBranch() {
+				if !strings.HasPrefix(path, base) {
+					return
+				}
 				if putter.filterFn != nil {
 					if !putter.filterFn(node) {
 						return
 					}
 				}
 				if putter.keepPrefix {
-					ret[path] = node.Data()
-				} else if prelen <= len(path) {
-					ret[path[prelen+1:]] = node.Data()
+					ret[path] = s.leafData(node, !putter.raw && interp, &err)
+				} else {
+					ret[path[len(base):]] = s.leafData(node, !putter.raw && interp, &err)
 				}
 			}
 		})
@@ -447,7 +457,7 @@ func (s *trieS[T]) GetM(path string, opts ...MOpt[T]) (ret map[string]any, err e
 	nodeX, branch, partialMatched, found = s.Locate(path, nil)
 	if found || partialMatched {
 		_, _, ret = branch, partialMatched, make(map[string]any)
-		putter := prefixPutter[T]{prefix: strings.Split(s.Join(s.prefix, path), string(s.delimiter))}
+		putter := prefixPutter[T]{prefix: SplitPath(s.fullKey(path), s.Delimiter())}
 		for _, opt := range opts {
 			opt(&putter)
 		}
@@ -459,19 +469,20 @@ func (s *trieS[T]) GetM(path string, opts ...MOpt[T]) (ret map[string]any, err e
 		prelen--
 		logz.Verbose("[GetM] loop subtree and return as a map", "path", putter.prefix)
 		nodeX.Walk(func(path, fragment string, node Node[T]) {
-			if !s.simpleEndsWith(path, s.delimiter) && !node.IsBranch() {
+			if !s.simpleEndsWith(path, s.Delimiter()) && !node.IsBranch() {
 				logz.Verbose("  - put into map", "path", path, "fragment", fragment)
 				if putter.filterFn != nil {
 					if !putter.filterFn(node) {
 						return
 					}
 				}
+				data := s.leafData(node, !putter.raw && interp, &err)
 				if putter.keepPrefix {
-					putter.put(ret, path, string(s.delimiter), node.Data())
+					putter.put(ret, path, s.Delimiter(), data)
 				} else if prelen+1 == len(path) {
-					putter.put(ret, fragment, string(s.delimiter), node.Data())
+					putter.put(ret, fragment, s.Delimiter(), data)
 				} else if prelen < len(path) {
-					putter.put(ret, path[prelen+1:], string(s.delimiter), node.Data())
+					putter.put(ret, path[prelen+1:], s.Delimiter(), data)
 				}
 			}
 		})
@@ -491,11 +502,11 @@ func (s *trieS[T]) MustM(path string, opts ...MOpt[T]) (ret map[string]any) {
 func (s *trieS[T]) splitCompactKeys(in map[string]any) (out map[string]any) {
 	out = make(map[string]any)
 	for k, v := range in {
-		if strings.ContainsRune(k, s.delimiter) {
-			a := strings.Split(k, string(s.delimiter))
+		if strings.ContainsRune(k, s.Delimiter()) {
+			a := SplitPath(k, s.Delimiter())
 			s.submap(out, a, v)
 		} else {
-			out[k] = v
+			out[UnescapeKey(k, s.Delimiter())] = v
 		}
 	}
 	return
@@ -609,6 +620,14 @@ func WithFilter[T any](filter FilterFn[T]) MOpt[T] {
 	}
 }
 
+// WithRawValues returns the stored values without interpolation,
+// see SetInterpolation.
+func WithRawValues[T any](b bool) MOpt[T] {
+	return func(s *prefixPutter[T]) {
+		s.raw = b
+	}
+}
+
 // WithoutFlattenKeys allows returns a nested map.
 // If the keys contain delimiter char, they will be split as
 // nested sub-map.
@@ -626,11 +645,12 @@ type prefixPutter[T any] struct {
 	prefix     []string
 	keepPrefix bool // constructing the result map by keeping prefix structure?
 	noFlatten  bool // split key like 'app.logging.files' as nested sub-map
+	raw        bool // don't expand the references in values
 	filterFn   FilterFn[T]
 }
 
-func (s *prefixPutter[T]) put(m map[string]any, prefix, delimiter string, v any) {
-	keys := strings.Split(prefix, delimiter)
+func (s *prefixPutter[T]) put(m map[string]any, prefix string, delimiter rune, v any) {
+	keys := SplitPath(prefix, delimiter)
 	if s.keepPrefix {
 		s.putKeys(m, keys, v)
 		return
@@ -679,7 +699,7 @@ func (s *trieS[T]) GetString(path string, defaultVal ...string) (ret string, err
 		found bool
 		data  any
 	)
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.String(data)
 	} else if !found {
@@ -691,7 +711,7 @@ func (s *trieS[T]) GetString(path string, defaultVal ...string) (ret string, err
 }
 
 func (s *trieS[T]) MustString(path string, defaultVal ...string) (ret string) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.String(data)
 	} else if !found {
@@ -705,7 +725,7 @@ func (s *trieS[T]) MustString(path string, defaultVal ...string) (ret string) {
 func (s *trieS[T]) GetStringSlice(path string, defaultVal ...string) (ret []string, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.StringSlice(data)
 	} else if !found {
@@ -715,7 +735,7 @@ func (s *trieS[T]) GetStringSlice(path string, defaultVal ...string) (ret []stri
 }
 
 func (s *trieS[T]) MustStringSlice(path string, defaultVal ...string) (ret []string) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.StringSlice(data)
 	} else if !found {
@@ -727,7 +747,7 @@ func (s *trieS[T]) MustStringSlice(path string, defaultVal ...string) (ret []str
 func (s *trieS[T]) GetStringMap(path string, defaultVal ...map[string]string) (ret map[string]string, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.StringMap(data)
 	} else if !found {
@@ -739,7 +759,7 @@ func (s *trieS[T]) GetStringMap(path string, defaultVal ...map[string]string) (r
 }
 
 func (s *trieS[T]) MustStringMap(path string, defaultVal ...map[string]string) (ret map[string]string) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.StringMap(data)
 	} else if !found {
@@ -755,7 +775,7 @@ func (s *trieS[T]) MustStringMap(path string, defaultVal ...map[string]string) (
 func (s *trieS[T]) GetInt64(path string, defaultVal ...int64) (ret int64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int(data)
 	} else if !found {
@@ -767,7 +787,7 @@ func (s *trieS[T]) GetInt64(path string, defaultVal ...int64) (ret int64, err er
 }
 
 func (s *trieS[T]) MustInt64(path string, defaultVal ...int64) (ret int64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int(data)
 	} else if !found {
@@ -781,7 +801,7 @@ func (s *trieS[T]) MustInt64(path string, defaultVal ...int64) (ret int64) {
 func (s *trieS[T]) GetInt(path string, defaultVal ...int) (ret int, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = int(converter.Int(data))
 	} else if !found {
@@ -793,7 +813,7 @@ func (s *trieS[T]) GetInt(path string, defaultVal ...int) (ret int, err error) {
 }
 
 func (s *trieS[T]) MustInt(path string, defaultVal ...int) (ret int) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = int(converter.Int(data))
 	} else if !found {
@@ -807,7 +827,7 @@ func (s *trieS[T]) MustInt(path string, defaultVal ...int) (ret int) {
 func (s *trieS[T]) GetInt32(path string, defaultVal ...int32) (ret int32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = int32(converter.Int(data))
 	} else if !found {
@@ -819,7 +839,7 @@ func (s *trieS[T]) GetInt32(path string, defaultVal ...int32) (ret int32, err er
 }
 
 func (s *trieS[T]) MustInt32(path string, defaultVal ...int32) (ret int32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = int32(converter.Int(data))
 	} else if !found {
@@ -833,7 +853,7 @@ func (s *trieS[T]) MustInt32(path string, defaultVal ...int32) (ret int32) {
 func (s *trieS[T]) GetInt16(path string, defaultVal ...int16) (ret int16, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = int16(converter.Int(data))
 	} else if !found {
@@ -845,7 +865,7 @@ func (s *trieS[T]) GetInt16(path string, defaultVal ...int16) (ret int16, err er
 }
 
 func (s *trieS[T]) MustInt16(path string, defaultVal ...int16) (ret int16) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = int16(converter.Int(data))
 	} else if !found {
@@ -859,7 +879,7 @@ func (s *trieS[T]) MustInt16(path string, defaultVal ...int16) (ret int16) {
 func (s *trieS[T]) GetInt8(path string, defaultVal ...int8) (ret int8, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = int8(converter.Int(data))
 	} else if !found {
@@ -871,7 +891,7 @@ func (s *trieS[T]) GetInt8(path string, defaultVal ...int8) (ret int8, err error
 }
 
 func (s *trieS[T]) MustInt8(path string, defaultVal ...int8) (ret int8) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = int8(converter.Int(data))
 	} else if !found {
@@ -885,7 +905,7 @@ func (s *trieS[T]) MustInt8(path string, defaultVal ...int8) (ret int8) {
 func (s *trieS[T]) GetUint64(path string, defaultVal ...uint64) (ret uint64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint(data)
 	} else if !found {
@@ -897,7 +917,7 @@ func (s *trieS[T]) GetUint64(path string, defaultVal ...uint64) (ret uint64, err
 }
 
 func (s *trieS[T]) MustUint64(path string, defaultVal ...uint64) (ret uint64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint(data)
 	} else if !found {
@@ -911,7 +931,7 @@ func (s *trieS[T]) MustUint64(path string, defaultVal ...uint64) (ret uint64) {
 func (s *trieS[T]) GetUint(path string, defaultVal ...uint) (ret uint, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = uint(converter.Uint(data))
 	} else if !found {
@@ -923,7 +943,7 @@ func (s *trieS[T]) GetUint(path string, defaultVal ...uint) (ret uint, err error
 }
 
 func (s *trieS[T]) MustUint(path string, defaultVal ...uint) (ret uint) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = uint(converter.Uint(data))
 	} else if !found {
@@ -937,7 +957,7 @@ func (s *trieS[T]) MustUint(path string, defaultVal ...uint) (ret uint) {
 func (s *trieS[T]) GetUint32(path string, defaultVal ...uint32) (ret uint32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = uint32(converter.Uint(data))
 	} else if !found {
@@ -949,7 +969,7 @@ func (s *trieS[T]) GetUint32(path string, defaultVal ...uint32) (ret uint32, err
 }
 
 func (s *trieS[T]) MustUint32(path string, defaultVal ...uint32) (ret uint32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = uint32(converter.Uint(data))
 	} else if !found {
@@ -963,7 +983,7 @@ func (s *trieS[T]) MustUint32(path string, defaultVal ...uint32) (ret uint32) {
 func (s *trieS[T]) GetUint16(path string, defaultVal ...uint16) (ret uint16, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = uint16(converter.Uint(data))
 	} else if !found {
@@ -975,7 +995,7 @@ func (s *trieS[T]) GetUint16(path string, defaultVal ...uint16) (ret uint16, err
 }
 
 func (s *trieS[T]) MustUint16(path string, defaultVal ...uint16) (ret uint16) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = uint16(converter.Uint(data))
 	} else if !found {
@@ -989,7 +1009,7 @@ func (s *trieS[T]) MustUint16(path string, defaultVal ...uint16) (ret uint16) {
 func (s *trieS[T]) GetUint8(path string, defaultVal ...uint8) (ret uint8, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = uint8(converter.Uint(data))
 	} else if !found {
@@ -1001,7 +1021,7 @@ func (s *trieS[T]) GetUint8(path string, defaultVal ...uint8) (ret uint8, err er
 }
 
 func (s *trieS[T]) MustUint8(path string, defaultVal ...uint8) (ret uint8) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = uint8(converter.Uint(data))
 	} else if !found {
@@ -1017,7 +1037,7 @@ func (s *trieS[T]) MustUint8(path string, defaultVal ...uint8) (ret uint8) {
 func (s *trieS[T]) GetInt64Slice(path string, defaultVal ...int64) (ret []int64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int64Slice(data)
 	} else if !found {
@@ -1027,7 +1047,7 @@ func (s *trieS[T]) GetInt64Slice(path string, defaultVal ...int64) (ret []int64,
 }
 
 func (s *trieS[T]) MustInt64Slice(path string, defaultVal ...int64) (ret []int64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int64Slice(data)
 	} else if !found {
@@ -1039,7 +1059,7 @@ func (s *trieS[T]) MustInt64Slice(path string, defaultVal ...int64) (ret []int64
 func (s *trieS[T]) GetInt32Slice(path string, defaultVal ...int32) (ret []int32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int32Slice(data)
 	} else if !found {
@@ -1049,7 +1069,7 @@ func (s *trieS[T]) GetInt32Slice(path string, defaultVal ...int32) (ret []int32,
 }
 
 func (s *trieS[T]) MustInt32Slice(path string, defaultVal ...int32) (ret []int32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int32Slice(data)
 	} else if !found {
@@ -1061,7 +1081,7 @@ func (s *trieS[T]) MustInt32Slice(path string, defaultVal ...int32) (ret []int32
 func (s *trieS[T]) GetInt16Slice(path string, defaultVal ...int16) (ret []int16, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int16Slice(data)
 	} else if !found {
@@ -1071,7 +1091,7 @@ func (s *trieS[T]) GetInt16Slice(path string, defaultVal ...int16) (ret []int16,
 }
 
 func (s *trieS[T]) MustInt16Slice(path string, defaultVal ...int16) (ret []int16) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int16Slice(data)
 	} else if !found {
@@ -1083,7 +1103,7 @@ func (s *trieS[T]) MustInt16Slice(path string, defaultVal ...int16) (ret []int16
 func (s *trieS[T]) GetInt8Slice(path string, defaultVal ...int8) (ret []int8, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int8Slice(data)
 	} else if !found {
@@ -1093,7 +1113,7 @@ func (s *trieS[T]) GetInt8Slice(path string, defaultVal ...int8) (ret []int8, er
 }
 
 func (s *trieS[T]) MustInt8Slice(path string, defaultVal ...int8) (ret []int8) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int8Slice(data)
 	} else if !found {
@@ -1105,7 +1125,7 @@ func (s *trieS[T]) MustInt8Slice(path string, defaultVal ...int8) (ret []int8) {
 func (s *trieS[T]) GetIntSlice(path string, defaultVal ...int) (ret []int, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.IntSlice(data)
 	} else if !found {
@@ -1115,7 +1135,7 @@ func (s *trieS[T]) GetIntSlice(path string, defaultVal ...int) (ret []int, err e
 }
 
 func (s *trieS[T]) MustIntSlice(path string, defaultVal ...int) (ret []int) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.IntSlice(data)
 	} else if !found {
@@ -1129,7 +1149,7 @@ func (s *trieS[T]) MustIntSlice(path string, defaultVal ...int) (ret []int) {
 func (s *trieS[T]) GetUint64Slice(path string, defaultVal ...uint64) (ret []uint64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint64Slice(data)
 	} else if !found {
@@ -1139,7 +1159,7 @@ func (s *trieS[T]) GetUint64Slice(path string, defaultVal ...uint64) (ret []uint
 }
 
 func (s *trieS[T]) MustUint64Slice(path string, defaultVal ...uint64) (ret []uint64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint64Slice(data)
 	} else if !found {
@@ -1151,7 +1171,7 @@ func (s *trieS[T]) MustUint64Slice(path string, defaultVal ...uint64) (ret []uin
 func (s *trieS[T]) GetUint32Slice(path string, defaultVal ...uint32) (ret []uint32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint32Slice(data)
 	} else if !found {
@@ -1161,7 +1181,7 @@ func (s *trieS[T]) GetUint32Slice(path string, defaultVal ...uint32) (ret []uint
 }
 
 func (s *trieS[T]) MustUint32Slice(path string, defaultVal ...uint32) (ret []uint32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint32Slice(data)
 	} else if !found {
@@ -1173,7 +1193,7 @@ func (s *trieS[T]) MustUint32Slice(path string, defaultVal ...uint32) (ret []uin
 func (s *trieS[T]) GetUint16Slice(path string, defaultVal ...uint16) (ret []uint16, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint16Slice(data)
 	} else if !found {
@@ -1183,7 +1203,7 @@ func (s *trieS[T]) GetUint16Slice(path string, defaultVal ...uint16) (ret []uint
 }
 
 func (s *trieS[T]) MustUint16Slice(path string, defaultVal ...uint16) (ret []uint16) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint16Slice(data)
 	} else if !found {
@@ -1195,7 +1215,7 @@ func (s *trieS[T]) MustUint16Slice(path string, defaultVal ...uint16) (ret []uin
 func (s *trieS[T]) GetUint8Slice(path string, defaultVal ...uint8) (ret []uint8, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint8Slice(data)
 	} else if !found {
@@ -1205,7 +1225,7 @@ func (s *trieS[T]) GetUint8Slice(path string, defaultVal ...uint8) (ret []uint8,
 }
 
 func (s *trieS[T]) MustUint8Slice(path string, defaultVal ...uint8) (ret []uint8) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint8Slice(data)
 	} else if !found {
@@ -1217,7 +1237,7 @@ func (s *trieS[T]) MustUint8Slice(path string, defaultVal ...uint8) (ret []uint8
 func (s *trieS[T]) GetUintSlice(path string, defaultVal ...uint) (ret []uint, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.UintSlice(data)
 	} else if !found {
@@ -1227,7 +1247,7 @@ func (s *trieS[T]) GetUintSlice(path string, defaultVal ...uint) (ret []uint, er
 }
 
 func (s *trieS[T]) MustUintSlice(path string, defaultVal ...uint) (ret []uint) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.UintSlice(data)
 	} else if !found {
@@ -1241,7 +1261,7 @@ func (s *trieS[T]) MustUintSlice(path string, defaultVal ...uint) (ret []uint) {
 func (s *trieS[T]) GetInt64Map(path string, defaultVal ...map[string]int64) (ret map[string]int64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int64Map(data)
 	} else if !found {
@@ -1253,7 +1273,7 @@ func (s *trieS[T]) GetInt64Map(path string, defaultVal ...map[string]int64) (ret
 }
 
 func (s *trieS[T]) MustInt64Map(path string, defaultVal ...map[string]int64) (ret map[string]int64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int64Map(data)
 	} else if !found {
@@ -1267,7 +1287,7 @@ func (s *trieS[T]) MustInt64Map(path string, defaultVal ...map[string]int64) (re
 func (s *trieS[T]) GetInt32Map(path string, defaultVal ...map[string]int32) (ret map[string]int32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int32Map(data)
 	} else if !found {
@@ -1279,7 +1299,7 @@ func (s *trieS[T]) GetInt32Map(path string, defaultVal ...map[string]int32) (ret
 }
 
 func (s *trieS[T]) MustInt32Map(path string, defaultVal ...map[string]int32) (ret map[string]int32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int32Map(data)
 	} else if !found {
@@ -1293,7 +1313,7 @@ func (s *trieS[T]) MustInt32Map(path string, defaultVal ...map[string]int32) (re
 func (s *trieS[T]) GetInt16Map(path string, defaultVal ...map[string]int16) (ret map[string]int16, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int16Map(data)
 	} else if !found {
@@ -1305,7 +1325,7 @@ func (s *trieS[T]) GetInt16Map(path string, defaultVal ...map[string]int16) (ret
 }
 
 func (s *trieS[T]) MustInt16Map(path string, defaultVal ...map[string]int16) (ret map[string]int16) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int16Map(data)
 	} else if !found {
@@ -1319,7 +1339,7 @@ func (s *trieS[T]) MustInt16Map(path string, defaultVal ...map[string]int16) (re
 func (s *trieS[T]) GetInt8Map(path string, defaultVal ...map[string]int8) (ret map[string]int8, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Int8Map(data)
 	} else if !found {
@@ -1331,7 +1351,7 @@ func (s *trieS[T]) GetInt8Map(path string, defaultVal ...map[string]int8) (ret m
 }
 
 func (s *trieS[T]) MustInt8Map(path string, defaultVal ...map[string]int8) (ret map[string]int8) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Int8Map(data)
 	} else if !found {
@@ -1345,7 +1365,7 @@ func (s *trieS[T]) MustInt8Map(path string, defaultVal ...map[string]int8) (ret
 func (s *trieS[T]) GetIntMap(path string, defaultVal ...map[string]int) (ret map[string]int, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.IntMap(data)
 	} else if !found {
@@ -1357,7 +1377,7 @@ func (s *trieS[T]) GetIntMap(path string, defaultVal ...map[string]int) (ret map
 }
 
 func (s *trieS[T]) MustIntMap(path string, defaultVal ...map[string]int) (ret map[string]int) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.IntMap(data)
 	} else if !found {
@@ -1373,7 +1393,7 @@ func (s *trieS[T]) MustIntMap(path string, defaultVal ...map[string]int) (ret ma
 func (s *trieS[T]) GetUint64Map(path string, defaultVal ...map[string]uint64) (ret map[string]uint64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint64Map(data)
 	} else if !found {
@@ -1385,7 +1405,7 @@ func (s *trieS[T]) GetUint64Map(path string, defaultVal ...map[string]uint64) (r
 }
 
 func (s *trieS[T]) MustUint64Map(path string, defaultVal ...map[string]uint64) (ret map[string]uint64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint64Map(data)
 	} else if !found {
@@ -1399,7 +1419,7 @@ func (s *trieS[T]) MustUint64Map(path string, defaultVal ...map[string]uint64) (
 func (s *trieS[T]) GetUint32Map(path string, defaultVal ...map[string]uint32) (ret map[string]uint32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint32Map(data)
 	} else if !found {
@@ -1411,7 +1431,7 @@ func (s *trieS[T]) GetUint32Map(path string, defaultVal ...map[string]uint32) (r
 }
 
 func (s *trieS[T]) MustUint32Map(path string, defaultVal ...map[string]uint32) (ret map[string]uint32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint32Map(data)
 	} else if !found {
@@ -1425,7 +1445,7 @@ func (s *trieS[T]) MustUint32Map(path string, defaultVal ...map[string]uint32) (
 func (s *trieS[T]) GetUint16Map(path string, defaultVal ...map[string]uint16) (ret map[string]uint16, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint16Map(data)
 	} else if !found {
@@ -1437,7 +1457,7 @@ func (s *trieS[T]) GetUint16Map(path string, defaultVal ...map[string]uint16) (r
 }
 
 func (s *trieS[T]) MustUint16Map(path string, defaultVal ...map[string]uint16) (ret map[string]uint16) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint16Map(data)
 	} else if !found {
@@ -1451,7 +1471,7 @@ func (s *trieS[T]) MustUint16Map(path string, defaultVal ...map[string]uint16) (
 func (s *trieS[T]) GetUint8Map(path string, defaultVal ...map[string]uint8) (ret map[string]uint8, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Uint8Map(data)
 	} else if !found {
@@ -1463,7 +1483,7 @@ func (s *trieS[T]) GetUint8Map(path string, defaultVal ...map[string]uint8) (ret
 }
 
 func (s *trieS[T]) MustUint8Map(path string, defaultVal ...map[string]uint8) (ret map[string]uint8) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Uint8Map(data)
 	} else if !found {
@@ -1477,7 +1497,7 @@ func (s *trieS[T]) MustUint8Map(path string, defaultVal ...map[string]uint8) (re
 func (s *trieS[T]) GetUintMap(path string, defaultVal ...map[string]uint) (ret map[string]uint, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.UintMap(data)
 	} else if !found {
@@ -1489,7 +1509,7 @@ func (s *trieS[T]) GetUintMap(path string, defaultVal ...map[string]uint) (ret m
 }
 
 func (s *trieS[T]) MustUintMap(path string, defaultVal ...map[string]uint) (ret map[string]uint) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.UintMap(data)
 	} else if !found {
@@ -1678,7 +1698,7 @@ func (s *trieS[T]) fromKilobytes(r rune) (times uint64) {
 func (s *trieS[T]) GetFloat64(path string, defaultVal ...float64) (ret float64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Float64(data)
 	} else if !found {
@@ -1690,7 +1710,7 @@ func (s *trieS[T]) GetFloat64(path string, defaultVal ...float64) (ret float64,
 }
 
 func (s *trieS[T]) MustFloat64(path string, defaultVal ...float64) (ret float64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Float64(data)
 	} else if !found {
@@ -1704,7 +1724,7 @@ func (s *trieS[T]) MustFloat64(path string, defaultVal ...float64) (ret float64)
 func (s *trieS[T]) GetFloat32(path string, defaultVal ...float32) (ret float32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Float32(data)
 	} else if !found {
@@ -1716,7 +1736,7 @@ func (s *trieS[T]) GetFloat32(path string, defaultVal ...float32) (ret float32,
 }
 
 func (s *trieS[T]) MustFloat32(path string, defaultVal ...float32) (ret float32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Float32(data)
 	} else if !found {
@@ -1730,7 +1750,7 @@ func (s *trieS[T]) MustFloat32(path string, defaultVal ...float32) (ret float32)
 func (s *trieS[T]) GetFloat64Slice(path string, defaultVal ...float64) (ret []float64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Float64Slice(data)
 	} else if !found {
@@ -1740,7 +1760,7 @@ func (s *trieS[T]) GetFloat64Slice(path string, defaultVal ...float64) (ret []fl
 }
 
 func (s *trieS[T]) MustFloat64Slice(path string, defaultVal ...float64) (ret []float64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Float64Slice(data)
 	} else if !found {
@@ -1752,7 +1772,7 @@ func (s *trieS[T]) MustFloat64Slice(path string, defaultVal ...float64) (ret []f
 func (s *trieS[T]) GetFloat32Slice(path string, defaultVal ...float32) (ret []float32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Float32Slice(data)
 	} else if !found {
@@ -1762,7 +1782,7 @@ func (s *trieS[T]) GetFloat32Slice(path string, defaultVal ...float32) (ret []fl
 }
 
 func (s *trieS[T]) MustFloat32Slice(path string, defaultVal ...float32) (ret []float32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Float32Slice(data)
 	} else if !found {
@@ -1774,7 +1794,7 @@ func (s *trieS[T]) MustFloat32Slice(path string, defaultVal ...float32) (ret []f
 func (s *trieS[T]) GetFloat64Map(path string, defaultVal ...map[string]float64) (ret map[string]float64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Float64Map(data)
 	} else if !found {
@@ -1786,7 +1806,7 @@ func (s *trieS[T]) GetFloat64Map(path string, defaultVal ...map[string]float64)
 }
 
 func (s *trieS[T]) MustFloat64Map(path string, defaultVal ...map[string]float64) (ret map[string]float64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Float64Map(data)
 	} else if !found {
@@ -1800,7 +1820,7 @@ func (s *trieS[T]) MustFloat64Map(path string, defaultVal ...map[string]float64)
 func (s *trieS[T]) GetFloat32Map(path string, defaultVal ...map[string]float32) (ret map[string]float32, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Float32Map(data)
 	} else if !found {
@@ -1812,7 +1832,7 @@ func (s *trieS[T]) GetFloat32Map(path string, defaultVal ...map[string]float32)
 }
 
 func (s *trieS[T]) MustFloat32Map(path string, defaultVal ...map[string]float32) (ret map[string]float32) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Float32Map(data)
 	} else if !found {
@@ -1828,7 +1848,7 @@ func (s *trieS[T]) MustFloat32Map(path string, defaultVal ...map[string]float32)
 func (s *trieS[T]) GetComplex128(path string, defaultVal ...complex128) (ret complex128, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Complex128(data)
 	} else if !found {
@@ -1840,7 +1860,7 @@ func (s *trieS[T]) GetComplex128(path string, defaultVal ...complex128) (ret com
 }
 
 func (s *trieS[T]) MustComplex128(path string, defaultVal ...complex128) (ret complex128) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Complex128(data)
 	} else if !found {
@@ -1854,7 +1874,7 @@ func (s *trieS[T]) MustComplex128(path string, defaultVal ...complex128) (ret co
 func (s *trieS[T]) GetComplex64(path string, defaultVal ...complex64) (ret complex64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Complex64(data)
 	} else if !found {
@@ -1866,7 +1886,7 @@ func (s *trieS[T]) GetComplex64(path string, defaultVal ...complex64) (ret compl
 }
 
 func (s *trieS[T]) MustComplex64(path string, defaultVal ...complex64) (ret complex64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Complex64(data)
 	} else if !found {
@@ -1880,7 +1900,7 @@ func (s *trieS[T]) MustComplex64(path string, defaultVal ...complex64) (ret comp
 func (s *trieS[T]) GetComplex128Slice(path string, defaultVal ...complex128) (ret []complex128, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Complex128Slice(data)
 	} else if !found {
@@ -1890,7 +1910,7 @@ func (s *trieS[T]) GetComplex128Slice(path string, defaultVal ...complex128) (re
 }
 
 func (s *trieS[T]) MustComplex128Slice(path string, defaultVal ...complex128) (ret []complex128) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Complex128Slice(data)
 	} else if !found {
@@ -1902,7 +1922,7 @@ func (s *trieS[T]) MustComplex128Slice(path string, defaultVal ...complex128) (r
 func (s *trieS[T]) GetComplex64Slice(path string, defaultVal ...complex64) (ret []complex64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Complex64Slice(data)
 	} else if !found {
@@ -1912,7 +1932,7 @@ func (s *trieS[T]) GetComplex64Slice(path string, defaultVal ...complex64) (ret
 }
 
 func (s *trieS[T]) MustComplex64Slice(path string, defaultVal ...complex64) (ret []complex64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Complex64Slice(data)
 	} else if !found {
@@ -1924,7 +1944,7 @@ func (s *trieS[T]) MustComplex64Slice(path string, defaultVal ...complex64) (ret
 func (s *trieS[T]) GetComplex128Map(path string, defaultVal ...map[string]complex128) (ret map[string]complex128, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Complex128Map(data)
 	} else if !found {
@@ -1936,7 +1956,7 @@ func (s *trieS[T]) GetComplex128Map(path string, defaultVal ...map[string]comple
 }
 
 func (s *trieS[T]) MustComplex128Map(path string, defaultVal ...map[string]complex128) (ret map[string]complex128) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Complex128Map(data)
 	} else if !found {
@@ -1950,7 +1970,7 @@ func (s *trieS[T]) MustComplex128Map(path string, defaultVal ...map[string]compl
 func (s *trieS[T]) GetComplex64Map(path string, defaultVal ...map[string]complex64) (ret map[string]complex64, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Complex64Map(data)
 	} else if !found {
@@ -1962,7 +1982,7 @@ func (s *trieS[T]) GetComplex64Map(path string, defaultVal ...map[string]complex
 }
 
 func (s *trieS[T]) MustComplex64Map(path string, defaultVal ...map[string]complex64) (ret map[string]complex64) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Complex64Map(data)
 	} else if !found {
@@ -1978,7 +1998,7 @@ func (s *trieS[T]) MustComplex64Map(path string, defaultVal ...map[string]comple
 func (s *trieS[T]) GetBool(path string, defaultVal ...bool) (ret bool, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Bool(data)
 	} else if !found {
@@ -1990,7 +2010,7 @@ func (s *trieS[T]) GetBool(path string, defaultVal ...bool) (ret bool, err error
 }
 
 func (s *trieS[T]) MustBool(path string, defaultVal ...bool) (ret bool) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Bool(data)
 	} else if !found {
@@ -2004,7 +2024,7 @@ func (s *trieS[T]) MustBool(path string, defaultVal ...bool) (ret bool) {
 func (s *trieS[T]) GetBoolSlice(path string, defaultVal ...bool) (ret []bool, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.BoolSlice(data)
 	} else if !found {
@@ -2014,7 +2034,7 @@ func (s *trieS[T]) GetBoolSlice(path string, defaultVal ...bool) (ret []bool, er
 }
 
 func (s *trieS[T]) MustBoolSlice(path string, defaultVal ...bool) (ret []bool) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.BoolSlice(data)
 	} else if !found {
@@ -2026,7 +2046,7 @@ func (s *trieS[T]) MustBoolSlice(path string, defaultVal ...bool) (ret []bool) {
 func (s *trieS[T]) GetBoolMap(path string, defaultVal ...map[string]bool) (ret map[string]bool, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.BoolMap(data)
 	} else if !found {
@@ -2038,7 +2058,7 @@ func (s *trieS[T]) GetBoolMap(path string, defaultVal ...map[string]bool) (ret m
 }
 
 func (s *trieS[T]) MustBoolMap(path string, defaultVal ...map[string]bool) (ret map[string]bool) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.BoolMap(data)
 	} else if !found {
@@ -2054,7 +2074,7 @@ func (s *trieS[T]) MustBoolMap(path string, defaultVal ...map[string]bool) (ret
 func (s *trieS[T]) GetDuration(path string, defaultVal ...time.Duration) (ret time.Duration, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Duration(data)
 	} else if !found {
@@ -2066,7 +2086,7 @@ func (s *trieS[T]) GetDuration(path string, defaultVal ...time.Duration) (ret ti
 }
 
 func (s *trieS[T]) MustDuration(path string, defaultVal ...time.Duration) (ret time.Duration) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Duration(data)
 	} else if !found {
@@ -2080,7 +2100,7 @@ func (s *trieS[T]) MustDuration(path string, defaultVal ...time.Duration) (ret t
 func (s *trieS[T]) GetDurationSlice(path string, defaultVal ...time.Duration) (ret []time.Duration, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.DurationSlice(data)
 	} else if !found {
@@ -2090,7 +2110,7 @@ func (s *trieS[T]) GetDurationSlice(path string, defaultVal ...time.Duration) (r
 }
 
 func (s *trieS[T]) MustDurationSlice(path string, defaultVal ...time.Duration) (ret []time.Duration) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.DurationSlice(data)
 	} else if !found {
@@ -2102,7 +2122,7 @@ func (s *trieS[T]) MustDurationSlice(path string, defaultVal ...time.Duration) (
 func (s *trieS[T]) GetDurationMap(path string, defaultVal ...map[string]time.Duration) (ret map[string]time.Duration, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.DurationMap(data)
 	} else if !found {
@@ -2114,7 +2134,7 @@ func (s *trieS[T]) GetDurationMap(path string, defaultVal ...map[string]time.Dur
 }
 
 func (s *trieS[T]) MustDurationMap(path string, defaultVal ...map[string]time.Duration) (ret map[string]time.Duration) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.DurationMap(data)
 	} else if !found {
@@ -2130,7 +2150,7 @@ func (s *trieS[T]) MustDurationMap(path string, defaultVal ...map[string]time.Du
 func (s *trieS[T]) GetTime(path string, defaultVal ...time.Time) (ret time.Time, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.Time(data)
 	} else if !found {
@@ -2142,7 +2162,7 @@ func (s *trieS[T]) GetTime(path string, defaultVal ...time.Time) (ret time.Time,
 }
 
 func (s *trieS[T]) MustTime(path string, defaultVal ...time.Time) (ret time.Time) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.Time(data)
 	} else if !found {
@@ -2156,7 +2176,7 @@ func (s *trieS[T]) MustTime(path string, defaultVal ...time.Time) (ret time.Time
 func (s *trieS[T]) GetTimeSlice(path string, defaultVal ...time.Time) (ret []time.Time, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.TimeSlice(data)
 	} else if !found {
@@ -2166,7 +2186,7 @@ func (s *trieS[T]) GetTimeSlice(path string, defaultVal ...time.Time) (ret []tim
 }
 
 func (s *trieS[T]) MustTimeSlice(path string, defaultVal ...time.Time) (ret []time.Time) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.TimeSlice(data)
 	} else if !found {
@@ -2178,7 +2198,7 @@ func (s *trieS[T]) MustTimeSlice(path string, defaultVal ...time.Time) (ret []ti
 func (s *trieS[T]) GetTimeMap(path string, defaultVal ...map[string]time.Time) (ret map[string]time.Time, err error) {
 	var found bool
 	var data any
-	data, _, found, err = s.Query(path, nil)
+	data, _, found, err = s.query(path)
 	if found && err == nil {
 		ret = converter.TimeMap(data)
 	} else if !found {
@@ -2190,7 +2210,7 @@ func (s *trieS[T]) GetTimeMap(path string, defaultVal ...map[string]time.Time) (
 }
 
 func (s *trieS[T]) MustTimeMap(path string, defaultVal ...map[string]time.Time) (ret map[string]time.Time) {
-	data, _, found, err := s.Query(path, nil)
+	data, _, found, err := s.query(path)
 	if found && err == nil {
 		ret = converter.TimeMap(data)
 	} else if !found {
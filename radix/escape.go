@@ -0,0 +1,164 @@
+package radix
+
+import (
+	"strings"
+)
+
+// A key may hold the delimiter if it's escaped by a backslash,
+// or the segment is quoted, in a path. For example, the two
+// paths are same:
+//
+//	upstreams.api\.example\.com.weight
+//	upstreams."api.example.com".weight
+//
+// The tree stores the escaped form, which is returned by Key,
+// Walk and the iterators. The segments are unescaped when they
+// become the keys of a map, such as GetM does.
+
+const escapeChar = '\\'
+
+// NormalizePath converts the quoted segments of path to the
+// escaped form. A quoted segment starts with a double quote and
+// ends with the one before a delimiter or the end of path, the
+// escaped quote (\") inside it is a literal one.
+//
+//	NormalizePath(`a."b.c".d`, '.') // returns `a.b\.c.d`
+//
+// A path without quotes is returned as is.
+func NormalizePath(path string, delimiter rune) string {
+	if strings.IndexByte(path, '"') < 0 {
+		return path
+	}
+	delim := string(delimiter)
+	var sb strings.Builder
+	sb.Grow(len(path) + 4)
+	for i := 0; i <= len(path); {
+		if i > 0 {
+			_, _ = sb.WriteString(delim)
+		}
+		var end int
+		if raw, n, ok := quotedSegment(path[i:], delim); ok {
+			_, _ = sb.WriteString(EscapeKey(raw, delimiter))
+			end = i + n
+		} else {
+			end = i + indexDelimiter(path[i:], delim)
+			if end < i {
+				end = len(path)
+			}
+			_, _ = sb.WriteString(path[i:end])
+		}
+		i = end + len(delim)
+	}
+	return sb.String()
+}
+
+// quotedSegment unquotes the quoted segment at the beginning of
+// path, n is the length of the quoted form.
+func quotedSegment(path, delim string) (raw string, n int, ok bool) {
+	if path == "" || path[0] != '"' {
+		return
+	}
+	var sb strings.Builder
+	for j := 1; j < len(path); j++ {
+		switch c := path[j]; {
+		case c == escapeChar && j+1 < len(path) && path[j+1] == '"':
+			_ = sb.WriteByte('"')
+			j++
+		case c == '"' && (j+1 == len(path) || strings.HasPrefix(path[j+1:], delim)):
+			return sb.String(), j + 1, true
+		default:
+			_ = sb.WriteByte(c)
+		}
+	}
+	return "", 0, false // not closed, it's a plain segment
+}
+
+// indexDelimiter returns the index of the first delimiter, which
+// isn't escaped, in path, or -1.
+func indexDelimiter(path, delim string) int {
+	for i := 0; i < len(path); {
+		pos := strings.Index(path[i:], delim)
+		if pos < 0 {
+			return -1
+		}
+		if pos += i; pos == 0 || path[pos-1] != escapeChar {
+			return pos
+		}
+		i = pos + len(delim)
+	}
+	return -1
+}
+
+// EscapeKey escapes the delimiters, and the leading double quote,
+// in a key so that it can be a segment of a path.
+//
+//	trie.Set(trie.Join("upstreams", EscapeKey("api.example.com", '.'), "weight"), 10)
+func EscapeKey(key string, delimiter rune) string {
+	delim := string(delimiter)
+	quoted := key != "" && key[0] == '"'
+	if !quoted && !strings.Contains(key, delim) {
+		return key
+	}
+	if quoted {
+		key = key[1:]
+	}
+	key = strings.ReplaceAll(key, delim, string(escapeChar)+delim)
+	if quoted {
+		key = string(escapeChar) + `"` + key
+	}
+	return key
+}
+
+// UnescapeKey reverts EscapeKey.
+func UnescapeKey(key string, delimiter rune) string {
+	if strings.IndexByte(key, escapeChar) < 0 {
+		return key
+	}
+	delim := string(delimiter)
+	if strings.HasPrefix(key, string(escapeChar)+`"`) {
+		key = key[1:]
+	}
+	return strings.ReplaceAll(key, string(escapeChar)+delim, delim)
+}
+
+// SplitPath splits path into the unescaped keys by delimiter,
+// the escaped delimiters and the quoted segments are kept in
+// the keys.
+//
+//	SplitPath(`upstreams."api.example.com".weight`, '.')
+//	// returns []string{"upstreams", "api.example.com", "weight"}
+func SplitPath(path string, delimiter rune) (keys []string) {
+	keys = splitEscaped(NormalizePath(path, delimiter), delimiter)
+	for i, k := range keys {
+		keys[i] = UnescapeKey(k, delimiter)
+	}
+	return
+}
+
+// splitEscaped splits a normalized path by delimiter, the keys
+// are still escaped.
+func splitEscaped(path string, delimiter rune) (keys []string) {
+	delim := string(delimiter)
+	for {
+		pos := indexDelimiter(path, delim)
+		if pos < 0 {
+			return append(keys, path)
+		}
+		keys, path = append(keys, path[:pos]), path[pos+len(delim):]
+	}
+}
+
+// RekeyPath converts path delimited by 'from' to the one delimited
+// by 'to', the keys in path are escaped for 'to'.
+//
+//	RekeyPath(`hosts.10\.0\.0\.1.path`, '.', '/') // returns `hosts/10.0.0.1/path`
+func RekeyPath(path string, from, to rune) string {
+	if path == "" {
+		return path
+	}
+	keys := splitEscaped(NormalizePath(path, from), from)
+	for i, k := range keys {
+		keys[i] = EscapeKey(UnescapeKey(k, from), to)
+	}
+	return strings.Join(keys, string(to))
+}
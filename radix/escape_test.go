@@ -0,0 +1,125 @@
+package radix
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	for _, c := range []struct{ path, want string }{
+		{`a.b.c`, `a.b.c`},
+		{`a."b.c".d`, `a.b\.c.d`},
+		{`"a.b"`, `a\.b`},
+		{`a."b.c"`, `a.b\.c`},
+		{`a."say \"hi\"".b`, `a.say "hi".b`},
+		{`a."\"q.x".b`, `a.\"q\.x.b`},
+		{`a."b.c`, `a."b.c`}, // not closed
+		{`a.b\.c."d"`, `a.b\.c.d`},
+	} {
+		assertEqual(t, c.want, NormalizePath(c.path, '.'), c.path)
+	}
+
+	assertEqual(t, []string{"upstreams", "api.example.com", "weight"}, SplitPath(`upstreams."api.example.com".weight`, '.'))
+	assertEqual(t, []string{"upstreams", "api.example.com", "weight"}, SplitPath(`upstreams.api\.example\.com.weight`, '.'))
+	assertEqual(t, []string{"a", "b"}, SplitPath("a/b", '/'))
+	for _, key := range []string{"a.b", `"q"`, "plain", "x\\.y"} {
+		assertEqual(t, key, UnescapeKey(EscapeKey(key, '.'), '.'), key)
+	}
+}
+
+func TestTrieS_EscapedKeys(t *testing.T) {
+	trie := newTrie[any]()
+	trie.Set(`upstreams."api.example.com".weight`, 10)
+	trie.Set(`upstreams.api\.example\.org.weight`, 20)
+	trie.Set(`upstreams.api.weight`, 1)
+	trie.Set(`hosts.10\.0\.0\.1`, "gw")
+	trie.Set(`hosts.10\.0\.0\.12`, "db")
+
+	assertEqual(t, 10, trie.MustGet(`upstreams.api\.example\.com.weight`))
+	assertEqual(t, 20, trie.MustGet(`upstreams."api.example.org".weight`))
+	assertEqual(t, 1, trie.MustGet(`upstreams.api.weight`))
+	assertFalse(t, trie.Has(`upstreams.api.example.com.weight`))
+	assertEqual(t, "gw", trie.MustGet(`hosts."10.0.0.1"`))
+	assertEqual(t, "db", trie.MustGet(`hosts."10.0.0.12"`))
+
+	m := trie.MustM("upstreams")
+	assertEqual(t, map[string]any{
+		"api.example.com": map[string]any{"weight": 10},
+		"api.example.org": map[string]any{"weight": 20},
+		"api":             map[string]any{"weight": 1},
+	}, m)
+
+	var keys []string
+	trie.Walk("", func(path, fragment string, node Node[any]) {
+		if node.HasData() {
+			keys = append(keys, path)
+		}
+	})
+	slices.Sort(keys)
+	assertEqual(t, []string{
+		`hosts.10\.0\.0\.1`, `hosts.10\.0\.0\.12`,
+		`upstreams.api.weight`, `upstreams.api\.example\.com.weight`, `upstreams.api\.example\.org.weight`,
+	}, keys)
+	assertEqual(t, keys[:2], slices.Collect(trie.Keys("hosts")))
+	var children []string
+	for key := range trie.Children("upstreams") {
+		children = append(children, key)
+	}
+	assertEqual(t, []string{`upstreams.api`, `upstreams.api\.example\.com`, `upstreams.api\.example\.org`}, children)
+
+	assertTrue(t, trie.Remove(`upstreams."api.example.com".weight`))
+	assertFalse(t, trie.Has(`upstreams.api\.example\.com.weight`))
+	assertTrue(t, trie.Has(`upstreams.api\.example\.org.weight`))
+
+	err := trie.Merge("java", map[string]any{"org.slf4j.level": "debug"})
+	assertTrue(t, err == nil)
+	assertEqual(t, "debug", trie.MustGet(`java."org.slf4j.level"`))
+	assertFalse(t, trie.Has("java.org"))
+}
+
+func TestTrieS_ChangeDelimiter(t *testing.T) {
+	assertEqual(t, `hosts/10.0.0.1/path`, RekeyPath(`hosts.10\.0\.0\.1.path`, '.', '/'))
+	assertEqual(t, `a/b.c/d\/e`, RekeyPath(`a."b.c".d/e`, '.', '/'))
+
+	trie := newTrie[any]()
+	trie.Set("app.servers.10\\.0\\.0\\.1.path", "/usr/bin")
+	trie.Set("app.servers.local.path", "/opt/bin")
+	trie.Set("app.debug", true)
+	trie.Set("app.url", "http://x/y")
+	assertTrue(t, trie.SetComment("app.debug", "desc", "remarks"))
+	assertTrue(t, trie.SetTag("app.url", 7))
+
+	sub := trie.WithPrefix("app.servers")
+	assertTrue(t, sub.ChangeDelimiter('/') != nil, "a view cannot change the delimiter")
+	assertTrue(t, trie.ChangeDelimiter('/') == nil)
+	assertEqual(t, '/', sub.Delimiter())
+	sub.SetPrefix("app/servers")
+	assertEqual(t, "/opt/bin", sub.MustGet("local/path"))
+	assertEqual(t, '/', trie.Delimiter())
+	assertEqual(t, "/usr/bin", trie.MustGet("app/servers/10.0.0.1/path"))
+	assertEqual(t, "/opt/bin", trie.MustGet(`app/servers/"local"/path`))
+	assertEqual(t, true, trie.MustGet("app/debug"))
+	assertFalse(t, trie.Has("app.debug"))
+
+	var keys []string
+	trie.Walk("", func(path, fragment string, node Node[any]) {
+		if !node.HasData() {
+			return
+		}
+		keys = append(keys, path)
+		switch path {
+		case "app/debug":
+			assertEqual(t, "desc", node.Description())
+			assertEqual(t, "remarks", node.Comment())
+		case "app/url":
+			assertEqual(t, 7, node.Tag())
+			assertEqual(t, "http://x/y", node.Data())
+		}
+	})
+	slices.Sort(keys)
+	assertEqual(t, []string{"app/debug", "app/servers/10.0.0.1/path", "app/servers/local/path", "app/url"}, keys)
+
+	assertTrue(t, trie.ChangeDelimiter('.') == nil)
+	assertEqual(t, "/usr/bin", trie.MustGet(`app.servers."10.0.0.1".path`))
+	assertEqual(t, "http://x/y", trie.MustGet("app.url"))
+}
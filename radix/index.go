@@ -0,0 +1,522 @@
+package radix
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/hedzr/errors.v3"
+
+	logz "github.com/hedzr/logg/slog"
+)
+
+// indexStep is a step of an index path after its base key, such
+// as "[2]", "[-1]", "[*]" or ".host".
+type indexStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// IsIndexPath tests if path addresses the elements of a slice, such
+// as "app.servers[2].host", "app.servers[-1]" or
+// "app.servers[*].host".
+//
+// A path with malformed brackets, such as "app.a[b]", is a plain
+// key.
+func IsIndexPath(path string, delimiter rune) bool {
+	_, _, ok := splitIndexPath(path, delimiter)
+	return ok
+}
+
+// splitIndexPath splits an index path into its base key and the
+// steps.
+func splitIndexPath(path string, delimiter rune) (base string, steps []indexStep, ok bool) {
+	pos := strings.IndexByte(path, '[')
+	if pos <= 0 {
+		return
+	}
+	base, rest := path[:pos], path[pos:]
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", nil, false
+			}
+			st := indexStep{isIndex: true}
+			if idx := rest[1:end]; idx == "*" {
+				st.wildcard = true
+			} else if n, err := strconv.Atoi(idx); err == nil {
+				st.index = n
+			} else {
+				return "", nil, false
+			}
+			steps, rest = append(steps, st), rest[end+1:]
+		case strings.HasPrefix(rest, string(delimiter)):
+			rest = rest[len(string(delimiter)):]
+			end := strings.IndexFunc(rest, func(r rune) bool { return r == delimiter || r == '[' })
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return "", nil, false
+			}
+			steps, rest = append(steps, indexStep{key: rest[:end]}), rest[end:]
+		default:
+			return "", nil, false
+		}
+	}
+	return base, steps, true
+}
+
+// hasWildcard tests if any of steps is "[*]".
+func hasWildcard(steps []indexStep) bool {
+	for _, st := range steps {
+		if st.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// normIndex resolves a negative index from the end, and tests if
+// it's in [0, n).
+func normIndex(i, n int) (int, bool) {
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0 && i < n
+}
+
+// asT converts v to T, or returns the zero value of T.
+func asT[T any](v any) (ret T) {
+	if t, ok := v.(T); ok {
+		ret = t
+	}
+	return
+}
+
+// queryIndexed is Query for an index path, the path is full. A
+// wildcard path returns a []any of the matched values.
+func (s *trieS[T]) queryIndexed(path string) (data T, branch, found bool, err error) {
+	base, steps, _ := splitIndexPath(path, s.Delimiter())
+	var results []any
+	if results, found = s.resolveIndexed(base, steps, nil); !found {
+		err = errors.NotFound
+		return
+	}
+	if hasWildcard(steps) {
+		return asT[T](results), false, true, nil
+	}
+	return asT[T](results[0]), false, true, nil
+}
+
+// resolveIndexed collects the values at the steps from the key at
+// path, which is a leaf holding a slice or a map, or a subtree
+// flattened by WithFlattenSlice.
+func (s *trieS[T]) resolveIndexed(path string, steps []indexStep, out []any) ([]any, bool) {
+	if data, ok := s.valueAt(path); ok {
+		return lookupValue(data, steps, out)
+	}
+	if len(steps) == 0 {
+		m, err := s.withPrefixReplacedImpl().GetM(path + string(s.Delimiter()))
+		if err != nil || len(m) == 0 {
+			return out, false
+		}
+		return append(out, m), true
+	}
+
+	st := steps[0]
+	if !st.isIndex {
+		return s.resolveIndexed(s.Join(path, st.key), steps[1:], out)
+	}
+	indices := s.childIndices(path)
+	if st.wildcard {
+		for _, i := range indices {
+			out, _ = s.resolveIndexed(s.Join(path, strconv.Itoa(i)), steps[1:], out)
+		}
+		return out, len(indices) > 0
+	}
+	i, ok := normIndex(st.index, len(indices))
+	if !ok {
+		return out, false
+	}
+	return s.resolveIndexed(s.Join(path, strconv.Itoa(indices[i])), steps[1:], out)
+}
+
+// valueAt returns the data of the node at the full path. Unlike
+// queryFull, a node holding data is taken even if it's split for
+// a longer sibling key, such as "app.ports.1" for "app.ports.10".
+func (s *trieS[T]) valueAt(path string) (data T, ok bool) {
+	node, _, partialMatched := s.search(path, nil)
+	if ok = node != nil && !partialMatched && node.hasData() && node.pathS == path; ok {
+		data = node.Data()
+	}
+	return
+}
+
+// childIndices returns the numeric child keys under path in order,
+// they're the elements of a flattened slice.
+func (s *trieS[T]) childIndices(path string) (indices []int) {
+	prefix := path + string(s.Delimiter())
+	seen := make(map[int]bool)
+	s.tree.load().walk(0, func(key, _ string, node Node[T]) {
+		if !node.HasData() || !strings.HasPrefix(key, prefix) {
+			return
+		}
+		seg := key[len(prefix):]
+		if pos := indexDelimiter(seg, string(s.Delimiter())); pos >= 0 {
+			seg = seg[:pos]
+		}
+		if n, err := strconv.Atoi(seg); err == nil && n >= 0 && !seen[n] {
+			seen[n] = true
+			indices = append(indices, n)
+		}
+	})
+	sort.Ints(indices)
+	return
+}
+
+// lookupValue navigates a slice or map value by steps.
+func lookupValue(v any, steps []indexStep, out []any) ([]any, bool) {
+	if len(steps) == 0 {
+		return append(out, v), true
+	}
+	st, rv := steps[0], reflect.ValueOf(v)
+	if st.isIndex {
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return out, false
+		}
+		if st.wildcard {
+			for i := 0; i < rv.Len(); i++ {
+				out, _ = lookupValue(rv.Index(i).Interface(), steps[1:], out)
+			}
+			return out, true
+		}
+		i, ok := normIndex(st.index, rv.Len())
+		if !ok {
+			return out, false
+		}
+		return lookupValue(rv.Index(i).Interface(), steps[1:], out)
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return out, false
+	}
+	e := rv.MapIndex(reflect.ValueOf(st.key).Convert(rv.Type().Key()))
+	if !e.IsValid() {
+		return out, false
+	}
+	return lookupValue(e.Interface(), steps[1:], out)
+}
+
+// setIndexed is Set for an index path, the path is full. The index
+// equal to the length of a slice appends an element.
+func (s *trieS[T]) setIndexed(path string, data T) (node Node[T], oldData any) {
+	base, steps, _ := splitIndexPath(path, s.Delimiter())
+	return s.setIndexedAt(base, steps, data)
+}
+
+func (s *trieS[T]) setIndexedAt(path string, steps []indexStep, data T) (node Node[T], oldData any) {
+	if len(steps) == 0 {
+		return s.insertFull(path, data)
+	}
+	if old, ok := s.valueAt(path); ok {
+		nv, o, err := setValue(old, steps, data)
+		if err != nil {
+			logz.Warn("[store/radix] cannot set by index path", "path", path, "err", err)
+			return
+		}
+		node, _ = s.insertFull(path, asT[T](nv))
+		return node, o
+	}
+
+	st := steps[0]
+	if !st.isIndex {
+		return s.setIndexedAt(s.Join(path, st.key), steps[1:], data)
+	}
+	indices := s.childIndices(path)
+	if len(indices) == 0 {
+		return
+	}
+	if st.wildcard {
+		for _, i := range indices {
+			node, oldData = s.setIndexedAt(s.Join(path, strconv.Itoa(i)), steps[1:], data)
+		}
+		return
+	}
+	i, ok := normIndex(st.index, len(indices))
+	switch {
+	case ok:
+		i = indices[i]
+	case st.index == len(indices):
+		i = indices[len(indices)-1] + 1
+	default:
+		return
+	}
+	return s.setIndexedAt(s.Join(path, strconv.Itoa(i)), steps[1:], data)
+}
+
+// setValue returns a copy of v with the element at steps replaced
+// by data, and the old element.
+func setValue(v any, steps []indexStep, data any) (ret, oldData any, err error) {
+	if len(steps) == 0 {
+		return data, v, nil
+	}
+	st, rv := steps[0], reflect.ValueOf(v)
+	if st.isIndex {
+		if rv.Kind() != reflect.Slice {
+			return nil, nil, errors.New("%T is not a slice", v)
+		}
+		ns := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len()+1)
+		reflect.Copy(ns, rv)
+		set := func(i int) (err error) {
+			var elem, ne any
+			if i < ns.Len() {
+				elem = ns.Index(i).Interface()
+			} else {
+				ns = reflect.Append(ns, reflect.Zero(rv.Type().Elem()))
+			}
+			if ne, oldData, err = setValue(elem, steps[1:], data); err != nil {
+				return
+			}
+			return assignTo(ns.Index(i), ne)
+		}
+		if st.wildcard {
+			for i := 0; i < rv.Len() && err == nil; i++ {
+				err = set(i)
+			}
+		} else if i, ok := normIndex(st.index, rv.Len()); ok || st.index == rv.Len() {
+			if !ok {
+				i = rv.Len()
+			}
+			err = set(i)
+		} else {
+			err = errors.New("index %d out of range [0, %d]", st.index, rv.Len())
+		}
+		return ns.Interface(), oldData, err
+	}
+
+	if v == nil {
+		rv = reflect.ValueOf(map[string]any{})
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, nil, errors.New("%T is not a map", v)
+	}
+	nm := reflect.MakeMapWithSize(rv.Type(), rv.Len()+1)
+	iter := rv.MapRange()
+	for iter.Next() {
+		nm.SetMapIndex(iter.Key(), iter.Value())
+	}
+	key := reflect.ValueOf(st.key).Convert(rv.Type().Key())
+	var elem, ne any
+	if e := rv.MapIndex(key); e.IsValid() {
+		elem = e.Interface()
+	}
+	if ne, oldData, err = setValue(elem, steps[1:], data); err != nil {
+		return
+	}
+	ev := reflect.New(rv.Type().Elem()).Elem()
+	if err = assignTo(ev, ne); err == nil {
+		nm.SetMapIndex(key, ev)
+	}
+	return nm.Interface(), oldData, err
+}
+
+// assignTo sets v to target, a nil v sets the zero value.
+func assignTo(target reflect.Value, v any) error {
+	if v == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.Type().AssignableTo(target.Type()) {
+		return errors.New("cannot set %T into an element of %v", v, target.Type())
+	}
+	target.Set(rv)
+	return nil
+}
+
+// removeIndexed is RemoveEx for an index path, the path is full.
+// nodeRemoved is a detached node keyed by path, holding the removed
+// element, or a []any of them for a wildcard path.
+func (s *trieS[T]) removeIndexed(path string) (nodeRemoved, nodeParent Node[T], removed bool) {
+	base, steps, _ := splitIndexPath(path, s.Delimiter())
+	var olds []any
+	if nodeParent, olds, removed = s.removeIndexedAt(base, steps, nil); removed {
+		var data any
+		if hasWildcard(steps) {
+			data = olds
+		} else if len(olds) > 0 {
+			data = olds[0]
+		}
+		nodeRemoved = newLeafNode("", path, asT[T](data))
+	}
+	return
+}
+
+func (s *trieS[T]) removeIndexedAt(path string, steps []indexStep, olds []any) (nodeParent Node[T], _ []any, removed bool) {
+	if old, ok := s.valueAt(path); ok {
+		nv, o, ok := removeValue(old, steps, olds)
+		if ok {
+			nodeParent, _ = s.insertFull(path, asT[T](nv))
+		}
+		return nodeParent, o, ok
+	}
+
+	st := steps[0]
+	if !st.isIndex {
+		return s.removeIndexedAt(s.Join(path, st.key), steps[1:], olds)
+	}
+	indices := s.childIndices(path)
+	if len(steps) > 1 {
+		if st.wildcard {
+			for _, i := range indices {
+				var ok bool
+				if _, olds, ok = s.removeIndexedAt(s.Join(path, strconv.Itoa(i)), steps[1:], olds); ok {
+					removed = true
+				}
+			}
+			return nil, olds, removed
+		}
+		if i, ok := normIndex(st.index, len(indices)); ok {
+			return s.removeIndexedAt(s.Join(path, strconv.Itoa(indices[i])), steps[1:], olds)
+		}
+		return nil, olds, false
+	}
+
+	// the last step removes the elements of a flattened slice, the
+	// following ones are renumbered.
+	drop := make(map[int]bool)
+	if st.wildcard {
+		for _, i := range indices {
+			drop[i] = true
+		}
+	} else if i, ok := normIndex(st.index, len(indices)); ok {
+		drop[indices[i]] = true
+	}
+	if len(drop) == 0 {
+		return nil, olds, false
+	}
+	for _, i := range indices {
+		if drop[i] {
+			if r, ok := s.resolveIndexed(s.Join(path, strconv.Itoa(i)), nil, nil); ok {
+				olds = append(olds, r[0])
+			}
+		}
+	}
+	s.renumber(path, indices, drop)
+	return nil, olds, true
+}
+
+// renumber rebuilds the flattened slice at path without the dropped
+// elements, the nodes are moved with their descriptions, comments
+// and tags.
+func (s *trieS[T]) renumber(path string, indices []int, drop map[int]bool) {
+	prefix := path + string(s.Delimiter())
+	var leaves []*nodeS[T]
+	s.tree.load().walk(0, func(key, _ string, node Node[T]) {
+		if nd, ok := node.(*nodeS[T]); ok && nd.hasData() && strings.HasPrefix(key, prefix) {
+			leaves = append(leaves, nd)
+		}
+	})
+
+	renamed := make(map[int]int, len(indices))
+	for _, i := range indices {
+		if !drop[i] {
+			renamed[i] = len(renamed)
+		}
+	}
+
+	if _, _, ok := s.removeFull(prefix); !ok {
+		for _, nd := range leaves {
+			s.removeFull(nd.pathS)
+		}
+	}
+
+	for _, nd := range leaves {
+		seg, rest := nd.pathS[len(prefix):], ""
+		if pos := indexDelimiter(seg, string(s.Delimiter())); pos >= 0 {
+			seg, rest = seg[:pos], seg[pos:]
+		}
+		i, err := strconv.Atoi(seg)
+		if err != nil {
+			continue
+		}
+		j, ok := renamed[i]
+		if !ok {
+			continue
+		}
+		key := prefix + strconv.Itoa(j) + rest
+		s.insertFull(key, nd.Data(), func(n *nodeS[T]) {
+			n.SetComment(nd.Description(), nd.Comment())
+			n.SetTag(nd.Tag())
+		})
+	}
+}
+
+// removeValue returns a copy of v without the elements at steps,
+// and appends the removed ones to olds.
+func removeValue(v any, steps []indexStep, olds []any) (ret any, _ []any, removed bool) {
+	st, rv := steps[0], reflect.ValueOf(v)
+	last := len(steps) == 1
+	if st.isIndex {
+		if rv.Kind() != reflect.Slice {
+			return v, olds, false
+		}
+		ns := reflect.MakeSlice(rv.Type(), 0, rv.Len())
+		target, ok := normIndex(st.index, rv.Len())
+		if !st.wildcard && !ok {
+			return v, olds, false
+		}
+		for i := 0; i < rv.Len(); i++ {
+			elem := rv.Index(i)
+			if !st.wildcard && i != target {
+				ns = reflect.Append(ns, elem)
+				continue
+			}
+			if last {
+				olds, removed = append(olds, elem.Interface()), true
+				continue
+			}
+			ne, o, yes := removeValue(elem.Interface(), steps[1:], olds)
+			olds, removed = o, removed || yes
+			ev := reflect.New(rv.Type().Elem()).Elem()
+			if err := assignTo(ev, ne); err != nil {
+				return v, olds, false
+			}
+			ns = reflect.Append(ns, ev)
+		}
+		return ns.Interface(), olds, removed
+	}
+
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return v, olds, false
+	}
+	key := reflect.ValueOf(st.key).Convert(rv.Type().Key())
+	e := rv.MapIndex(key)
+	if !e.IsValid() {
+		return v, olds, false
+	}
+	nm := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		nm.SetMapIndex(iter.Key(), iter.Value())
+	}
+	if last {
+		nm.SetMapIndex(key, reflect.Value{})
+		return nm.Interface(), append(olds, e.Interface()), true
+	}
+	ne, o, yes := removeValue(e.Interface(), steps[1:], olds)
+	if !yes {
+		return v, o, false
+	}
+	ev := reflect.New(rv.Type().Elem()).Elem()
+	if err := assignTo(ev, ne); err != nil {
+		return v, o, false
+	}
+	nm.SetMapIndex(key, ev)
+	return nm.Interface(), o, true
+}
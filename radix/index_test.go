@@ -0,0 +1,97 @@
+package radix
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSplitIndexPath(t *testing.T) {
+	base, steps, ok := splitIndexPath("app.servers[2].host", '.')
+	assertTrue(t, ok)
+	assertEqual(t, "app.servers", base)
+	assertEqual(t, []indexStep{{index: 2, isIndex: true}, {key: "host"}}, steps)
+
+	_, steps, ok = splitIndexPath("app.m[-1][*]", '.')
+	assertTrue(t, ok)
+	assertEqual(t, []indexStep{{index: -1, isIndex: true}, {isIndex: true, wildcard: true}}, steps)
+
+	for _, path := range []string{"app.servers", "app.a[b]", "app.a[1", "app.a[1]x", "[1]", "app.a[1]..b"} {
+		assertFalse(t, IsIndexPath(path, '.'), path)
+	}
+}
+
+func TestTrieS_IndexPath(t *testing.T) {
+	trie := newTrie[any]()
+	trie.Set("app.servers", []any{
+		map[string]any{"host": "a", "port": 80},
+		map[string]any{"host": "b", "port": 81},
+		map[string]any{"host": "c", "port": 82},
+	})
+	trie.Set("app.ports", []int{1, 2, 3})
+
+	v, branch, found, err := trie.Query("app.servers[2].host", nil)
+	assertTrue(t, found && !branch && err == nil)
+	assertEqual(t, "c", v)
+	assertEqual(t, 3, trie.MustGet("app.ports[-1]"))
+	assertEqual(t, []any{"a", "b", "c"}, trie.MustGet("app.servers[*].host"))
+	assertTrue(t, trie.Has("app.servers[0]"))
+	assertFalse(t, trie.Has("app.servers[3]"))
+	assertFalse(t, trie.Has("app.servers[0].user"))
+
+	_, old := trie.Set("app.servers[1].host", "bb")
+	assertEqual(t, "b", old)
+	assertEqual(t, "bb", trie.MustGet("app.servers[1].host"))
+	trie.Set("app.ports[3]", 4) // appends
+	assertEqual(t, []int{1, 2, 3, 4}, trie.MustGet("app.ports"))
+	trie.Set("app.ports[5]", 6) // out of range
+	assertEqual(t, []int{1, 2, 3, 4}, trie.MustGet("app.ports"))
+	trie.Set("app.ports[0]", "x") // mismatched type
+	assertEqual(t, []int{1, 2, 3, 4}, trie.MustGet("app.ports"))
+	trie.Set("app.servers[*].port", 8080)
+	assertEqual(t, []any{8080, 8080, 8080}, trie.MustGet("app.servers[*].port"))
+
+	rmn, _, removed := trie.RemoveEx("app.ports[1]")
+	assertTrue(t, removed)
+	assertEqual(t, 2, rmn.Data())
+	assertEqual(t, "app.ports[1]", rmn.Key())
+	assertEqual(t, []int{1, 3, 4}, trie.MustGet("app.ports"))
+	assertTrue(t, trie.Remove("app.servers[0].port"))
+	assertEqual(t, map[string]any{"host": "a"}, trie.MustGet("app.servers[0]"))
+	assertFalse(t, trie.Remove("app.servers[9]"))
+}
+
+func TestTrieS_IndexPathFlattened(t *testing.T) {
+	trie := newTrie[any]()
+	ports := []int{80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90, 91}
+	for i, p := range ports {
+		trie.Set(trie.Join("app.ports", strconv.Itoa(i)), p) // 1 is a prefix of 10 and 11
+	}
+	trie.Set("app.srv.0.host", "a")
+	trie.Set("app.srv.1.host", "b")
+	trie.Set("app.srv.1.port", 81)
+
+	assertEqual(t, 81, trie.MustGet("app.ports[1]"))
+	assertEqual(t, 91, trie.MustGet("app.ports[-1]"))
+	assertEqual(t, "b", trie.MustGet("app.srv[1].host"))
+	assertEqual(t, []any{"a", "b"}, trie.MustGet("app.srv[*].host"))
+	assertEqual(t, map[string]any{"host": "b", "port": 81}, trie.MustGet("app.srv[-1]"))
+
+	trie.Set("app.srv[0].host", "aa")
+	assertEqual(t, "aa", trie.MustGet("app.srv.0.host"))
+	trie.Set("app.srv[2].host", "c") // appends
+	assertEqual(t, "c", trie.MustGet("app.srv.2.host"))
+
+	trie.SetComment("app.ports.11", "the last", "")
+	assertTrue(t, trie.Remove("app.ports[1]"))
+	assertEqual(t, 82, trie.MustGet("app.ports[1]"))
+	assertEqual(t, 91, trie.MustGet("app.ports.10"))
+	assertEqual(t, "the last", trie.MustGetDesc("app.ports.10"))
+	assertFalse(t, trie.Has("app.ports.11"))
+
+	rmn, _, removed := trie.RemoveEx("app.srv[0]")
+	assertTrue(t, removed)
+	assertEqual(t, map[string]any{"host": "aa"}, rmn.Data())
+	assertEqual(t, "b", trie.MustGet("app.srv.0.host"))
+	assertEqual(t, "c", trie.MustGet("app.srv.1.host"))
+	assertFalse(t, trie.Has("app.srv.2.host"))
+}
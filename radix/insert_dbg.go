@@ -3,9 +3,9 @@
 
 package radix
 
-func (s *nodeS[T]) insert(word []rune, fullPath string, data T, trie *trieS[T], cb OnSetEx[T]) (node Node[T], oldData any) {
-	node, oldData = s.insertInternal(word, fullPath, data, trie, cb)
-	str := s.dump(true) // check integrity
+func (s *nodeS[T]) insert(word, fullPath string, data T) (repl, node *nodeS[T], oldData any) {
+	repl, node, oldData = s.insertInternal(word, fullPath, data)
+	str := repl.dump(true) // check integrity
 	_ = str
 	return
 }
@@ -0,0 +1,321 @@
+package radix
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// Resolver resolves the name of a ${scheme:name} reference to
+// its value.
+type Resolver func(name string) (value string, err error)
+
+var defaultResolvers = map[string]Resolver{
+	"env": func(name string) (value string, err error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			err = errors.New("env var %q not found", name)
+		}
+		return
+	},
+	"file": func(name string) (value string, err error) {
+		var b []byte
+		if b, err = os.ReadFile(name); err == nil {
+			value = strings.TrimRight(string(b), "\r\n")
+		}
+		return
+	},
+}
+
+// interpS holds the interpolation settings, it is shared by a
+// Trie and all of its prefixed views.
+type interpS struct {
+	mu        sync.RWMutex
+	enabled   bool
+	resolvers map[string]Resolver
+	values    map[string]Resolver // by the prefix of whole value, see RegisterValueResolver
+}
+
+func newInterp() *interpS { return &interpS{} }
+
+func (s *interpS) isEnabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled
+}
+
+// isActive reports whether the values should be passed to
+// expandAny, for interpolation or value resolvers.
+func (s *interpS) isActive() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled || len(s.values) > 0
+}
+
+// valueResolver finds the resolver registered for the prefix of
+// text, and returns text without the prefix as 'name'.
+func (s *interpS) valueResolver(text string) (r Resolver, name string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for prefix, vr := range s.values {
+		if strings.HasPrefix(text, prefix) {
+			return vr, text[len(prefix):], true
+		}
+	}
+	return
+}
+
+func (s *interpS) resolver(scheme string) (r Resolver) {
+	s.mu.RLock()
+	r = s.resolvers[scheme]
+	s.mu.RUnlock()
+	if r == nil {
+		r = defaultResolvers[scheme]
+	}
+	return
+}
+
+// SetInterpolation enables or disables the interpolation for the
+// typed getters (GetString, MustInt, ...), GetM, GetR and To.
+//
+// Once enabled, a string value can refer to the others:
+//
+//   - ${app.home} is replaced with the value of key "app.home".
+//     The key is a full path, even if it is read from a prefixed
+//     view.
+//   - ${env:HOME} is replaced with the environment variable.
+//   - ${file:/run/secrets/token} is replaced with the content of
+//     the file, the trailing newlines are trimmed.
+//   - ${scheme:name} is resolved by a custom resolver, see
+//     RegisterResolver.
+//   - $${ is an escaped ${.
+//
+// The stored values are kept unexpanded, and Get, MustGet and
+// Walk return them as is.
+//
+// A GetXXX returns an error if a reference cannot be resolved or
+// a cyclic reference found, and the MustXXX returns the default
+// value in this case.
+func (s *trieS[T]) SetInterpolation(enabled bool) {
+	s.interp.mu.Lock()
+	defer s.interp.mu.Unlock()
+	s.interp.enabled = enabled
+}
+
+// RegisterResolver adds a resolver for the references like
+// ${scheme:name}. Passing nil resolver removes it.
+//
+// The builtin schemes "env" and "file" can be overridden.
+func (s *trieS[T]) RegisterResolver(scheme string, resolver Resolver) {
+	s.interp.mu.Lock()
+	defer s.interp.mu.Unlock()
+	if resolver == nil {
+		delete(s.interp.resolvers, scheme)
+		return
+	}
+	if s.interp.resolvers == nil {
+		s.interp.resolvers = make(map[string]Resolver)
+	}
+	s.interp.resolvers[scheme] = resolver
+}
+
+// RegisterValueResolver adds a resolver for the whole string
+// values starting with prefix, such as "secret://". The resolver
+// is called with the rest of the value, and its result replaces
+// the value. Passing nil resolver removes it.
+//
+// Unlike RegisterResolver, the value resolvers work even if the
+// interpolation is disabled. Same as the interpolation, the
+// stored values are kept as is, only the typed getters, GetM,
+// GetR and To return the resolved values.
+func (s *trieS[T]) RegisterValueResolver(prefix string, resolver Resolver) {
+	s.interp.mu.Lock()
+	defer s.interp.mu.Unlock()
+	if resolver == nil {
+		delete(s.interp.values, prefix)
+		return
+	}
+	if s.interp.values == nil {
+		s.interp.values = make(map[string]Resolver)
+	}
+	s.interp.values[prefix] = resolver
+}
+
+// Expand resolves the references in text. It works even if the
+// interpolation is disabled.
+func (s *trieS[T]) Expand(text string) (ret string, err error) {
+	return s.expand(text, nil)
+}
+
+// QueryResolved is Query with the interpolation and the value
+// resolvers applied, as the typed getters do.
+func (s *trieS[T]) QueryResolved(path string) (data T, branch, found bool, err error) {
+	data, branch, found, err = s.Query(path, nil)
+	if found && err == nil && s.interp.isActive() {
+		data, err = s.expandData(data)
+	}
+	return
+}
+
+// query is QueryResolved for the typed getters.
+func (s *trieS[T]) query(path string) (data T, branch, found bool, err error) {
+	if data, branch, found, err = s.QueryResolved(path); err != nil {
+		found = false // so the getters fall back to the default value
+	}
+	return
+}
+
+// leafData returns the data of node for GetM and GetR, the
+// first error will be kept in 'err'.
+func (s *trieS[T]) leafData(node Node[T], interp bool, err *error) (data any) {
+	data = node.Data()
+	if interp {
+		var e error
+		if data, e = s.expandAny(data); e != nil && *err == nil {
+			*err = e
+		}
+	}
+	return
+}
+
+func (s *trieS[T]) expandData(data T) (ret T, err error) {
+	var v any
+	ret = data
+	if v, err = s.expandAny(data); err == nil {
+		if t, ok := v.(T); ok {
+			ret = t
+		}
+	}
+	return
+}
+
+func (s *trieS[T]) expandAny(data any) (ret any, err error) {
+	switch v := data.(type) {
+	case string:
+		return s.expandValue(v)
+	case []string:
+		if !anyHasRef(v) && !s.anyHasValueResolver(v) {
+			return v, nil
+		}
+		a := make([]string, len(v))
+		for i, it := range v {
+			if a[i], err = s.expandValue(it); err != nil {
+				return data, err
+			}
+		}
+		return a, nil
+	case []any:
+		a := make([]any, len(v))
+		for i, it := range v {
+			if a[i], err = s.expandAny(it); err != nil {
+				return data, err
+			}
+		}
+		return a, nil
+	}
+	return data, nil
+}
+
+// expandValue resolves text by a value resolver if it has a
+// registered prefix, or else expands the references in it.
+func (s *trieS[T]) expandValue(text string) (ret string, err error) {
+	if r, name, ok := s.interp.valueResolver(text); ok {
+		if ret, err = r(name); err != nil {
+			err = errors.New("cannot resolve %q", text).WithErrors(err)
+		}
+		return
+	}
+	if !s.interp.isEnabled() {
+		return text, nil
+	}
+	return s.expand(text, nil)
+}
+
+func (s *trieS[T]) anyHasValueResolver(a []string) bool {
+	for _, it := range a {
+		if _, _, ok := s.interp.valueResolver(it); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func anyHasRef(a []string) bool {
+	for _, it := range a {
+		if strings.Contains(it, "${") {
+			return true
+		}
+	}
+	return false
+}
+
+// expand replaces the references in text. 'visiting' holds the
+// keys in resolving, for detecting the cyclic references.
+func (s *trieS[T]) expand(text string, visiting []string) (ret string, err error) {
+	if !strings.Contains(text, "${") {
+		return text, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], "$${") {
+			_, _ = sb.WriteString("${")
+			i += 3
+			continue
+		}
+		if strings.HasPrefix(text[i:], "${") {
+			end := strings.IndexByte(text[i+2:], '}')
+			if end < 0 {
+				_, _ = sb.WriteString(text[i:]) // unterminated, keep it
+				break
+			}
+			ref := text[i+2 : i+2+end]
+			val, e := s.resolveRef(ref, visiting)
+			if e != nil {
+				return text, e
+			}
+			_, _ = sb.WriteString(val)
+			i += 3 + end
+			continue
+		}
+		_ = sb.WriteByte(text[i])
+		i++
+	}
+	return sb.String(), nil
+}
+
+func (s *trieS[T]) resolveRef(ref string, visiting []string) (val string, err error) {
+	if scheme, name, ok := strings.Cut(ref, ":"); ok {
+		if r := s.interp.resolver(scheme); r != nil {
+			if val, err = r(name); err != nil {
+				err = errors.New("cannot resolve ${%s}", ref).WithErrors(err)
+			}
+			return
+		}
+	}
+
+	for _, v := range visiting {
+		if v == ref {
+			return "", errors.New("cyclic reference: %s -> %s", strings.Join(visiting, " -> "), ref)
+		}
+	}
+	data, _, found, _ := s.queryFull(ref, nil)
+	if !found {
+		return "", errors.New("cannot resolve ${%s}", ref).WithErrors(errors.NotFound)
+	}
+	if str, ok := any(data).(string); ok {
+		if r, name, ok := s.interp.valueResolver(str); ok {
+			return r(name)
+		}
+		return s.expand(str, append(visiting, ref))
+	}
+	return converter.String(data), nil
+}
@@ -0,0 +1,83 @@
+package radix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrieS_Interpolation(t *testing.T) {
+	trie := newTrieTree()
+	trie.Set("app.home", "/opt/app")
+	trie.Set("app.log.dir", "${app.home}/logs")
+	trie.Set("app.log.file", "${app.log.dir}/app.log")
+	trie.Set("app.port", 7999)
+	trie.Set("app.addr", ":${app.port}")
+	trie.Set("app.user", "${env:STORE_TEST_USER}")
+	trie.Set("app.escaped", "$${app.home}")
+	trie.Set("app.words", []string{"${app.home}", "b"})
+	trie.Set("app.xa", "${app.xb}")
+	trie.Set("app.xb", "${app.xa}")
+	trie.Set("app.missing", "${app.nothing}")
+	t.Setenv("STORE_TEST_USER", "bob")
+
+	assertEqual(t, "${app.home}/logs", trie.MustString("app.log.dir"), "disabled by default")
+
+	trie.SetInterpolation(true)
+	assertEqual(t, "/opt/app/logs/app.log", trie.MustString("app.log.file"))
+	assertEqual(t, ":7999", trie.MustString("app.addr"))
+	assertEqual(t, "bob", trie.MustString("app.user"))
+	assertEqual(t, "${app.home}", trie.MustString("app.escaped"))
+	assertEqual(t, []string{"/opt/app", "b"}, trie.MustStringSlice("app.words"))
+	assertEqual(t, "${app.home}/logs", trie.MustGet("app.log.dir"), "raw value is kept")
+
+	log := trie.WithPrefix("app.log")
+	assertEqual(t, "/opt/app/logs", log.MustString("dir"), "references are full paths")
+
+	_, err := trie.GetString("app.xa")
+	assertTrue(t, err != nil && strings.Contains(err.Error(), "cyclic"), "expecting cyclic reference error")
+	_, err = trie.GetString("app.missing")
+	assertTrue(t, err != nil, "expecting unresolved reference error")
+	assertEqual(t, "def", trie.MustString("app.missing", "def"))
+
+	m := trie.MustM("app.log")
+	assertEqual(t, "/opt/app/logs", m["dir"])
+	m = trie.MustM("app.log", WithRawValues[any](true))
+	assertEqual(t, "${app.home}/logs", m["dir"])
+
+	var holder struct{ Dir, File string }
+	if err = trie.To("app.log", &holder); err != nil {
+		t.Fatalf("To failed: %v", err)
+	}
+	assertEqual(t, "/opt/app/logs", holder.Dir)
+
+	trie.RegisterResolver("upper", func(name string) (string, error) { return strings.ToUpper(name), nil })
+	v, _ := trie.Expand("${upper:abc}-${app.port}")
+	assertEqual(t, "ABC-7999", v)
+
+	token := filepath.Join(t.TempDir(), "token")
+	_ = os.WriteFile(token, []byte("s3cr3t\n"), 0600)
+	v, _ = trie.Expand("${file:" + token + "}")
+	assertEqual(t, "s3cr3t", v)
+}
+
+func TestTrieS_RegisterValueResolver(t *testing.T) {
+	trie := newTrieTree()
+	trie.Set("db.password", "secret://db#password")
+	trie.Set("db.dsn", "postgres://app:${db.password}@db")
+	trie.Set("db.hosts", []string{"secret://hosts", "db2"})
+	trie.RegisterValueResolver("secret://", func(name string) (string, error) { return "<" + name + ">", nil })
+
+	assertEqual(t, "<db#password>", trie.MustString("db.password"), "works without interpolation")
+	assertEqual(t, "postgres://app:${db.password}@db", trie.MustString("db.dsn"))
+	assertEqual(t, []string{"<hosts>", "db2"}, trie.MustStringSlice("db.hosts"))
+	assertEqual(t, "secret://db#password", trie.MustGet("db.password"), "raw value is kept")
+	assertEqual(t, "secret://db#password", trie.MustM("db", WithRawValues[any](true))["password"])
+
+	trie.SetInterpolation(true)
+	assertEqual(t, "postgres://app:<db#password>@db", trie.MustString("db.dsn"))
+
+	trie.RegisterValueResolver("secret://", nil)
+	assertEqual(t, "secret://db#password", trie.MustString("db.password"))
+}
@@ -0,0 +1,147 @@
+package radix
+
+import (
+	"iter"
+	"sort"
+	"strings"
+)
+
+// All returns an iterator over the nodes under prefix, including
+// the node at prefix, in lexicographic order of their full keys.
+// The keys of the branch nodes end with the delimiter, such as
+// "app.server.", like Walk. The nodes split from a longer key,
+// which hold no data and aren't a branch key, are skipped.
+//
+//	for key, node := range trie.All("app.server") {
+//	    if key == "app.server.tls." {
+//	        break
+//	    }
+//	    ...
+//	}
+//
+// The iterators, All, Leaves, Keys and Children, take a snapshot
+// of the keys when the iteration starts. The writings after that,
+// such as the Set and Remove calls in the loop body, aren't
+// observed by the iteration, and don't disturb it. A node
+// yielded by All is the live node, its data is read at the time.
+func (s *trieS[T]) All(prefix string) iter.Seq2[string, Node[T]] {
+	return func(yield func(string, Node[T]) bool) {
+		for _, node := range s.collectNodes(prefix, func(node *nodeS[T]) bool {
+			return node.hasData() || (node.isBranch() && node.endsWith(s.Delimiter()))
+		}) {
+			if !yield(node.pathS, node) {
+				return
+			}
+		}
+	}
+}
+
+// Leaves returns an iterator over the keys and data of the leaves
+// under prefix, in lexicographic order of the keys. The data are
+// read when the iteration starts. See also All for the snapshot
+// semantics.
+func (s *trieS[T]) Leaves(prefix string) iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		type leafS struct {
+			key  string
+			data T
+		}
+		var leaves []leafS
+		for _, node := range s.collectNodes(prefix, s.isLeafKey) {
+			leaves = append(leaves, leafS{node.pathS, node.Data()})
+		}
+		for _, it := range leaves {
+			if !yield(it.key, it.data) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of the leaves under
+// prefix, in lexicographic order. See also All for the snapshot
+// semantics.
+func (s *trieS[T]) Keys(prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, node := range s.collectNodes(prefix, s.isLeafKey) {
+			if !yield(node.pathS) {
+				return
+			}
+		}
+	}
+}
+
+// Children returns an iterator over the direct children of path,
+// in lexicographic order of their full keys. The key of a child
+// has no trailing delimiter, such as "app.server" for path "app".
+//
+// The node of a leaf child is yielded. For a branch child, it's
+// the branch node, or a detached branch node if the tree merged
+// it into a longer key, such as "app.server.port" which is the
+// only key under "app.server". See also All for the snapshot
+// semantics.
+func (s *trieS[T]) Children(path string) iter.Seq2[string, Node[T]] {
+	return func(yield func(string, Node[T]) bool) {
+		base := s.fullKey(path)
+		delim := string(s.Delimiter())
+		var keys []string
+		children := make(map[string]Node[T])
+		for _, node := range s.collectNodes(path, func(node *nodeS[T]) bool {
+			return s.isLeafKey(node) || (node.isBranch() && node.endsWith(s.Delimiter()))
+		}) {
+			rel := node.pathS
+			if base != "" {
+				if !strings.HasPrefix(rel, base+delim) {
+					continue // the node at path itself
+				}
+				rel = rel[len(base)+len(delim):]
+			}
+			name := rel
+			if pos := indexDelimiter(rel, delim); pos >= 0 {
+				name = rel[:pos]
+			}
+			key := name
+			if base != "" {
+				key = base + delim + name
+			}
+			if _, ok := children[key]; ok || name == "" {
+				continue // the leaf, then the branch node, come first in order
+			}
+			keys = append(keys, key)
+			if rel == name || rel == name+delim {
+				children[key] = node
+			} else {
+				children[key] = &nodeS[T]{path: name + delim, pathS: key + delim, nType: NTBranch}
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !yield(key, children[key]) {
+				return
+			}
+		}
+	}
+}
+
+// isLeafKey tests if node holds data at a leaf key.
+func (s *trieS[T]) isLeafKey(node *nodeS[T]) bool {
+	return node.hasData() && !node.endsWith(s.Delimiter())
+}
+
+// collectNodes collects the nodes matched under prefix, sorted by
+// their full keys.
+func (s *trieS[T]) collectNodes(prefix string, match func(node *nodeS[T]) bool) (nodes []*nodeS[T]) {
+	base, delim := s.fullKey(prefix), string(s.Delimiter())
+	s.tree.load().walk(0, func(key, _ string, node Node[T]) {
+		nd, ok := node.(*nodeS[T])
+		if !ok || key == "" || !match(nd) {
+			return
+		}
+		if base != "" && key != base && !strings.HasPrefix(key, base+delim) {
+			return
+		}
+		nodes = append(nodes, nd)
+	})
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].pathS < nodes[j].pathS })
+	return
+}
@@ -0,0 +1,59 @@
+package radix
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestTrieS_Iterators(t *testing.T) {
+	trie := newTrie[any]()
+	trie.Set("app.server.port", 80)
+	trie.Set("app.debug", true)
+	trie.Set("app.server.host", "a")
+	trie.Set("app.logging.file.path", "/tmp")
+	trie.Set("app.server-name", "x")
+	trie.Set("zoo", 1)
+
+	assertEqual(t, []string{
+		"app.debug", "app.logging.file.path", "app.server-name", "app.server.host", "app.server.port", "zoo",
+	}, slices.Collect(trie.Keys("")))
+	assertEqual(t, []string{"app.server.host", "app.server.port"}, slices.Collect(trie.Keys("app.server")))
+	assertEqual(t, map[string]any{"app.server.host": "a", "app.server.port": 80}, maps.Collect(trie.Leaves("app.server")))
+
+	var keys []string
+	for key, node := range trie.All("app") {
+		if key == "app.server." {
+			assertTrue(t, node.IsBranch())
+			break
+		}
+		keys = append(keys, key)
+	}
+	assertEqual(t, []string{"app.", "app.debug", "app.logging.file.path", "app.server-name"}, keys)
+
+	children := make(map[string]bool)
+	keys = nil
+	for key, node := range trie.Children("app") {
+		keys, children[key] = append(keys, key), node.IsBranch()
+	}
+	assertEqual(t, []string{"app.debug", "app.logging", "app.server", "app.server-name"}, keys)
+	assertEqual(t, map[string]bool{"app.debug": false, "app.logging": true, "app.server": true, "app.server-name": false}, children)
+	keys = nil
+	for key := range trie.Children("") {
+		keys = append(keys, key)
+	}
+	assertEqual(t, []string{"app", "zoo"}, keys)
+
+	// the snapshot isn't disturbed by the writings in the loop
+	keys = nil
+	for key := range trie.Keys("app.server") {
+		trie.Remove("app.server.port")
+		trie.Set("app.server.tls", true)
+		keys = append(keys, key)
+	}
+	assertEqual(t, []string{"app.server.host", "app.server.port"}, keys)
+	assertEqual(t, []string{"app.server.host", "app.server.tls"}, slices.Collect(trie.Keys("app.server")))
+
+	ns := trie.WithPrefix("app.server")
+	assertEqual(t, []string{"app.server.host", "app.server.tls"}, slices.Collect(ns.Keys("")))
+}
@@ -17,7 +17,7 @@ func (s *trieS[T]) WithPrefix(prefix ...string) (entry Trie[T]) {
 }
 
 func (s *trieS[T]) withPrefixImpl(prefix ...string) (entry *trieS[T]) {
-	return s.dupS(s.root, s.join1(s.prefix, prefix...))
+	return s.dupS(s.tree, NormalizePath(s.join1(s.prefix, prefix...), s.Delimiter()))
 }
 
 // WithPrefixReplaced makes a new Trie instance with a new
@@ -28,30 +28,30 @@ func (s *trieS[T]) WithPrefixReplaced(newPrefix ...string) (entry Trie[T]) {
 }
 
 func (s *trieS[T]) withPrefixReplacedImpl(newPrefix ...string) (entry *trieS[T]) {
-	return s.dupS(s.root, s.Join(newPrefix...))
+	return s.dupS(s.tree, NormalizePath(s.Join(newPrefix...), s.Delimiter()))
 }
 
 // SetPrefix replaces the current prefix setting with the given new value.
 func (s *trieS[T]) SetPrefix(newPrefix ...string) {
-	s.prefix = s.Join(newPrefix...)
+	s.prefix = NormalizePath(s.Join(newPrefix...), s.Delimiter())
 }
 
 func (s *trieS[T]) RecursiveMode() RecusiveMode { return s.recursiveMode }
 
 func (s *trieS[T]) N() (entry Trie[T]) {
-	d := s.dupS(s.root, s.prefix)
+	d := s.dupS(s.tree, s.prefix)
 	d.recursiveMode = RecusiveNone
 	return d
 }
 
 func (s *trieS[T]) R() (entry Trie[T]) {
-	d := s.dupS(s.root, s.prefix)
+	d := s.dupS(s.tree, s.prefix)
 	d.recursiveMode = RecusiveDown
 	return d
 }
 
 func (s *trieS[T]) BR() (entry Trie[T]) {
-	d := s.dupS(s.root, s.prefix)
+	d := s.dupS(s.tree, s.prefix)
 	d.recursiveMode = RecusiveUp
 	return d
 }
@@ -60,7 +60,7 @@ func (s *trieS[T]) loadMap(m map[string]any) (err error) {
 	ec := errors.New()
 	defer ec.Defer(&err)
 	for k, v := range m {
-		s.loadMapByValueType(ec, m, k, v)
+		s.loadMapByValueType(ec, m, EscapeKey(k, s.Delimiter()), v) // a key holds no path
 	}
 	return
 }
@@ -73,7 +73,7 @@ func (s *trieS[T]) loadMapByValueType(ec errors.Error, m map[string]any, k strin
 		buf := make([]byte, 0, len(k)+16)
 		for i, mm := range vv {
 			buf = append(buf, k...)
-			buf = append(buf, byte(s.delimiter))
+			buf = append(buf, byte(s.Delimiter()))
 			buf = strconv.AppendInt(buf, int64(i), 10)
 			ec.Attach(s.withPrefixImpl(string(buf)).loadMap(mm))
 			buf = buf[:0]
@@ -83,10 +83,10 @@ func (s *trieS[T]) loadMapByValueType(ec errors.Error, m map[string]any, k strin
 		for i, mm := range vv {
 			if s.prefix != "" {
 				buf = append(buf, s.prefix...)
-				buf = append(buf, byte(s.delimiter))
+				buf = append(buf, byte(s.Delimiter()))
 			}
 			buf = append(buf, k...)
-			buf = append(buf, byte(s.delimiter))
+			buf = append(buf, byte(s.Delimiter()))
 			buf = strconv.AppendInt(buf, int64(i), 10)
 			s.loadMapByValueType(ec, m, string(buf), mm)
 			buf = buf[:0]
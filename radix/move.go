@@ -0,0 +1,172 @@
+package radix
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// OnMoved is called back by Move and Copy for each node carried,
+// 'from' is the source node and 'to' is the new one at the
+// destination.
+type OnMoved[T any] func(from, to Node[T])
+
+// Move relocates the subtree at src to dst, including the
+// values, descriptions, comments, tags, modified states and the
+// pending TTLs.
+//
+//	err := trie.Move("app.server", "app.servers.default", nil)
+//
+// dst must not exist, and must not be inside src. cb is called
+// back for each node moved after the new tree published, the
+// nodes holding neither a value nor a description, comment or
+// tag are restructured silently.
+func (s *trieS[T]) Move(src, dst string, cb OnMoved[T]) (err error) {
+	return s.relocate("move", src, dst, cb)
+}
+
+// Copy duplicates the subtree at src to dst, like Move, but the
+// source is kept. The pending TTLs are copied with the remaining
+// durations.
+func (s *trieS[T]) Copy(src, dst string, cb OnMoved[T]) (err error) {
+	return s.relocate("copy", src, dst, cb)
+}
+
+type movedS[T any] struct {
+	from, to *nodeS[T]
+}
+
+func (s *trieS[T]) relocate(op, src, dst string, cb OnMoved[T]) (err error) {
+	src, dst = s.fullKey(src), s.fullKey(dst)
+	delim := string(s.Delimiter())
+	switch {
+	case src == "" || dst == "":
+		return errors.New("cannot %s the root, from %q to %q", op, src, dst)
+	case src == dst:
+		return
+	case strings.HasPrefix(dst, src+delim):
+		return errors.New("cannot %s %q into itself, %q", op, src, dst)
+	}
+
+	var moved []movedS[T]
+	if moved, err = s.relocateNodes(src, dst, op == "move"); err != nil {
+		return
+	}
+	if op == "copy" {
+		s.copyTTLs(src, dst)
+	}
+	if cb != nil {
+		for _, m := range moved {
+			cb(m.from, m.to)
+		}
+	}
+	return
+}
+
+// relocateNodes builds and publishes the new tree, and returns the
+// nodes carried.
+func (s *trieS[T]) relocateNodes(src, dst string, move bool) (moved []movedS[T], err error) {
+	delim := string(s.Delimiter())
+	under := func(key, at string) bool { return key == at || strings.HasPrefix(key, at+delim) }
+
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+
+	old := s.tree.load()
+	top, _, partialMatched := s.search(src, nil)
+	if top == nil || (partialMatched && !strings.HasPrefix(top.pathS, src+delim)) {
+		return nil, errors.New("cannot find %q", src).WithErrors(errors.NotFound)
+	}
+
+	var carried, kept []*nodeS[T]
+	top.walk(0, func(key, _ string, node Node[T]) {
+		if nd, ok := node.(*nodeS[T]); ok && (nd.hasData() || nd.attrs.Load() != nil) {
+			if under(key, src) {
+				carried = append(carried, nd)
+			} else {
+				kept = append(kept, nd) // shares the prefix only, such as src+"X"
+			}
+		}
+	})
+	if len(carried) == 0 {
+		return nil, errors.New("cannot find %q", src).WithErrors(errors.NotFound)
+	}
+	var conflicted bool
+	old.walk(0, func(key, _ string, node Node[T]) {
+		if nd, ok := node.(*nodeS[T]); ok && under(key, dst) && (nd.hasData() || nd.attrs.Load() != nil) {
+			conflicted = true
+		}
+	})
+	if conflicted {
+		return nil, errors.New("cannot relocate %q to %q, which exists", src, dst)
+	}
+
+	root := old
+	if move {
+		root, _ = old.without(top)
+		for _, nd := range kept {
+			root, _ = root.insertCopy(nd.pathS, nd)
+		}
+	}
+	for _, nd := range carried {
+		var n *nodeS[T]
+		root, n = root.insertCopy(dst+nd.pathS[len(src):], nd)
+		moved = append(moved, movedS[T]{from: nd, to: n})
+	}
+	if move {
+		s.tree.rekeyTTLJobs(src, dst, delim)
+	}
+	s.tree.publish(root)
+	return
+}
+
+// insertCopy inserts the value of 'from' at key, and copies the
+// description, comment, tag and modified state.
+func (s *nodeS[T]) insertCopy(key string, from *nodeS[T]) (repl, node *nodeS[T]) {
+	repl, node, _ = s.insert(key, key, from.Data())
+	node.attrs.Store(from.attrs.Load())
+	flags := from.flags()
+	node.updateFlags(func(nt nodeType) nodeType {
+		if flags&NTData == 0 {
+			nt &^= NTData | NTMask // a branch holds a comment or tag only
+		}
+		return nt | flags&NTModified
+	})
+	return
+}
+
+// rekeyTTLJobs moves the pending TTLs under src to dst.
+func (s *treeS[T]) rekeyTTLJobs(src, dst, delim string) {
+	s.ttlMu.Lock()
+	defer s.ttlMu.Unlock()
+	for job := range s.ttlJobs {
+		if job.key == src || strings.HasPrefix(job.key, src+delim) {
+			job.key = dst + job.key[len(src):]
+		}
+	}
+}
+
+// copyTTLs adds the pending TTLs under src to dst, with the
+// remaining durations.
+func (s *trieS[T]) copyTTLs(src, dst string) {
+	delim := string(s.Delimiter())
+	var jobs []ttljobS[T]
+	s.tree.ttlMu.Lock()
+	for job := range s.tree.ttlJobs {
+		if job.key == src || strings.HasPrefix(job.key, src+delim) {
+			jobs = append(jobs, ttljobS[T]{key: dst + job.key[len(src):], duration: time.Until(job.deadline), action: job.action})
+		}
+	}
+	s.tree.ttlMu.Unlock()
+
+	if len(jobs) == 0 {
+		return
+	}
+	if s.ttlpresent.CompareAndSwap(0, 1) {
+		s.ttls = newttls(s)
+	}
+	for _, job := range jobs {
+		s.ttls.adder <- job
+	}
+}
@@ -0,0 +1,102 @@
+package radix
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+func TestTrieS_Move(t *testing.T) {
+	trie := newTrie[any]()
+	trie.Set("app.server.port", 80)
+	trie.Set("app.server.host", "localhost")
+	trie.Set("app.serverX", "kept")
+	trie.Set("app.sx", 1)
+	assertTrue(t, trie.SetComment("app.server.port", "desc", "remarks"))
+	assertTrue(t, trie.SetTag("app.server.host", "tag"))
+	node, _, _, _ := trie.Locate("app.server.port", nil)
+	node.SetModified(true)
+
+	var moved [][2]string
+	err := trie.Move("app.server", "app.srv", func(from, to Node[any]) {
+		moved = append(moved, [2]string{from.Key(), to.Key()})
+	})
+	assertTrue(t, err == nil, err)
+	slices.SortFunc(moved, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) })
+	assertEqual(t, [][2]string{{"app.server.host", "app.srv.host"}, {"app.server.port", "app.srv.port"}}, moved)
+
+	assertFalse(t, trie.Has("app.server.port"))
+	assertEqual(t, 80, trie.MustGet("app.srv.port"))
+	assertEqual(t, "localhost", trie.MustGet("app.srv.host"))
+	assertEqual(t, "kept", trie.MustGet("app.serverX"))
+	assertEqual(t, 1, trie.MustGet("app.sx"))
+	assertEqual(t, "desc", trie.MustGetDesc("app.srv.port"))
+	assertEqual(t, "remarks", trie.MustGetComment("app.srv.port"))
+	assertEqual(t, "tag", trie.MustGetTag("app.srv.host"))
+	node, _, _, _ = trie.Locate("app.srv.port", nil)
+	assertTrue(t, node.Modified())
+	node, _, _, _ = trie.Locate("app.srv.host", nil)
+	assertFalse(t, node.Modified())
+
+	err = trie.Move("app.sx", "app.srv.sx", nil)
+	assertTrue(t, err == nil, err)
+	assertEqual(t, 1, trie.MustGet("app.srv.sx"))
+	assertFalse(t, trie.Has("app.sx"))
+
+	err = trie.Move("app.none", "app.x", nil)
+	assertTrue(t, errors.Is(err, errors.NotFound), err)
+	assertTrue(t, trie.Move("app.srv", "app.srv.inner", nil) != nil, "into itself")
+	assertTrue(t, trie.Move("app.srv.port", "app.serverX", nil) != nil, "exists")
+	assertTrue(t, trie.Move("app.srv", "", nil) != nil, "root")
+	assertEqual(t, 80, trie.MustGet("app.srv.port"))
+
+	sub := trie.WithPrefix("app")
+	err = sub.Move("srv.host", `hosts."a.b"`, nil)
+	assertTrue(t, err == nil, err)
+	assertEqual(t, "localhost", trie.MustGet(`app.hosts.a\.b`))
+}
+
+func TestTrieS_Copy(t *testing.T) {
+	trie := newTrie[any]()
+	trie.Set("app.server.port", 80)
+	trie.Set("app.server.tls.cert", "a.pem")
+	assertTrue(t, trie.SetComment("app.server.tls.cert", "", "remarks"))
+
+	err := trie.Copy("app.server", "app.backup", nil)
+	assertTrue(t, err == nil, err)
+	assertEqual(t, 80, trie.MustGet("app.server.port"))
+	assertEqual(t, 80, trie.MustGet("app.backup.port"))
+	assertEqual(t, "remarks", trie.MustGetComment("app.backup.tls.cert"))
+
+	trie.Set("app.backup.port", 8080)
+	assertEqual(t, 80, trie.MustGet("app.server.port"), "the copy is independent")
+	assertTrue(t, trie.Copy("app.server", "app.backup", nil) != nil, "exists")
+}
+
+func TestTrieS_MoveTTL(t *testing.T) {
+	trie := newTrie[any]()
+	defer trie.Close()
+	trie.Set("app.session.token", "abc")
+	trie.Set("app.session.user", "joe")
+
+	var rang atomic.Int32
+	trie.SetTTL("app.session.token", 200*time.Millisecond, func(s *TTL[any], nd Node[any]) {
+		rang.Add(1)
+	})
+	time.Sleep(50 * time.Millisecond) // the job is registered asynchronously
+
+	assertTrue(t, trie.Move("app.session", "app.sess", nil) == nil)
+	assertTrue(t, trie.Copy("app.sess", "app.copied", nil) == nil)
+	trie.Set("app.sess.extra", 1) // copies the nodes on the path
+
+	time.Sleep(350 * time.Millisecond)
+	assertEqual(t, int32(2), rang.Load())
+	assertEqual(t, nil, trie.MustGet("app.sess.token"))
+	assertEqual(t, nil, trie.MustGet("app.copied.token"))
+	assertEqual(t, "joe", trie.MustGet("app.sess.user"))
+	assertFalse(t, trie.Has("app.session.token"))
+}
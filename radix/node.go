@@ -3,14 +3,15 @@ package radix
 import (
 	"fmt"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/hedzr/evendeep"
 	logz "github.com/hedzr/logg/slog"
 )
 
-type nodeType int
+type nodeType int32
 
 const (
 	NTBranch   nodeType    = iota // non-leaf nodes in a tree
@@ -20,53 +21,97 @@ const (
 	NTMask     = NTLeaf           // mask for checking if it's a branch or leaf
 )
 
+// nodeS is a node of the tree.
+//
+// A published node, which is reachable from the root, is never
+// changed structurally: the writers copy the changed path and
+// publish a new root, see treeS. Its attrs and nType fields are
+// updated atomically in place, so the readers take no locks.
 type nodeS[T any] struct {
-	path        []rune // path fragment for this node.
-	pathS       string // full path for performance, on a node we want to retrieve its full path as Key()
-	children    []*nodeS[T]
+	path     string // path fragment for this node.
+	pathS    string // full path for performance, on a node we want to retrieve its full path as Key()
+	children []*nodeS[T]
+	attrs    atomic.Pointer[attrsS[T]] // data, description, comment and tag, nil for zero values
+	nType    nodeType                  // accessed atomically, see flags
+}
+
+// attrsS holds the attributes of a node. It's immutable once
+// stored into a node, a setter replaces it as a whole.
+type attrsS[T any] struct {
 	data        T
 	description string
 	comment     string
 	tag         any
-	nType       nodeType
-	rw          *sync.RWMutex
 }
 
 var _ Node[any] = (*nodeS[any])(nil) // assertion helper
 
 type Extractor func(outputPtr any, defaultValue ...any) (err error) // data field extractor
 
-func (s *nodeS[T]) isBranch() bool      { return s.nType&NTMask == NTBranch } // branch node, not leaf node
-func (s *nodeS[T]) hasData() bool       { return s.nType&NTData != 0 }        // has data?
-func (s *nodeS[T]) isEmpty() bool       { return s.nType&NTData == 0 }        // no data?
-func (s *nodeS[T]) Modified() bool      { return s.nType&NTModified != 0 }    // modification state
-func (s *nodeS[T]) Description() string { return s.description }              // description field
-func (s *nodeS[T]) Comment() string     { return s.comment }                  // comment field
-func (s *nodeS[T]) Tag() any            { return s.tag }                      // tag field
-func (s *nodeS[T]) Key() string         { return s.pathS }                    // key field is the full path of this node
-func (s *nodeS[T]) KeyPiece() string    { return string(s.path) }             // key piece field for this node
-func (s *nodeS[T]) IsLeaf() bool        { return s.nType&NTMask == NTLeaf }   // leaf node?
-func (s *nodeS[T]) IsBranch() bool      { return s.nType&NTMask == NTBranch } // branch node?
-func (s *nodeS[T]) HasData() bool       { return s.nType&NTData != 0 }        //nolint:revive //data field is valid?
-func (s *nodeS[T]) Empty() bool         { return s.nType&NTData == 0 }        //nolint:revive //data field is empty?
-
-func (s *nodeS[T]) readLockFor(cb func(*nodeS[T])) { //nolint:revive
-	if s.rw != nil {
-		s.rw.RLock()
-		cb(s)
-		s.rw.RUnlock()
-	} else {
-		cb(s)
+func (s *nodeS[T]) isBranch() bool   { return s.flags()&NTMask == NTBranch } // branch node, not leaf node
+func (s *nodeS[T]) hasData() bool    { return s.flags()&NTData != 0 }        // has data?
+func (s *nodeS[T]) isEmpty() bool    { return s.flags()&NTData == 0 }        // no data?
+func (s *nodeS[T]) Modified() bool   { return s.flags()&NTModified != 0 }    // modification state
+func (s *nodeS[T]) Key() string      { return s.pathS }                      // key field is the full path of this node
+func (s *nodeS[T]) KeyPiece() string { return s.path }                       // key piece field for this node
+func (s *nodeS[T]) IsLeaf() bool     { return s.flags()&NTMask == NTLeaf }   // leaf node?
+func (s *nodeS[T]) IsBranch() bool   { return s.flags()&NTMask == NTBranch } // branch node?
+func (s *nodeS[T]) HasData() bool    { return s.flags()&NTData != 0 }        //nolint:revive //data field is valid?
+func (s *nodeS[T]) Empty() bool      { return s.flags()&NTData == 0 }        //nolint:revive //data field is empty?
+
+// flags loads the nType field.
+func (s *nodeS[T]) flags() nodeType {
+	return nodeType(atomic.LoadInt32((*int32)(&s.nType)))
+}
+
+// updateFlags replaces the nType field with fn(nType) atomically.
+func (s *nodeS[T]) updateFlags(fn func(nt nodeType) nodeType) {
+	p := (*int32)(&s.nType)
+	for {
+		old := atomic.LoadInt32(p)
+		if atomic.CompareAndSwapInt32(p, old, int32(fn(nodeType(old)))) {
+			return
+		}
 	}
 }
 
-func (s *nodeS[T]) lockFor(cb func(*nodeS[T])) { //nolint:revive
-	if s.rw != nil {
-		s.rw.Lock()
-		cb(s)
-		s.rw.Unlock()
-	} else {
-		cb(s)
+// Description returns the description field.
+func (s *nodeS[T]) Description() (desc string) {
+	if a := s.attrs.Load(); a != nil {
+		desc = a.description
+	}
+	return
+}
+
+// Comment returns the comment field.
+func (s *nodeS[T]) Comment() (comment string) {
+	if a := s.attrs.Load(); a != nil {
+		comment = a.comment
+	}
+	return
+}
+
+// Tag returns the tag field.
+func (s *nodeS[T]) Tag() (tag any) {
+	if a := s.attrs.Load(); a != nil {
+		tag = a.tag
+	}
+	return
+}
+
+// updateAttrs replaces the attributes with a copy modified by fn
+// atomically.
+func (s *nodeS[T]) updateAttrs(fn func(a *attrsS[T])) {
+	for {
+		old := s.attrs.Load()
+		a := new(attrsS[T])
+		if old != nil {
+			*a = *old
+		}
+		fn(a)
+		if s.attrs.CompareAndSwap(old, a) {
+			return
+		}
 	}
 }
 
@@ -75,46 +120,36 @@ func (s *nodeS[T]) lockFor(cb func(*nodeS[T])) { //nolint:revive
 // To clear the state, using ResetModified;
 // Or flip the state with ToggleModified.
 func (s *nodeS[T]) SetModified(b bool) { //nolint:revive
-	if b {
-		s.nType |= NTModified
-	} else {
-		s.nType &= ^NTModified
-	}
+	s.updateFlags(func(nt nodeType) nodeType {
+		if b {
+			return nt | NTModified
+		}
+		return nt &^ NTModified
+	})
 }
 
 // ToggleModified flips the modified state.
 func (s *nodeS[T]) ToggleModified() {
-	s.nType ^= NTModified
-	// if s.Modified() {
-	// 	s.nType &= ^NTModified
-	// } else {
-	// 	s.nType |= NTModified
-	// }
+	s.updateFlags(func(nt nodeType) nodeType { return nt ^ NTModified })
 }
 
 // ResetModified clears the modified state.
 func (s *nodeS[T]) ResetModified() {
-	s.nType &= ^NTModified
+	s.updateFlags(func(nt nodeType) nodeType { return nt &^ NTModified })
 }
 
 // Data returns the Data field of a node.
 func (s *nodeS[T]) Data() (data T) {
-	// if !s.isBranch() {
-	s.readLockFor(func(s *nodeS[T]) {
-		data = s.data
-	})
-	// }
+	if a := s.attrs.Load(); a != nil {
+		data = a.data
+	}
 	return
 }
 
 // SetData sets the Data field of a node.
 func (s *nodeS[T]) SetData(data T) {
-	// if !s.isBranch() {
-	s.lockFor(func(s *nodeS[T]) {
-		s.data = data
-		s.nType |= NTData
-	})
-	// }
+	s.updateAttrs(func(a *attrsS[T]) { a.data = data })
+	s.updateFlags(func(nt nodeType) nodeType { return nt | NTData })
 }
 
 func (s *nodeS[T]) SetTTL(duration time.Duration, trie Trie[T], cb OnTTLRinging[T]) {
@@ -125,39 +160,33 @@ func (s *nodeS[T]) SetTTL(duration time.Duration, trie Trie[T], cb OnTTLRinging[
 //
 // Internally, SetEmpty sets Data field to zero value, and Tag field to nil, since v1.2.7+.
 func (s *nodeS[T]) SetEmpty() {
-	// if !s.isBranch() {
-	// s.nType &= ^NTData
-	s.lockFor(func(s *nodeS[T]) {
+	s.updateAttrs(func(a *attrsS[T]) {
 		var t T
-		s.data = t
-		s.tag = nil
+		a.data, a.tag = t, nil
 	})
-	// }
 }
 
 // SetComment sets the Description and Comment field.
 func (s *nodeS[T]) SetComment(desc, comment string) { //nolint:revive
-	// if s.isBranch() {
-	// 	return
-	// }
-	s.description, s.comment = desc, comment
+	s.updateAttrs(func(a *attrsS[T]) { a.description, a.comment = desc, comment })
 }
 
 // SetTag sets the Tag field.
 //
 // You may save any value into a Tag field.
 func (s *nodeS[T]) SetTag(tag any) { //nolint:revive
-	// if s.isBranch() {
-	// 	return
-	// }
-	s.tag = tag
+	s.updateAttrs(func(a *attrsS[T]) { a.tag = tag })
 }
 
 func (s *nodeS[T]) StartsWith(ch rune) bool { //nolint:revive
-	if len(s.path) == 0 {
+	if s.path == "" {
 		return false
 	}
-	return s.path[0] == ch
+	if ch < utf8.RuneSelf {
+		return s.path[0] == byte(ch)
+	}
+	r, _ := utf8.DecodeRuneInString(s.path)
+	return r == ch
 }
 
 func (s *nodeS[T]) EndsWith(ch rune) bool { //nolint:revive
@@ -165,61 +194,113 @@ func (s *nodeS[T]) EndsWith(ch rune) bool { //nolint:revive
 	if kl == 0 {
 		return false
 	}
-	return s.path[kl-1] == ch
+	if ch < utf8.RuneSelf {
+		return s.path[kl-1] == byte(ch)
+	}
+	r, _ := utf8.DecodeLastRuneInString(s.path)
+	return r == ch
 }
 
+// endsWith tests if the node ends with the delimiter ch, which
+// isn't escaped.
 func (s *nodeS[T]) endsWith(ch rune) bool { //nolint:revive
-	kl := len(s.path)
-	if kl == 0 {
+	if !s.EndsWith(ch) {
 		return false
 	}
-	return s.path[kl-1] == ch
+	pos := len(s.pathS) - utf8.RuneLen(ch) - 1
+	return pos < 0 || s.pathS[pos] != escapeChar
 }
 
-// func (s *nodeS[T]) endsWithLite(ch rune) bool { //nolint:revive,unused
-// 	return s.path[len(s.path)-1] == ch
-// }
+// clone makes a shallow copy of s for a writer, the children are
+// shared with s.
+func (s *nodeS[T]) clone() (node *nodeS[T]) {
+	node = &nodeS[T]{path: s.path, pathS: s.pathS, nType: s.flags()}
+	if len(s.children) > 0 {
+		node.children = append(make([]*nodeS[T], 0, len(s.children)+1), s.children...)
+	}
+	node.attrs.Store(s.attrs.Load())
+	return
+}
 
-func (s *nodeS[T]) remove(item *nodeS[T]) (removed bool) { //nolint:revive
+// without returns a copy of s without the descendant item. The
+// ancestors of item are copied, the other subtrees are shared.
+func (s *nodeS[T]) without(item *nodeS[T]) (repl *nodeS[T], removed bool) { //nolint:revive
 	if item == nil {
-		return
+		return s, false
 	}
-	// remove a child
 	for i, c := range s.children {
 		if c == item {
-			removed, s.children = true, append(s.children[:i], s.children[i+1:]...)
-			break
+			repl = s.clone()
+			repl.children = append(repl.children[:i], repl.children[i+1:]...)
+			return repl, true
+		}
+		if strings.HasPrefix(item.pathS, c.pathS) {
+			if nc, ok := c.without(item); ok {
+				repl = s.clone()
+				repl.children[i] = nc
+				return repl, true
+			}
 		}
 	}
-	return
+	return s, false
+}
+
+// replacing returns a copy of s with the descendant item replaced
+// by with. The ancestors of item are copied, the other subtrees
+// are shared.
+func (s *nodeS[T]) replacing(item, with *nodeS[T]) (repl *nodeS[T], replaced bool) { //nolint:revive
+	if s == item {
+		return with, true
+	}
+	for i, c := range s.children {
+		if c == item || strings.HasPrefix(item.pathS, c.pathS) {
+			if nc, ok := c.replacing(item, with); ok {
+				repl = s.clone()
+				repl.children[i] = nc
+				return repl, true
+			}
+		}
+	}
+	return s, false
 }
 
-func (s *nodeS[T]) findCommonPrefixLength(word []rune) (length int) {
+func (s *nodeS[T]) findCommonPrefixLength(word string) (length int) {
 	ml := min(len(word), len(s.path))
 	for length < ml && word[length] == s.path[length] {
 		length++
 	}
+	// never split a multibyte rune
+	for length > 0 && length < len(s.path) && !utf8.RuneStart(s.path[length]) {
+		length--
+	}
 	return
 }
 
-func (s *nodeS[T]) insertInternal(word []rune, fullPath string, data T, trie *trieS[T], cb OnSetEx[T]) (node *nodeS[T], oldData any) {
-	base, ourLen, wordLen := s, len(s.path), len(word)
+// insertInternal inserts word, the rest part of fullPath, into the
+// subtree s. s may be published, so it's never changed structurally
+// in place: the changed path is copied, and repl, the replacement
+// of s, is returned. The node hit is copied too, so a published
+// root never sees the new data.
+func (s *nodeS[T]) insertInternal(word, fullPath string, data T) (repl, node *nodeS[T], oldData any) {
+	ourLen, wordLen := len(s.path), len(word)
 	if ourLen == 0 {
 		if wordLen > 0 && len(s.children) == 0 {
-			node = base.insertAsLeaf(word, fullPath, data)
+			repl = s.clone()
+			node = repl.insertAsLeaf(word, fullPath, data)
 			return
 		}
 	}
 
-	// var newNode *nodeS[T]
 	var cpl int
 	if ourLen > 0 && wordLen > 0 {
-		cpl = base.findCommonPrefixLength(word)
+		cpl = s.findCommonPrefixLength(word)
 	}
 
+	repl = s
 	if cpl < ourLen {
 		// eg: insert 'apple' into 'appZ', or insert 'appZ' into 'apple'
-		base.split(cpl, word) // split this as 'app' and 'Z'/'le'
+		repl = s.clone()
+		repl.split(cpl, word) // split this as 'app' and 'Z'/'le'
 		// eg2: insert '/app/:client/tokens' into '/app/:client/tokens/:token',
 	}
 
@@ -228,122 +309,123 @@ func (s *nodeS[T]) insertInternal(word []rune, fullPath string, data T, trie *tr
 		if cpl > 0 {
 			word = word[cpl:] //nolint:revive
 		}
-		matched, child := base.matchChildren(word)
-		if matched {
-			var n Node[T]
-			n, oldData = child.insert(word, fullPath, data, trie, cb)
-			if nn, ok := n.(*nodeS[T]); ok {
-				node = nn
-				if cb != nil {
-					cb(fullPath, oldData, node, trie)
+		if i, child := repl.matchChildren(word); child != nil {
+			var nc *nodeS[T]
+			nc, node, oldData = child.insert(word, fullPath, data)
+			if nc != child {
+				if repl == s {
+					repl = s.clone()
 				}
+				repl.children[i] = nc
 			}
 		} else {
-			node = base.insertAsLeaf(word, fullPath, data)
-			if cb != nil {
-				cb(fullPath, nil, node, trie)
+			if repl == s {
+				repl = s.clone()
 			}
+			node = repl.insertAsLeaf(word, fullPath, data)
 		}
-	} else {
-		// hit this node,
-		base.nType |= NTData
-		node, oldData, base.data = base, base.data, data
-		if cb != nil {
-			cb(fullPath, oldData, node, trie)
-		}
+		return
+	}
+
+	// hit this node,
+	if repl == s {
+		repl = s.clone()
 	}
+	node, oldData = repl, s.Data()
+	node.SetData(data)
 	return
 }
 
-func (s *nodeS[T]) split(pos int, word []rune) (newNode *nodeS[T]) {
-	tip("[store/radix] split original path %q by word %q at pos %d", string(s.path), string(word), pos)
-
-	// origPath, origPathS := s.path, s.pathS
-	// if assertEnabled {
-	// 	defer func() {
-	// 		origPath, origPathS = []rune(origPathS), string(origPath)
-	// 	}()
-	// }
+// split splits s at pos, s must be a private copy.
+func (s *nodeS[T]) split(pos int, word string) (newNode *nodeS[T]) {
+	tip("[store/radix] split original path %q by word %q at pos %d", s.path, word, pos)
 
 	d := len(s.path) - pos
-	_ = word
 
 	newNode = &nodeS[T]{
-		path:        s.path[pos:],
-		pathS:       s.pathS, // [pos:], // s.pathS[len(s.pathS)-d:],
-		children:    s.children,
-		data:        s.data,
-		description: s.description,
-		comment:     s.comment,
-		tag:         s.tag,
-		nType:       s.nType,
+		path:     s.path[pos:],
+		pathS:    s.pathS, // [pos:], // s.pathS[len(s.pathS)-d:],
+		children: s.children,
+		nType:    s.nType,
 	}
-	assert(strings.HasSuffix(newNode.pathS, string(newNode.path)), "newNode: pathS should end with path")
+	newNode.attrs.Store(s.attrs.Load())
+	assert(strings.HasSuffix(newNode.pathS, newNode.path), "newNode: pathS should end with path")
 
 	s.path = s.path[:pos]
 	s.pathS = s.pathS[:len(s.pathS)-d] // s.pathS[:pos] //
 	s.children = []*nodeS[T]{newNode}
 	s.nType = NTBranch
-	s.description = ""
-	s.comment = ""
-	s.tag = nil
-	var t T
-	s.data = t
-	assert(strings.HasSuffix(s.pathS, string(s.path)), "parentNode: pathS(%q) should end with path(%q)", s.pathS, string(s.path))
+	s.attrs.Store(nil)
+	assert(strings.HasSuffix(s.pathS, s.path), "parentNode: pathS(%q) should end with path(%q)", s.pathS, s.path)
 	return
 }
 
-func (s *nodeS[T]) insertAsLeaf(word []rune, fullPath string, data T) (newNode *nodeS[T]) {
-	newNode = &nodeS[T]{
+// insertAsLeaf appends a new leaf to s, s must be a private copy.
+func (s *nodeS[T]) insertAsLeaf(word, fullPath string, data T) (newNode *nodeS[T]) {
+	newNode = newLeafNode(word, fullPath, data)
+	assert(strings.HasSuffix(newNode.pathS, newNode.path), "newNode: pathS should end with path")
+	s.children = append(s.children, newNode)
+	return
+}
+
+func newLeafNode[T any](word, fullPath string, data T) (node *nodeS[T]) {
+	node = &nodeS[T]{
 		path:  word,
 		pathS: fullPath,
 		nType: NTLeaf | NTData,
-		data:  data,
 	}
-	assert(strings.HasSuffix(newNode.pathS, string(newNode.path)), "newNode: pathS should end with path")
-	s.children = append(s.children, newNode)
+	node.attrs.Store(&attrsS[T]{data: data})
 	return
 }
 
-func (s *nodeS[T]) matchChildren(word []rune) (matched bool, child *nodeS[T]) {
-	for _, child = range s.children {
+func (s *nodeS[T]) matchChildren(word string) (index int, child *nodeS[T]) {
+	for i, c := range s.children {
 		// not a bug, when we need to compare the given word
 		// with each of children, just the first char need
 		// to be tested, since only have one child will
 		// own the testing prefix, or only one child
 		// have the part of the testing prefix.
-		if child.path[0] == word[0] {
-			matched = true
-			break
+		if hasSameFirstRune(c.path, word) {
+			return i, c
 		}
 	}
 	return
 }
 
-func extractor(from, to int, src, piece []rune, delimiter rune) (ret string, pos int, end bool) {
-	j := 0
-	for i := from; i < to; j++ {
-		if src[i] == delimiter {
-			ret, pos = string(piece[:j]), i
-			break
-		}
-		piece[j] = src[i]
-		i++
-		if i == to {
-			ret, pos, end = string(piece[:j+1]), i, true
-		}
+// hasSameFirstRune tests if a and b, both are not empty, start with
+// the same rune.
+func hasSameFirstRune(a, b string) bool {
+	if a[0] != b[0] {
+		return false
 	}
-	return
+	if a[0] < utf8.RuneSelf {
+		return true
+	}
+	_, n := utf8.DecodeRuneInString(a)
+	return len(b) >= n && a[:n] == b[:n]
+}
+
+func extractor(from, to int, src, delimiter string) (ret string, pos int, end bool) {
+	if from >= to {
+		return
+	}
+	if i := strings.Index(src[from:to], delimiter); i >= 0 {
+		return src[from : from+i], from + i, false
+	}
+	return src[from:to], to, true
 }
 
 // search node by dotted path key with RecursiveMode.
-func (s *nodeS[T]) search(mctx *matchCtx, word []rune, lastRuneIsDelimiter bool, parentNode *nodeS[T], kvpair KVPair) (matched, partialMatched bool, child, parent *nodeS[T]) { //nolint:revive
+func (s *nodeS[T]) search(mctx *matchCtx, word string, lastRuneIsDelimiter bool, parentNode *nodeS[T], kvpair KVPair) (matched, partialMatched bool, child, parent *nodeS[T]) { //nolint:revive
 	matched, partialMatched, child, parent = s.matchR(mctx, word, lastRuneIsDelimiter, parentNode, kvpair)
 	return
 }
 
 // matchR matches a path by walking child nodes recursively.
-func (s *nodeS[T]) matchR(mctx *matchCtx, word []rune, lastRuneIsDelimiter bool, parentNode *nodeS[T], kvpair KVPair) (matched, partialMatched bool, child, parent *nodeS[T]) { //nolint:revive
+//
+// The path is matched byte by byte, a multibyte delimiter is
+// recognized by its last byte.
+func (s *nodeS[T]) matchR(mctx *matchCtx, word string, lastRuneIsDelimiter bool, parentNode *nodeS[T], kvpair KVPair) (matched, partialMatched bool, child, parent *nodeS[T]) { //nolint:revive
 	wl, l := len(word), len(s.path)
 	if wl == 0 {
 		return true, false, s, parentNode
@@ -351,12 +433,12 @@ func (s *nodeS[T]) matchR(mctx *matchCtx, word []rune, lastRuneIsDelimiter bool,
 
 	// dm: delimiter just matched?
 	// base: the working node ptr
-	base, srcMatchedL, dstMatchedL, minL, maxL := s, 0, 0, min(l, wl), max(l, wl)
+	base, srcMatchedL, dstMatchedL, minL := s, 0, 0, min(l, wl)
 masterLoop:
 	for ; srcMatchedL < minL; srcMatchedL++ {
 		ch := base.path[srcMatchedL]
 		if ch1 := word[srcMatchedL]; ch == ch1 {
-			lastRuneIsDelimiter = ch == mctx.delimiter
+			lastRuneIsDelimiter = mctx.delimiterAt(base.path, srcMatchedL)
 			continue // first comparing loop, assume the index to base.path and word are both identical.
 		}
 
@@ -371,16 +453,15 @@ masterLoop:
 				if lastRuneIsDelimiter {
 					// matching "/*filepath"
 					if ch == '*' {
-						piece := make([]rune, maxL)
-						id, srcMatchedL, srcEnd = extractor(srcMatchedL+1, l, base.path, piece, mctx.delimiter)
+						id, srcMatchedL, srcEnd = extractor(srcMatchedL+1, l, base.path, mctx.delim)
 						if !srcEnd {
 							logz.Warn("[matchR] invalid wildcard matching rule, it can only at end of the rule", "id", id, "srcMatchedL", srcMatchedL)
 						}
-						val, dstMatchedL, dstEnd = string(word[dstMatchedL:]), wl, true
+						val, dstMatchedL, dstEnd = word[dstMatchedL:], wl, true
 						if kvpair != nil {
 							kvpair[id] = val
 						}
-						logz.Verbose("[matchR] ident matched", "ident", id, "val", val, "key-path", base.pathS, "matching", string(word))
+						logz.Verbose("[matchR] ident matched", "ident", id, "val", val, "key-path", base.pathS, "matching", word)
 						// break masterLoop
 						matched, child, parent = true, base, parentNode
 						return
@@ -389,10 +470,9 @@ masterLoop:
 					if ch == ':' {
 						// delimiter+':'+ident? | eg, matching source word "/hello/bob" on a trie-path pattern "/hello/:name"
 
-						piece := make([]rune, maxL)
-						id, srcMatchedL, srcEnd = extractor(srcMatchedL+1, l, base.path, piece, mctx.delimiter)
-						val, dstMatchedL, dstEnd = extractor(dstMatchedL, wl, word, piece, mctx.delimiter)
-						// logz.Verbose("[matchR] ident matched", "ident", id, "val", val, "key-path", base.pathS, "matching", string(word))
+						id, srcMatchedL, srcEnd = extractor(srcMatchedL+1, l, base.path, mctx.delim)
+						val, dstMatchedL, dstEnd = extractor(dstMatchedL, wl, word, mctx.delim)
+						// logz.Verbose("[matchR] ident matched", "ident", id, "val", val, "key-path", base.pathS, "matching", word)
 						// _, _, _, _, _ = dm, srcEnd, dstEnd, srcMatchedL, dstMatchedL
 
 						if kvpair != nil {
@@ -410,7 +490,7 @@ masterLoop:
 							for ; srcMatchedL < l && dstMatchedL < wl; srcMatchedL++ {
 								ch = base.path[srcMatchedL]
 								if ch1 := word[dstMatchedL]; ch == ch1 {
-									lastRuneIsDelimiter = ch == mctx.delimiter
+									lastRuneIsDelimiter = mctx.delimiterAt(base.path, srcMatchedL)
 									dstMatchedL++
 									continue
 								}
@@ -439,7 +519,7 @@ masterLoop:
 		}
 	}
 
-	if srcMatchedL == l-1 && base.path[srcMatchedL] == mctx.delimiter {
+	if srcMatchedL == l-len(mctx.delim) && base.path[srcMatchedL:] == mctx.delim {
 		matched, child, parent = true, base, parentNode
 	} else if minL < l && srcMatchedL == minL {
 		partialMatched, child, parent = true, base, parentNode
@@ -489,13 +569,13 @@ func iif[T any](cond bool, tv, fv T) (rv T) {
 
 func (s *nodeS[T]) dumpR(sb *strings.Builder, lvl int, noColor bool) string { //nolint:revive
 	_, _ = sb.WriteString(strings.Repeat("  ", lvl))
-	if len(s.path) == 0 {
+	if s.path == "" {
 		if lvl > 0 {
 			_, _ = sb.WriteString("(nil)\n")
 		}
 	} else {
-		_, _ = sb.WriteString(string(s.path))
-		if width := col1Width - lvl*2 - len(s.path); width > 0 {
+		_, _ = sb.WriteString(s.path)
+		if width := col1Width - lvl*2 - utf8.RuneCountInString(s.path); width > 0 {
 			_, _ = sb.WriteString(strings.Repeat(" ", width))
 		} else {
 			_ = sb.WriteByte(' ')
@@ -519,31 +599,31 @@ func (s *nodeS[T]) dumpR(sb *strings.Builder, lvl int, noColor bool) string { //
 			// }
 		}
 
-		s.readLockFor(func(n *nodeS[T]) {
-			if s.hasData() {
-				_, _ = sb.WriteString(" ")
-				_, _ = sb.WriteString(s.pathS)
-				_, _ = sb.WriteString(" => ")
-				_, _ = sb.WriteString(ColorToDim(fmt.Sprint(s.Data())))
-			}
+		if s.hasData() {
+			_, _ = sb.WriteString(" ")
+			_, _ = sb.WriteString(s.pathS)
+			_, _ = sb.WriteString(" => ")
+			_, _ = sb.WriteString(ColorToDim(fmt.Sprint(s.Data())))
+		}
 
-			if s.comment != "" {
-				_, _ = sb.WriteString(ColorToColor(FgLightGreen, " // "+s.comment))
+		if a := s.attrs.Load(); a != nil {
+			if a.comment != "" {
+				_, _ = sb.WriteString(ColorToColor(FgLightGreen, " // "+a.comment))
 			}
 
-			if s.tag != nil {
+			if a.tag != nil {
 				_, _ = sb.WriteString(" | tag = ")
-				_, _ = sb.WriteString(ColorToColor(FgGreen, fmt.Sprint(s.tag)))
+				_, _ = sb.WriteString(ColorToColor(FgGreen, fmt.Sprint(a.tag)))
 			}
 
-			if s.description != "" {
-				_, _ = sb.WriteString(ColorToColor(FgLightGreen, " ~ "+s.description))
+			if a.description != "" {
+				_, _ = sb.WriteString(ColorToColor(FgLightGreen, " ~ "+a.description))
 			}
+		}
 
-			if !strings.HasSuffix(s.pathS, string(s.path)) {
-				_, _ = fmt.Fprintf(sb, " [WRONG path & pathS: %q / %q]", string(s.path), s.pathS)
-			}
-		})
+		if !strings.HasSuffix(s.pathS, s.path) {
+			_, _ = fmt.Fprintf(sb, " [WRONG path & pathS: %q / %q]", s.path, s.pathS)
+		}
 		_ = sb.WriteByte('\n')
 	}
 
@@ -560,7 +640,7 @@ func (s *nodeS[T]) Dup() (newNode *nodeS[T]) { //nolint:revive
 	newNode = &nodeS[T]{
 		path:  s.path,
 		pathS: s.pathS,
-		nType: s.nType,
+		nType: s.flags(),
 	}
 
 	newNode.children = make([]*nodeS[T], 0, len(s.children))
@@ -571,9 +651,9 @@ func (s *nodeS[T]) Dup() (newNode *nodeS[T]) { //nolint:revive
 	data := evendeep.MakeClone(s.Data())
 	switch z := data.(type) {
 	case *T:
-		newNode.data = *z
+		newNode.attrs.Store(&attrsS[T]{data: *z})
 	case T:
-		newNode.data = z
+		newNode.attrs.Store(&attrsS[T]{data: z})
 	}
 	return
 }
@@ -584,7 +664,7 @@ func (s *nodeS[T]) Walk(cb func(path, fragment string, node Node[T])) { //nolint
 }
 
 func (s *nodeS[T]) walk(level int, cb func(path, fragment string, node Node[T])) { //nolint:revive
-	cb(s.pathS, string(s.path), s)
+	cb(s.pathS, s.path, s)
 	for _, ch := range s.children {
 		ch.walk(level+1, cb)
 	}
@@ -1,6 +1,7 @@
 package radix
 
 import (
+	"iter"
 	"time"
 )
 
@@ -23,6 +24,9 @@ type Trie[T any] interface {
 
 	Merge(pathAt string, data map[string]any) (err error) // advanced operation to Merge hierarchical data
 
+	Move(src, dst string, cb OnMoved[T]) (err error) // relocates a subtree with its metadata and TTLs
+	Copy(src, dst string, cb OnMoved[T]) (err error) // duplicates a subtree with its metadata and TTLs
+
 	StartsWith(path string, r rune) (yes bool) // tests the last path fragment by delimiter
 	EndsWith(path string, r rune) (yes bool)   // tests the last path fragment by delimiter
 
@@ -44,6 +48,9 @@ type Trie[T any] interface {
 	SetEmpty(path string) (oldData any)
 	// Update a node whether it existed or not.
 	Update(path string, cb func(node Node[T], old any))
+	// Modify updates a copy of an existing node by fn, and
+	// publishes it, so the snapshots are not changed.
+	Modify(path string, fn func(node Node[T])) (node Node[T], ok bool)
 
 	// SetTTL sets a ttl timeout for a branch or a leaf node.
 	//
@@ -105,6 +112,7 @@ type Trie[T any] interface {
 	Prefix() string                                         // return current prefix string
 	Delimiter() rune                                        // return current delimiter, generally it's dot ('.')
 	SetDelimiter(delimiter rune)                            // setter. Change it in runtime doesn't update old delimiter inside tree nodes.
+	ChangeDelimiter(delimiter rune) (err error)             // re-keys the whole tree with the new delimiter, and sets it.
 
 	R() (entry Trie[T])  // make a clone with [RecursiveDown] mode
 	BR() (entry Trie[T]) // make a clone with [RecursiveUp] mode
@@ -113,10 +121,47 @@ type Trie[T any] interface {
 
 	// Dup duplicates a new instance from this one. = Clone.
 	Dup() (newTrie *trieS[T]) // a native Clone function
+	// Fork makes a cheap copy sharing the current root, the
+	// writings on either side don't affect the other.
+	Fork() (newTrie Trie[T])
+	// Batch runs fn on an unprefixed fork of the current root,
+	// and publishes it at once if fn returns nil.
+	Batch(fn func(tx Trie[T]) (err error)) (err error)
+
+	// Snapshot takes an immutable copy of the whole tree, which
+	// is the current root, shared with the tree.
+	Snapshot() (snapshot *Snapshot[T])
+	// Restore swaps the root of the tree back to a snapshot.
+	Restore(snapshot *Snapshot[T])
+
+	// SetInterpolation enables or disables the interpolation for
+	// the typed getters, GetM, GetR and To.
+	SetInterpolation(enabled bool)
+	// RegisterResolver adds a resolver for the references like
+	// ${scheme:name}.
+	RegisterResolver(scheme string, resolver Resolver)
+	// RegisterValueResolver adds a resolver for the whole string
+	// values starting with prefix, such as "secret://".
+	RegisterValueResolver(prefix string, resolver Resolver)
+	// Expand resolves the references in text.
+	Expand(text string) (ret string, err error)
 
 	// Walk iterators the whole tree for each node.
 	Walk(path string, cb func(path, fragment string, node Node[T]))
 
+	// All returns an iterator over the nodes under prefix, in
+	// lexicographic order of their full keys.
+	All(prefix string) iter.Seq2[string, Node[T]]
+	// Leaves returns an iterator over the keys and data of the
+	// leaves under prefix.
+	Leaves(prefix string) iter.Seq2[string, T]
+	// Keys returns an iterator over the keys of the leaves under
+	// prefix.
+	Keys(prefix string) iter.Seq[string]
+	// Children returns an iterator over the direct children of
+	// path.
+	Children(path string) iter.Seq2[string, Node[T]]
+
 	String() string               // for log/slog text mode
 	MarshalJSON() ([]byte, error) // for log/slog json mode
 }
@@ -0,0 +1,42 @@
+package radix
+
+// Snapshot is an immutable copy of a Trie taken at a moment.
+//
+// The writings on a Trie never modify a published root, they
+// copy the path to the changed node and swap the root. So a
+// snapshot is just the root at that moment, it shares all of
+// the nodes with the Trie and the other snapshots until they
+// are replaced. See [Trie.Snapshot].
+//
+// The data values are shared with the Trie rather than cloned,
+// so don't modify a map or slice value in place. The setters of
+// a [Node] located from the Trie modify it in place too, use
+// the setters of the Trie instead.
+type Snapshot[T any] struct {
+	root *rootS[T]
+}
+
+// Snapshot takes an immutable copy of the whole tree.
+func (s *trieS[T]) Snapshot() (snapshot *Snapshot[T]) {
+	return &Snapshot[T]{root: s.tree.root.Load()}
+}
+
+// Restore replaces the whole tree with a snapshot, including
+// the Desc, Comment, Tag fields, the modified states and the
+// delimiter.
+//
+// The prefixed views of this Trie see the restored tree too.
+func (s *trieS[T]) Restore(snapshot *Snapshot[T]) {
+	if snapshot == nil {
+		return
+	}
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	s.tree.root.Store(snapshot.root)
+}
+
+// Trie makes a new, writable Trie from the snapshot. The
+// writings on it don't affect the snapshot.
+func (s *Snapshot[T]) Trie() Trie[T] {
+	return &trieS[T]{tree: newTree(s.root.node, s.root.delimiter), interp: newInterp()}
+}
@@ -17,27 +17,86 @@ import (
 
 // NewTrie returns a Trie-tree instance.
 func NewTrie[T any]() *trieS[T] {
-	return &trieS[T]{root: &nodeS[T]{}, delimiter: dotChar}
+	return &trieS[T]{tree: newTree(&nodeS[T]{}, dotChar), interp: newInterp()}
 }
 
 // NewTrieBy returns a Trie-tree instance.
 func NewTrieBy[T any](delimiter rune) *trieS[T] {
-	return &trieS[T]{root: &nodeS[T]{}, delimiter: delimiter}
+	return &trieS[T]{tree: newTree(&nodeS[T]{}, delimiter), interp: newInterp()}
 }
 
 var _ Trie[any] = (*trieS[any])(nil) // assertion helper
 
 func newTrie[T any]() *trieS[T] { //nolint:revive
-	return &trieS[T]{root: &nodeS[T]{}, delimiter: dotChar}
+	return &trieS[T]{tree: newTree(&nodeS[T]{}, dotChar), interp: newInterp()}
 }
 
 type trieS[T any] struct {
-	root          *nodeS[T]
+	tree          *treeS[T] // shared with prefixed views
 	prefix        string
-	delimiter     rune
+	view          bool // made by WithPrefix and alike, see ChangeDelimiter
 	ttlpresent    atomic.Uint32
 	ttls          *TTL[T]
 	recursiveMode RecusiveMode
+	interp        *interpS // shared with prefixed views, see SetInterpolation
+}
+
+// treeS holds the root of a tree.
+//
+// The readers load the root without any locks. The writers,
+// serialized by mu, copy the changed path from the root and
+// publish the new root, so a reader sees a consistent tree.
+type treeS[T any] struct {
+	root atomic.Pointer[rootS[T]]
+	mu   sync.Mutex
+
+	ttlMu   sync.Mutex
+	ttlJobs map[*ttljobS[T]]struct{} // the pending TTLs of all views, see Move
+}
+
+// rootS is a published root. The delimiter of the keys is
+// published with it, so the both are swapped together.
+type rootS[T any] struct {
+	node      *nodeS[T]
+	delimiter rune
+}
+
+func newTree[T any](root *nodeS[T], delimiter rune) (tree *treeS[T]) {
+	tree = &treeS[T]{}
+	tree.root.Store(&rootS[T]{node: root, delimiter: delimiter})
+	return
+}
+
+// load returns the current root.
+func (s *treeS[T]) load() *nodeS[T] { return s.root.Load().node }
+
+// delimiter returns the delimiter of the keys in the current root.
+func (s *treeS[T]) delimiter() rune { return s.root.Load().delimiter }
+
+// publish swaps in the new root, the caller holds mu.
+func (s *treeS[T]) publish(root *nodeS[T]) {
+	s.root.Store(&rootS[T]{node: root, delimiter: s.delimiter()})
+}
+
+func (s *treeS[T]) addTTLJob(job *ttljobS[T]) {
+	s.ttlMu.Lock()
+	defer s.ttlMu.Unlock()
+	if s.ttlJobs == nil {
+		s.ttlJobs = make(map[*ttljobS[T]]struct{})
+	}
+	s.ttlJobs[job] = struct{}{}
+}
+
+func (s *treeS[T]) removeTTLJob(job *ttljobS[T]) {
+	s.ttlMu.Lock()
+	defer s.ttlMu.Unlock()
+	delete(s.ttlJobs, job)
+}
+
+func (s *treeS[T]) ttlJobKey(job *ttljobS[T]) string {
+	s.ttlMu.Lock()
+	defer s.ttlMu.Unlock()
+	return job.key
 }
 
 // RecursiveMode specifies how Must/GetXXX looks up a key
@@ -91,8 +150,12 @@ type wheelS struct {
 
 type wheelAction func(ctx context.Context, w *wheelS)
 
+// ttljobS is a pending TTL. The node is located by key when the
+// timer rings, so the job follows the node after it's copied on
+// writing, or moved to another key.
 type ttljobS[T any] struct {
-	node     *nodeS[T]
+	key      string // guarded by treeS.ttlMu
+	deadline time.Time
 	duration time.Duration
 	action   OnTTLRinging[T]
 }
@@ -130,10 +193,7 @@ func (s *TTL[T]) Close() {
 func (s *TTL[T]) Tree() Trie[T] { return s.treevec[0] }
 
 func (s *TTL[T]) Add(nd *nodeS[T], duration time.Duration, action OnTTLRinging[T]) {
-	if nd.rw == nil {
-		nd.rw = &sync.RWMutex{}
-	}
-	s.adder <- ttljobS[T]{node: nd, duration: duration, action: action}
+	s.adder <- ttljobS[T]{key: nd.pathS, duration: duration, action: action}
 }
 
 func (s *TTL[T]) run() {
@@ -146,27 +206,36 @@ func (s *TTL[T]) run() {
 		}
 
 		// add a new job
+		tree := s.treevec[0].tree
+		j := &job
+		j.deadline = time.Now().Add(job.duration)
+		tree.addTTLJob(j)
 		timer := time.NewTimer(job.duration)
-		go func(timer *time.Timer, job ttljobS[T]) {
+		go func(timer *time.Timer, job *ttljobS[T]) {
 			defer timer.Stop()
-			// defer func() { job.node.rw = nil }()
+			defer tree.removeTTLJob(job)
 			for {
 				select {
 				case <-timer.C:
+					key := tree.ttlJobKey(job)
+					node, _, partialMatched := s.treevec[0].search(key, nil)
+					if node == nil || partialMatched || strings.TrimSuffix(node.pathS, string(s.treevec[0].Delimiter())) != key {
+						return // removed already
+					}
 					if job.action != nil {
-						job.action(s, job.node)
+						job.action(s, node)
 					}
-					if job.node.isBranch() {
-						s.treevec[0].Remove(job.node.pathS)
+					if node.isBranch() {
+						s.treevec[0].removeFull(key)
 					} else {
-						job.node.SetEmpty()
+						s.treevec[0].updateFull(key, (*nodeS[T]).SetEmpty)
 					}
 					return
 				case <-s.done:
 					return
 				}
 			}
-		}(timer, job)
+		}(timer, j)
 	}
 
 	for {
@@ -210,9 +279,7 @@ func (s *trieS[T]) Close() {
 //
 // The returned `state`: 0 assumed no error.
 func (s *trieS[T]) SetTTL(path string, ttl time.Duration, cb OnTTLRinging[T]) (state int) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	node, _, partialMatched := s.search(path, nil)
 	found := node != nil && !partialMatched // && !node.isBranch()
 	state = -1
@@ -241,12 +308,13 @@ func (s *trieS[T]) SetTTLFast(node Node[T], ttl time.Duration, cb OnTTLRinging[T
 //
 
 // dupS for duplicating itself. see also Dup, WithPrefix, WithPrefix & WithPrefixReplaced, withPrefixReplacedImpl.
-func (s *trieS[T]) dupS(root *nodeS[T], prefix string) (newTrie *trieS[T]) { //nolint:revive
+func (s *trieS[T]) dupS(tree *treeS[T], prefix string) (newTrie *trieS[T]) { //nolint:revive
 	newTrie = &trieS[T]{
-		root:          root,
+		tree:          tree,
 		prefix:        prefix,
-		delimiter:     s.delimiter,
+		view:          true,
 		recursiveMode: s.recursiveMode,
+		interp:        s.interp,
 	}
 	if s.ttlpresent.Load() > 0 {
 		newTrie.ttls = s.ttls.dupS()
@@ -260,7 +328,7 @@ func (s *trieS[T]) String() string {
 	_, _ = sb.WriteString("Trie{\"")
 	_, _ = sb.WriteString(s.prefix)
 	_, _ = sb.WriteString("\", delimiter:'")
-	_, _ = sb.WriteRune(s.delimiter)
+	_, _ = sb.WriteRune(s.Delimiter())
 	_, _ = sb.WriteString("', r:")
 	_, _ = sb.WriteString(s.recursiveMode.String())
 	_, _ = sb.WriteString("}")
@@ -272,7 +340,7 @@ func (s *trieS[T]) MarshalJSON() ([]byte, error) {
 	_, _ = sb.WriteString("\"trie\":{\"prefix\":")
 	_, _ = sb.WriteString(strconv.Quote(s.prefix))
 	_, _ = sb.WriteString(",\"delimiter\":\"")
-	_, _ = sb.WriteRune(s.delimiter)
+	_, _ = sb.WriteRune(s.Delimiter())
 	_, _ = sb.WriteString("\", \"recursive-mode\":\"")
 	_, _ = sb.WriteString(s.recursiveMode.String())
 	_, _ = sb.WriteString("\"}")
@@ -329,7 +397,7 @@ func (s *trieS[T]) join1(pre string, args ...string) (ret string) {
 
 	for _, it := range args {
 		if it != "" {
-			_ = bb.WriteByte(byte(s.delimiter))
+			_ = bb.WriteByte(byte(s.Delimiter()))
 			_, _ = bb.WriteString(it)
 			i++
 		}
@@ -353,7 +421,7 @@ func (s *trieS[T]) Join(args ...string) (ret string) {
 		for _, it := range args {
 			if it != "" {
 				if bytes > 0 {
-					_ = bb.WriteByte(byte(s.delimiter))
+					_ = bb.WriteByte(byte(s.Delimiter()))
 				}
 				_, _ = bb.WriteString(it)
 				bytes++ //nolint:revive
@@ -367,7 +435,7 @@ func (s *trieS[T]) Join(args ...string) (ret string) {
 	// for _, it := range args {
 	// 	if it != "" {
 	// 		if i > 0 {
-	// 			_ = bb.WriteByte(byte(s.delimiter))
+	// 			_ = bb.WriteByte(byte(s.Delimiter()))
 	// 		}
 	// 		_, _ = bb.WriteString(it)
 	// 		i++
@@ -376,6 +444,16 @@ func (s *trieS[T]) Join(args ...string) (ret string) {
 	// return bb.String()
 }
 
+// fullKey joins the prefix of the trie and path, the quoted
+// segments in path are normalized, see NormalizePath.
+func (s *trieS[T]) fullKey(path string) string {
+	path = NormalizePath(path, s.Delimiter())
+	if s.prefix != "" {
+		return s.Join(s.prefix, path)
+	}
+	return path
+}
+
 func (s *trieS[T]) Insert(path string, data T) (oldData any) { //nolint:revive
 	_, oldData = s.Set(path, data)
 	return
@@ -386,76 +464,78 @@ func (s *trieS[T]) Insert(path string, data T) (oldData any) { //nolint:revive
 // If the given path cannot be found, a new node will be created at that
 // location so that the new data value can be set into it.
 func (s *trieS[T]) Set(path string, data T) (node Node[T], oldData any) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	if strings.Contains(path, " ") {
 		path = strings.ReplaceAll(path, " ", "-")
 	}
-	return s.root.insert([]rune(path), path, data, s, nil)
+	if IsIndexPath(path, s.Delimiter()) {
+		return s.setIndexed(path, data)
+	}
+	return s.insertFull(path, data)
 }
 
 type OnSetEx[T any] func(path string, oldData any, node Node[T], trie Trie[T])
 
 func (s *trieS[T]) SetEx(path string, data T, cb OnSetEx[T]) (oldData any) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
+	path = s.fullKey(path) //nolint:revive
+	node, oldData := s.insertFull(path, data)
+	if cb != nil {
+		cb(path, oldData, node, s)
 	}
-	_, oldData = s.root.insert([]rune(path), path, data, s, cb)
 	return
 }
 
 // SetNode sets the all node fields at once.
 func (s *trieS[T]) SetNode(path string, data T, tag any, descriptionAndComments ...string) (ret Node[T], oldData any) { //nolint:revive
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
-	node, old := s.root.insertInternal([]rune(path), path, data, s, nil)
-	switch len(descriptionAndComments) {
-	case 0:
-	case 1:
-		node.description = descriptionAndComments[0]
-	case 2:
-		node.description, node.comment = descriptionAndComments[0], descriptionAndComments[1]
-	default:
-		node.description = descriptionAndComments[0]
-		node.comment = strings.Join(descriptionAndComments[1:], "\n")
-	}
-	ret, oldData = node, old
+	path = s.fullKey(path) //nolint:revive
+	ret, oldData = s.insertFull(path, data, func(node *nodeS[T]) {
+		switch len(descriptionAndComments) {
+		case 0:
+		case 1:
+			node.SetComment(descriptionAndComments[0], node.Comment())
+		case 2:
+			node.SetComment(descriptionAndComments[0], descriptionAndComments[1])
+		default:
+			node.SetComment(descriptionAndComments[0], strings.Join(descriptionAndComments[1:], "\n"))
+		}
+	})
 	return
 }
 
 // SetEmpty clear the Data field.
 func (s *trieS[T]) SetEmpty(path string) (oldData any) { //nolint:revive
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	var v T
-	node, old := s.root.insertInternal([]rune(path), path, v, s, nil)
-	node.SetEmpty()
-	return old
+	_, oldData = s.insertFull(path, v, (*nodeS[T]).SetEmpty)
+	return
 }
 
 func (s *trieS[T]) Update(path string, cb func(node Node[T], old any)) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	var v T
-	node, old := s.root.insertInternal([]rune(path), path, v, s, nil)
+	node, old := s.insertFull(path, v)
 	cb(node, old)
 }
 
+// Modify updates a copy of the node specified by path by fn, and
+// publishes the copy. Unlike the setters of the located nodes,
+// Modify doesn't change the snapshots taken before.
+//
+// Nothing happens if the given path cannot be found.
+func (s *trieS[T]) Modify(path string, fn func(node Node[T])) (node Node[T], ok bool) {
+	path = s.fullKey(path) //nolint:revive
+	if n, found := s.updateFull(path, func(node *nodeS[T]) { fn(node) }); found {
+		node, ok = n, true
+	}
+	return
+}
+
 // SetComment sets the Desc and Comment field of a node specified by path.
 //
 // Nothing happens if the given path cannot be found.
 func (s *trieS[T]) SetComment(path, description, comment string) (ok bool) { //nolint:revive
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
-	node, _, _ := s.search(path, nil)
-	if ok = node != nil; ok {
-		node.description, node.comment = description, comment
-	}
+	path = s.fullKey(path) //nolint:revive
+	_, ok = s.updateFull(path, func(node *nodeS[T]) { node.SetComment(description, comment) })
 	return
 }
 
@@ -463,13 +543,8 @@ func (s *trieS[T]) SetComment(path, description, comment string) (ok bool) { //n
 //
 // Nothing happens if the given path cannot be found.
 func (s *trieS[T]) SetTag(path string, tag any) (ok bool) { //nolint:revive// set extra notable data bound to a key
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
-	node, _, _ := s.search(path, nil)
-	if ok = node != nil; ok {
-		node.tag = tag
-	}
+	path = s.fullKey(path) //nolint:revive
+	_, ok = s.updateFull(path, func(node *nodeS[T]) { node.SetTag(tag) })
 	return
 }
 
@@ -492,8 +567,10 @@ func (s *trieS[T]) Merge(pathAt string, data map[string]any) (err error) {
 //
 // Using Location to retrieve more info for seaching a path.
 func (s *trieS[T]) Search(path string) (found bool) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
+	path = s.fullKey(path) //nolint:revive
+	if IsIndexPath(path, s.Delimiter()) {
+		_, _, found, _ = s.queryIndexed(path)
+		return
 	}
 	node, _, partialMatched := s.search(path, nil)
 	found = node != nil && !partialMatched // && !node.isBranch()
@@ -502,9 +579,7 @@ func (s *trieS[T]) Search(path string) (found bool) {
 
 // Locate checks a path if it exists.
 func (s *trieS[T]) Locate(path string, kvpair KVPair) (node *nodeS[T], branch, partialMatched, found bool) { //nolint:revive
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	node, _, partialMatched = s.search(path, kvpair)
 	if node != nil {
 		found = !partialMatched
@@ -532,8 +607,10 @@ func safeIsBranch[T any](node *nodeS[T]) bool { return node != nil && node.isBra
 //
 // And Has("app.l") must be false.
 func (s *trieS[T]) Has(path string) (found bool) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
+	path = s.fullKey(path) //nolint:revive
+	if IsIndexPath(path, s.Delimiter()) {
+		_, _, found, _ = s.queryIndexed(path)
+		return
 	}
 	node, _, partialMatched := s.search(path, nil)
 	found = node != nil && !partialMatched // && !node.isBranch()
@@ -544,9 +621,7 @@ func (s *trieS[T]) Has(path string) (found bool) {
 //
 // Using Location to retrieve more info for searching a path.
 func (s *trieS[T]) HasPart(path string) (yes bool) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	node, _, partialMatched := s.search(path, nil)
 	yes = node != nil || partialMatched
 	if partialMatched && node != nil {
@@ -566,20 +641,66 @@ func (s *trieS[T]) Remove(path string) (removed bool) { //nolint:revive
 
 // RemoveEx deleting a path and return more status than Remove.
 func (s *trieS[T]) RemoveEx(path string) (nodeRemoved, nodeParent Node[T], removed bool) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
+	path = s.fullKey(path) //nolint:revive
+	if IsIndexPath(path, s.Delimiter()) {
+		return s.removeIndexed(path)
 	}
+	if node, parent, ok := s.removeFull(path); ok {
+		nodeRemoved, nodeParent, removed = node, parent, true
+	}
+	return
+}
+
+// removeFull is RemoveEx without prefix. The removed node is
+// unlinked from a copy of its ancestors, and the new root is
+// published.
+func (s *trieS[T]) removeFull(path string) (nodeRemoved, nodeParent *nodeS[T], removed bool) {
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
 	node, parent, partialMatched := s.search(path, nil)
-	found := node != nil && !partialMatched // && !node.isBranch()
-	if found {
-		if parent != nil {
-			removed = parent.remove(node)
-			if removed {
-				nodeRemoved, nodeParent = node, parent
-			}
-		} else {
-			logz.Warn("if given path found and return node, its parent MUST NOT be nil", "node", node, "parent", parent)
-		}
+	if node == nil || partialMatched {
+		return
+	}
+	if parent == nil {
+		logz.Warn("if given path found and return node, its parent MUST NOT be nil", "node", node, "parent", parent)
+		return
+	}
+	if root, ok := s.tree.load().without(node); ok {
+		s.tree.publish(root)
+		return node, parent, true
+	}
+	return
+}
+
+// insertFull is Set without prefix. The changed path is copied
+// from the root, the fns update the new node, and then the new
+// root is published.
+func (s *trieS[T]) insertFull(path string, data T, fns ...func(node *nodeS[T])) (node *nodeS[T], oldData any) {
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	var root *nodeS[T]
+	root, node, oldData = s.tree.load().insert(path, path, data)
+	for _, fn := range fns {
+		fn(node)
+	}
+	s.tree.publish(root)
+	return
+}
+
+// updateFull replaces the node at path, a key without prefix,
+// with a copy updated by fn, and publishes the new root.
+func (s *trieS[T]) updateFull(path string, fn func(node *nodeS[T])) (node *nodeS[T], ok bool) {
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	found, _, _ := s.search(path, nil)
+	if found == nil {
+		return
+	}
+	node = found.clone()
+	fn(node)
+	var root *nodeS[T]
+	if root, ok = s.tree.load().replacing(found, node); ok {
+		s.tree.publish(root)
 	}
 	return
 }
@@ -610,22 +731,18 @@ func (s *trieS[T]) GetEx(path string, cb func(node Node[T], data T, branch bool,
 }
 
 func (s *trieS[T]) getNode(path string) (node *nodeS[T], data T, kvpair KVPair, branch bool, err error) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	node, _, partialMatched := s.search(path, kvpair)
 	found := node != nil && !partialMatched
 	if found {
 		if node.isBranch() {
 			branch = true
-			if !node.endsWith(s.delimiter) {
+			if !node.endsWith(s.Delimiter()) {
 				found = false
 			}
 		}
 		if node.hasData() {
-			node.lockFor(func(n *nodeS[T]) {
-				data = node.data
-			})
+			data = node.Data()
 		}
 	}
 	// if !found {
@@ -636,16 +753,14 @@ func (s *trieS[T]) getNode(path string) (node *nodeS[T], data T, kvpair KVPair,
 }
 
 func (s *trieS[T]) getNodeFast(path string) (node *nodeS[T], err error) {
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
-	}
+	path = s.fullKey(path) //nolint:revive
 	var kvpair KVPair
 	var partialMatched bool
 	node, _, partialMatched = s.search(path, kvpair)
 	found := node != nil && !partialMatched
 	if found {
 		if node.isBranch() {
-			if !node.endsWith(s.delimiter) {
+			if !node.endsWith(s.Delimiter()) {
 				found = false
 			}
 		}
@@ -700,22 +815,26 @@ func (s *trieS[T]) MustGetComment(path string) (comment string) {
 // If something is wrong, 'err' might collect the reason for why. But,
 // it generally is errors.NotFound (errors.Code -5).
 func (s *trieS[T]) Query(path string, kvpair KVPair) (data T, branch, found bool, err error) { //nolint:revive
-	if s.prefix != "" {
-		path = s.Join(s.prefix, path) //nolint:revive
+	path = s.fullKey(path) //nolint:revive
+	if IsIndexPath(path, s.Delimiter()) {
+		return s.queryIndexed(path)
 	}
+	return s.queryFull(path, kvpair)
+}
+
+// queryFull is Query without prefix.
+func (s *trieS[T]) queryFull(path string, kvpair KVPair) (data T, branch, found bool, err error) {
 	node, _, partialMatched := s.search(path, kvpair)
 	found = node != nil && !partialMatched
 	if found {
 		if node.isBranch() {
 			branch = true
-			if !node.endsWith(s.delimiter) {
+			if !node.endsWith(s.Delimiter()) {
 				found = false
 			}
 		}
 		if node.hasData() {
-			node.lockFor(func(n *nodeS[T]) {
-				data = node.data
-			})
+			data = node.Data()
 		}
 	}
 	// if !found {
@@ -729,24 +848,24 @@ func (s *trieS[T]) Query(path string, kvpair KVPair) (data T, branch, found bool
 //
 // Since v1.4.29, matchR supports look last key according to RecursiveMode.
 func (s *trieS[T]) search(word string, kvpair KVPair) (found, parent *nodeS[T], partialMatched bool) { //nolint:revive
-	found = s.root
-	mctx := getMatchCtx(word, s.delimiter)
+	found = s.tree.load()
+	mctx := getMatchCtx(word, s.Delimiter())
 	defer putBack(mctx)
 
-	// stringtoslicerune needs two pass full-scanning for a string, but it have to be to do.
-	if matched, pm, child, prnt := found.search(mctx, []rune(word), false, nil, kvpair); matched || pm {
+	// the word is matched byte by byte, no []rune conversion needed.
+	if matched, pm, child, prnt := found.search(mctx, word, false, nil, kvpair); matched || pm {
 		return child, prnt, pm
 	}
 
-	if s.recursiveMode == RecusiveUp && strings.Contains(word, string(s.delimiter)) {
+	if s.recursiveMode == RecusiveUp && strings.Contains(word, string(s.Delimiter())) {
 		w := word
 	retry:
-		a := strings.Split(w, string(s.delimiter))
+		a := splitEscaped(w, s.Delimiter())
 		if len(a) > 2 {
 			a = append(a[:len(a)-2], a[len(a)-1])
-			w = strings.Join(a, string(s.delimiter))
+			w = strings.Join(a, string(s.Delimiter()))
 			mctx.fullPath = w
-			if matched, pm, child, prnt := found.search(mctx, []rune(w), false, nil, kvpair); matched || pm {
+			if matched, pm, child, prnt := found.search(mctx, w, false, nil, kvpair); matched || pm {
 				return child, prnt, pm
 			}
 			goto retry
@@ -759,7 +878,10 @@ func (s *trieS[T]) search(word string, kvpair KVPair) (found, parent *nodeS[T],
 
 func getMatchCtx(word string, delimiter rune) *matchCtx {
 	s := matchCtxPool.Get().(*matchCtx)
-	s.fullPath, s.delimiter = word, delimiter
+	if delimiter != s.delimiter || s.delim == "" {
+		s.delimiter, s.delim = delimiter, string(delimiter)
+	}
+	s.fullPath = word
 	return s
 }
 func putBack(mctx *matchCtx) { matchCtxPool.Put(mctx) }
@@ -771,6 +893,15 @@ var matchCtxPool = sync.Pool{New: func() any {
 type matchCtx struct {
 	fullPath  string
 	delimiter rune
+	delim     string // the delimiter in UTF-8
+}
+
+// delimiterAt tests if the delimiter ends at str[i].
+func (s *matchCtx) delimiterAt(str string, i int) bool {
+	if len(s.delim) == 1 {
+		return str[i] == s.delim[0]
+	}
+	return i+1 >= len(s.delim) && str[i+1-len(s.delim):i+1] == s.delim
 }
 
 type KVPair map[string]string
@@ -796,7 +927,7 @@ type KVPair map[string]string
 // dynamically: splitting a path by a delimiter or joining
 // the segements splitted into from path are both unnecessary, in
 // a conventional designing and implementing mode.
-func (s *trieS[T]) Delimiter() rune { return s.delimiter }
+func (s *trieS[T]) Delimiter() rune { return s.tree.delimiter() }
 
 // SetDelimiter sets the delimiter rune.
 //
@@ -820,8 +951,61 @@ func (s *trieS[T]) Delimiter() rune { return s.delimiter }
 //	data, err := trie.GetM("/about-us")
 //	assert.True(reflect.DeepEqual(data, map[string]any{"legal": 6, "team": 4}))
 //
+// The delimiter is shared by the prefixed views of a Trie, and
+// the keys inside the tree aren't updated, see ChangeDelimiter.
+//
 // See also TestTrieS_Delimiter(),
-func (s *trieS[T]) SetDelimiter(delimiter rune) { s.delimiter = delimiter }
+func (s *trieS[T]) SetDelimiter(delimiter rune) {
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	s.tree.root.Store(&rootS[T]{node: s.tree.load(), delimiter: delimiter})
+}
+
+// ChangeDelimiter re-keys the whole tree with a new delimiter,
+// and sets it as the delimiter of this Trie, the prefix is
+// re-keyed too.
+//
+// The new delimiters inside a key are escaped, and the old ones
+// escaped become plain, so a key keeps its meaning. For example,
+// changing '.' to '/':
+//
+//	app.servers.10\.0\.0\.1.path => /usr/bin
+//	app/servers/10.0.0.1/path       => /usr/bin
+//
+// The values, descriptions, comments, tags and modified states
+// are kept. The new root and delimiter are published together.
+//
+// It's for the root Trie only, a prefixed view reports an error.
+// The views created before see the re-keyed tree and the new
+// delimiter, but their prefixes aren't changed, make them again.
+func (s *trieS[T]) ChangeDelimiter(delimiter rune) (err error) {
+	if s.view {
+		return errors.New("cannot change the delimiter by a prefixed view %q", s.prefix)
+	}
+
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	from := s.tree.delimiter()
+	if delimiter == from {
+		return
+	}
+	root := &nodeS[T]{}
+	s.tree.load().walk(0, func(key, _ string, node Node[T]) {
+		nd, ok := node.(*nodeS[T])
+		if !ok || key == "" || (!nd.hasData() && nd.attrs.Load() == nil) {
+			return
+		}
+		root, _ = root.insertCopy(RekeyPath(key, from, delimiter), nd)
+	})
+	s.tree.ttlMu.Lock()
+	for job := range s.tree.ttlJobs {
+		job.key = RekeyPath(job.key, from, delimiter)
+	}
+	s.tree.ttlMu.Unlock()
+	s.tree.root.Store(&rootS[T]{node: root, delimiter: delimiter})
+	s.prefix = RekeyPath(s.prefix, from, delimiter)
+	return
+}
 
 func (s *trieS[T]) simpleEndsWith(str string, ch rune) bool { //nolint:revive
 	if str != "" {
@@ -848,23 +1032,56 @@ func (s *trieS[T]) simpleEndsWith(str string, ch rune) bool { //nolint:revive
 //	println(trie.Dump())
 //
 // Or, [StatesEnvSetColorMode(true)] can also do that.
-func (s *trieS[T]) Dump() string             { return s.root.dump(false) }   //nolint:revive
-func (s *trieS[T]) dump(noColor bool) string { return s.root.dump(noColor) } //nolint:revive
+func (s *trieS[T]) Dump() string             { return s.tree.load().dump(false) }   //nolint:revive
+func (s *trieS[T]) dump(noColor bool) string { return s.tree.load().dump(noColor) } //nolint:revive
 
 // Dup or Clone makes an exact deep copy of this tree.
 func (s *trieS[T]) Dup() (newTrie *trieS[T]) { //nolint:revive
-	return s.dupS(s.root.Dup(), s.prefix)
+	newTrie = s.dupS(newTree(s.tree.load().Dup(), s.Delimiter()), s.prefix)
+	newTrie.view = false
+	return
+}
+
+// Fork makes a copy of this tree sharing the current root, so
+// it's cheap. The writings on either of them copy the changed
+// paths, so they don't affect each other. But the setters of a
+// Node, such as SetComment, update it in place, use the ones of
+// the Trie instead.
+func (s *trieS[T]) Fork() (newTrie Trie[T]) {
+	r := s.tree.root.Load()
+	t := s.dupS(newTree(r.node, r.delimiter), s.prefix)
+	t.view = false
+	return t
+}
+
+// Batch runs fn on an unprefixed fork of the current root, and
+// publishes the fork at once if fn returns nil. So the readers
+// see all or none of the writings in fn.
+//
+// The other writers of this tree wait for Batch, fn must not
+// write this Trie or its prefixed views. The TTLs set by 'tx'
+// are ignored.
+func (s *trieS[T]) Batch(fn func(tx Trie[T]) (err error)) (err error) {
+	s.tree.mu.Lock()
+	defer s.tree.mu.Unlock()
+	r := s.tree.root.Load()
+	tx := &trieS[T]{tree: newTree(r.node, r.delimiter), recursiveMode: s.recursiveMode, interp: s.interp}
+	if err = fn(tx); err == nil {
+		s.tree.root.Store(&rootS[T]{node: tx.tree.load(), delimiter: r.delimiter})
+	}
+	return
 }
 
 // Walk navigates the whole tree (passing "" as 'path' param) or
 // a subtree from a given path.
 func (s *trieS[T]) Walk(path string, cb func(path, fragment string, node Node[T])) { //nolint:revive
-	root := s.root
+	root := s.tree.load()
+	path = NormalizePath(path, s.Delimiter()) //nolint:revive
 	if path != "" {
 		node, parent, partialMatched := s.search(path, nil)
 		if !partialMatched {
 			root = parent
-			if runes := []rune(path); runes[len(runes)-1] == s.delimiter {
+			if strings.HasSuffix(path, string(s.Delimiter())) {
 				root = node
 			}
 		}
@@ -2,6 +2,7 @@ package radix
 
 import (
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -262,3 +263,96 @@ func newTrieTree2() *trieS[any] {
 
 	return trie
 }
+
+func TestTrieS_Snapshot(t *testing.T) {
+	trie := newBasicStore()
+	trie.SetComment("app.server.start", "desc", "comment")
+
+	s1 := trie.Snapshot()
+	s2 := trie.Snapshot()
+	assertTrue(t, s1.root == s2.root, "an unchanged tree should be shared entirely")
+
+	trie.Set("app.server.start", 6)
+	s3 := trie.Snapshot()
+	assertTrue(t, s3.root != s2.root, "the changed path should be copied")
+	app2, app3 := s2.root.node.children[0], s3.root.node.children[0]
+	assertEqual(t, "app.", app3.pathS)
+	assertTrue(t, app2 != app3, "the ancestors of the changed node should be copied")
+	assertTrue(t, childOf(app2, "logging.") == childOf(app3, "logging."), "the unchanged subtree should be shared")
+
+	trie.Remove("app.logging")
+	assertFalse(t, trie.Has("app.logging.file"))
+	trie.Restore(s2)
+	assertEqual(t, "/tmp/1.log", trie.MustString("app.logging.file"))
+	assertEqual(t, 5, trie.MustInt("app.server.start"))
+	assertEqual(t, "comment", trie.MustGetComment("app.server.start"))
+	assertTrue(t, trie.tree.root.Load() == s2.root, "restoring should swap the root back")
+
+	ro := s3.Trie()
+	assertEqual(t, 6, ro.MustInt("app.server.start"))
+	ro.Set("app.server.start", 7)
+	assertEqual(t, 5, trie.MustInt("app.server.start"))
+	assertEqual(t, 6, s3.Trie().MustInt("app.server.start"), "the snapshot should not be changed by its tries")
+
+	s4 := trie.Snapshot()
+	node, ok := trie.Modify("app.server.start", func(node Node[any]) { node.SetModified(true) })
+	assertTrue(t, ok && node.Modified(), "Modify should update the node")
+	n, _, _, _ := s4.Trie().Locate("app.server.start", nil)
+	assertFalse(t, n.Modified(), "Modify should not change the snapshot")
+	_, ok = trie.Modify("app.server.none", func(node Node[any]) { t.Fatal("should not be called") })
+	assertFalse(t, ok)
+}
+
+func childOf[T any](node *nodeS[T], path string) *nodeS[T] {
+	for _, c := range node.children {
+		if c.path == path {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestTrieS_CopyOnWrite(t *testing.T) {
+	trie := newBasicStore()
+	root := trie.tree.load()
+	node, _ := trie.Set("app.server.start", 6)
+	assertTrue(t, root != trie.tree.load(), "updating the data should publish a new root")
+	assertEqual(t, 6, node.Data())
+	old := &trieS[any]{tree: newTree(root, '.')}
+	assertEqual(t, 5, old.MustInt("app.server.start"), "a published root is never changed")
+
+	root = trie.tree.load()
+	trie.Set("app.server.stop", 1)
+	assertTrue(t, root != trie.tree.load(), "a new key should publish a new root")
+	assertEqual(t, 6, trie.MustInt("app.server.start"))
+	assertTrue(t, trie.SetComment("app.server.start", "desc", ""))
+	root = trie.tree.load()
+	assertTrue(t, trie.SetComment("app.server.start", "changed", ""))
+	prev := &trieS[any]{tree: newTree(root, '.')}
+	n, _, _, _ := prev.Locate("app.server.start", nil)
+	assertEqual(t, "desc", n.Description(), "nor its attributes")
+
+	ns := trie.WithPrefix("app.server")
+	trie.Remove("app.server.stop")
+	assertFalse(t, ns.Has("stop"), "the views share the published root")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			trie.Set("app.server.start", i)
+			trie.Set("app.server.k"+strconv.Itoa(i%10), i)
+			trie.Remove("app.server.k" + strconv.Itoa((i+5)%10))
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			assertEqual(t, 999, trie.MustInt("app.server.start"))
+			return
+		default:
+			assertEqual(t, "/tmp/1.log", trie.MustString("app.logging.file"))
+			assertTrue(t, trie.Has("app.server.start"))
+		}
+	}
+}
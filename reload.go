@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	logz "github.com/hedzr/logg/slog"
+)
+
+// Reloadable is implemented by the Change of a provider which
+// watches its data source as a whole, such as a file.
+//
+// For such a change, the store doesn't apply the pairs from
+// Change.Next. It re-reads and re-decodes the source instead,
+// and applies the changed, added and removed keys only.
+type Reloadable interface {
+	Reload() bool
+}
+
+// RemovePolicy tells the store what to do when a watched source,
+// such as a file, has been removed or renamed.
+type RemovePolicy int
+
+const (
+	// RemoveKeep keeps the keys loaded from the source. They
+	// will be updated if the source is recreated later.
+	RemoveKeep RemovePolicy = iota
+	// RemoveClear removes the keys loaded from the source.
+	RemoveClear
+	// RemoveWait keeps the keys for a grace period, and removes
+	// them if the source isn't recreated in time. It's fit for the
+	// editors which save a file by renaming and recreating it.
+	RemoveWait
+)
+
+const defaultRemoveGrace = time.Second
+
+// WithRemovePolicy sets the policy for the removing or renaming
+// of a watched source, the default is RemoveKeep.
+//
+// The optional grace is for RemoveWait, 1s by default.
+func WithRemovePolicy(policy RemovePolicy, grace ...time.Duration) LoadOpt {
+	return func(s *Loader) {
+		s.removePolicy = policy
+		for _, g := range grace {
+			s.removeGrace = g
+		}
+	}
+}
+
+// applyExternalChanges is the callback for the watching provider.
+func (s *Loader) applyExternalChanges(ctx context.Context, event any, err error) {
+	if err != nil {
+		logz.Error("[Watcher.ERROR]", "err", err)
+		return
+	}
+
+	ev, ok := event.(Change)
+	if !ok {
+		return
+	}
+	if r, ok := ev.(Reloadable); ok && r.Reload() {
+		s.applyReload(ctx, ev)
+		return
+	}
+	s.providerView().applyChanges(ev)
+}
+
+func (s *Loader) applyReload(ctx context.Context, ev Change) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	switch {
+	case ev.Has(OpCreate) || ev.Has(OpWrite):
+		if s.removeTimer != nil {
+			s.removeTimer.Stop()
+			s.removeTimer = nil
+		}
+		if err := s.reload(ctx); err != nil {
+			logz.Error("[Watcher.reload]", "err", err, "src", s.provider)
+		}
+	case ev.Has(OpRemove) || ev.Has(OpRename):
+		switch s.removePolicy {
+		case RemoveClear:
+			s.clearSource()
+		case RemoveWait:
+			grace := s.removeGrace
+			if grace <= 0 {
+				grace = defaultRemoveGrace
+			}
+			var timer *time.Timer
+			timer = time.AfterFunc(grace, func() {
+				s.reloadMu.Lock()
+				defer s.reloadMu.Unlock()
+				if s.removeTimer == timer {
+					s.removeTimer = nil
+					s.clearSource()
+				}
+			})
+			s.removeTimer = timer
+		default:
+			logz.Debug("[Watcher] source removed, keys kept", "src", s.provider)
+		}
+	}
+}
+
+// reload re-reads the source, and applies the differences between
+// its previous and next leaves.
+//
+// The keys unchanged in the source are kept as is, even if they
+// have been changed at runtime or by another source. The keys
+// which are not in the source any more will be removed only if
+// they were loaded from the source.
+//
+// With the schemas, the changes are checked on a fork of the tree
+// first, as Load does. An invalid source is rejected and the store
+// is kept as is.
+func (s *Loader) reload(ctx context.Context) (err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	data, bin, err := s.tryLoad(ctx)
+	if err != nil {
+		return
+	}
+	next := s.sourceLeaves(data, bin)
+	root := s.loadingRoot()
+	deltas := reloadDeltas(collectLeaves(root, s.Prefix()), s.leaves, next)
+	if err = root.checkStaged(func(stage *storeS) error {
+		applyDeltas(stage, deltas)
+		return nil
+	}); err != nil {
+		return
+	}
+	s.apply(root, deltas)
+	s.leaves = next
+	return
+}
+
+// clearSource removes the keys loaded from the source.
+func (s *Loader) clearSource() {
+	root := s.loadingRoot()
+	s.apply(root, reloadDeltas(collectLeaves(root, s.Prefix()), s.leaves, nil))
+	s.leaves = nil
+}
+
+// loadingRoot returns an unprefixed view in loading state, so the
+// handlers see the reloaded values as the loaded ones.
+func (s *Loader) loadingRoot() (root *storeS) {
+	root = s.dupS(s.Trie.WithPrefixReplaced())
+	root.parent = s.owner
+	atomic.StoreInt32(&root.loading, 1)
+	return
+}
+
+func (s *Loader) apply(root *storeS, deltas []Delta) {
+	if len(deltas) == 0 {
+		return
+	}
+	applyDeltas(root, deltas)
+	if len(s.schemas) > 0 {
+		root.applySchemaDefaults() // for the removed keys
+	}
+	s.history.autoPush(s.Trie)
+}
+
+// applyDeltas writes the changes of a source into the unprefixed
+// root.
+func applyDeltas(root *storeS, deltas []Delta) {
+	for _, d := range deltas {
+		switch d.Op {
+		case OpCreate, OpWrite:
+			root.setKV(d.Path, d.NewValue, d.Op == OpCreate, nil)
+		case OpRemove:
+			root.Remove(d.Path)
+		}
+	}
+}
+
+// sourceLeaves decodes the dataset into a scratch store, and
+// returns its leaves with full paths.
+func (s *Loader) sourceLeaves(data map[string]ValPkg, bin map[string]any) (leaves map[string]any) {
+	tmp := newStore(WithDelimiter(s.Delimiter()), WithFlattenSlice(s.flattenSlice))
+	prefix := s.Prefix()
+	if data != nil {
+		_ = tmp.loadMapDedicated(data, prefix, true)
+	}
+	if bin != nil {
+		_ = tmp.loadMap(bin, prefix, true, nil)
+	}
+	return collectLeaves(tmp, prefix)
+}
+
+// reloadDeltas computes the changes of a source from 'prev' to
+// 'next', as the writings to 'cur', the current values. A key
+// absent in 'next' is removed only if it was in 'prev'.
+func reloadDeltas(cur, prev, next map[string]any) (deltas []Delta) {
+	for k, vn := range next {
+		if vp, ok := prev[k]; ok && reflect.DeepEqual(vp, vn) {
+			continue // unchanged in the source
+		}
+		if vc, ok := cur[k]; !ok {
+			deltas = append(deltas, Delta{Op: OpCreate, Path: k, NewValue: vn})
+		} else if !reflect.DeepEqual(vc, vn) {
+			deltas = append(deltas, Delta{Op: OpWrite, Path: k, OldValue: vc, NewValue: vn})
+		}
+	}
+	for k := range prev {
+		if _, ok := next[k]; ok {
+			continue
+		}
+		if vc, ok := cur[k]; ok {
+			deltas = append(deltas, Delta{Op: OpRemove, Path: k, OldValue: vc})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Path < deltas[j].Path })
+	return
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchableS is a watchable OnceProvider for the tests, it serves
+// the JSON text in 'data'.
+type watchableS struct {
+	mu   sync.Mutex
+	data string
+	cb   func(event any, err error)
+}
+
+func (s *watchableS) Read() (m map[string]ValPkg, err error) { return nil, ErrNotImplemented }
+func (s *watchableS) ReadBytes() (data []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []byte(s.data), nil
+}
+func (s *watchableS) Write(data []byte) (err error) { return ErrNotImplemented }
+func (s *watchableS) GetCodec() (codec Codec)       { return nil }
+func (s *watchableS) GetPosition() (pos string)     { return "" }
+func (s *watchableS) WithCodec(codec Codec)         {}
+func (s *watchableS) WithPosition(pos string)       {}
+func (s *watchableS) Close()                        {}
+func (s *watchableS) Watch(ctx context.Context, cb func(event any, err error)) error {
+	s.cb = cb
+	return nil
+}
+
+func (s *watchableS) change(op Op, data string) {
+	s.mu.Lock()
+	s.data = data
+	s.mu.Unlock()
+	s.cb(&reloadChangeS{changeS{op: op}}, nil)
+}
+
+type reloadChangeS struct{ changeS }
+
+func (s *reloadChangeS) Reload() bool { return true }
+
+func TestLoader_Reload(t *testing.T) {
+	var changed []string
+	conf := New(WithWatchEnable(true), WithOnChangeHandlers(func(path string, value, oldValue any, mergingMapOrLoading bool) {
+		changed = append(changed, path)
+	}))
+	defer conf.Close()
+	conf.Set("app.env", "prod") // from another source
+
+	src := &watchableS{data: `{"app":{"server":{"port":7999,"host":"a"},"debug":true}}`}
+	if _, err := conf.Load(context.TODO(), WithProvider(src), WithRemovePolicy(RemoveWait, 50*time.Millisecond)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	var deltas []Delta
+	cleared := make(chan struct{})
+	conf.Watch("**", func(d Delta) {
+		deltas = append(deltas, d)
+		if d.Op == OpRemove && d.Path == "app.server.tls" {
+			close(cleared) // the last one of the clearing
+		}
+	})
+
+	src.change(OpWrite, `{"app":{"server":{"port":8080,"tls":true},"debug":true}}`)
+	assertEqual(t, []Delta{
+		{Op: OpRemove, Path: "app.server.host", OldValue: "a"},
+		{Op: OpWrite, Path: "app.server.port", OldValue: float64(7999), NewValue: float64(8080)},
+		{Op: OpCreate, Path: "app.server.tls", NewValue: true},
+	}, deltas)
+	assertEqual(t, []string{"app.server.port"}, changed)
+	assertEqual(t, 8080, conf.MustInt("app.server.port"))
+	assertFalse(t, conf.Has("app.server.host"))
+	assertEqual(t, "prod", conf.MustString("app.env"), "keys from other sources are kept")
+
+	conf.Set("app.debug", "verbose") // changed at runtime
+	deltas, changed = nil, nil
+	src.change(OpWrite, `{"app":{"server":{"port":8081,"tls":true},"debug":true}}`)
+	assertEqual(t, []Delta{{Op: OpWrite, Path: "app.server.port", OldValue: float64(8080), NewValue: float64(8081)}}, deltas)
+	assertEqual(t, "verbose", conf.MustString("app.debug"), "unchanged in the source")
+	conf.Set("app.debug", true)
+	src.change(OpWrite, `{"app":{"server":{"port":8080,"tls":true},"debug":true}}`)
+
+	// removed and recreated in the grace period
+	deltas = nil
+	src.change(OpRemove, ``)
+	src.change(OpCreate, `{"app":{"server":{"port":8080,"tls":true},"debug":false}}`)
+	time.Sleep(100 * time.Millisecond)
+	assertEqual(t, []Delta{{Op: OpWrite, Path: "app.debug", OldValue: true, NewValue: false}}, deltas)
+
+	// removed, and the grace period expired
+	src.change(OpRename, ``)
+	assertTrue(t, conf.Has("app.server.port"), "kept in the grace period")
+	select {
+	case <-cleared:
+	case <-time.After(time.Second):
+		t.Fatal("the keys are not cleared after the grace period")
+	}
+	assertFalse(t, conf.Has("app.server.port"))
+	assertFalse(t, conf.Has("app.debug"))
+	assertEqual(t, "prod", conf.MustString("app.env"))
+}
+
+func TestReloadDeltas(t *testing.T) {
+	cur := map[string]any{"a": 1, "b": 2, "c": 3, "other": 4}
+	prev := map[string]any{"a": 1, "b": 2, "c": 3}
+	next := map[string]any{"a": 1, "b": 20, "d": 5}
+	assertEqual(t, []Delta{
+		{Op: OpWrite, Path: "b", OldValue: 2, NewValue: 20},
+		{Op: OpRemove, Path: "c", OldValue: 3},
+		{Op: OpCreate, Path: "d", NewValue: 5},
+	}, reloadDeltas(cur, prev, next))
+	assertEqual(t, 3, len(reloadDeltas(cur, prev, nil)), "clear the keys of the source only")
+
+	cur = map[string]any{"a": 10, "b": 2}
+	prev = map[string]any{"a": 1, "b": 2}
+	next = map[string]any{"a": 1, "b": 3}
+	assertEqual(t, []Delta{
+		{Op: OpWrite, Path: "b", OldValue: 2, NewValue: 3},
+	}, reloadDeltas(cur, prev, next), "a key unchanged in the source keeps its runtime value")
+}
@@ -0,0 +1,507 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	logz "github.com/hedzr/logg/slog"
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// WithSchema attaches a JSON Schema to the subtree at 'path', or
+// the whole store if path is empty.
+//
+// A subset of JSON Schema draft 2020-12 is supported: type,
+// properties, required, additionalProperties, items, enum,
+// const, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, minItems, maxItems and default.
+//
+// Once attached,
+//
+//   - the defaults declared in the schema are put into the
+//     missing keys, at once and after each loading.
+//   - Load rejects the provider data which violates the schema,
+//     the store is kept untouched in this case.
+//   - Set and Merge are checked too. The violations are logged
+//     as warnings, or rejected in strict mode, see
+//     WithSchemaStrict.
+//
+// The violations are reported as *SchemaError with full dotted
+// paths. A bad schema is reported by Load and [Store.Validate].
+func WithSchema(path string, schema []byte) Opt {
+	return func(s *storeS) {
+		sch, err := compileSchema(schema)
+		if err != nil {
+			err = errors.New("bad schema at %q", path).WithErrors(err)
+		}
+		s.schemas = append(s.schemas, &schemaEntry{path: path, schema: sch, err: err})
+		if err == nil {
+			s.WithinLoading(func() { s.applySchemaDefaults() })
+		}
+	}
+}
+
+// WithSchemaStrict rejects Set and Merge if the new values
+// violate the schemas. In strict mode, Set doesn't write the
+// value and logs the violation as a warning, since it has no
+// error to return, and Merge returns an error.
+//
+// Without it, the violations are logged and the values are
+// written, use Validate to check the store.
+func WithSchemaStrict(b bool) Opt {
+	return func(s *storeS) {
+		s.schemaStrict = b
+	}
+}
+
+// SchemaError is a violation of a schema.
+type SchemaError struct {
+	Path    string // the full dotted path of the key
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ErrSchemaViolation is the container error for schema
+// violations, the *SchemaError items are attached to it.
+//
+//	if errors.Is(err, store.ErrSchemaViolation) { ... }
+var ErrSchemaViolation = errors.New("schema violation")
+
+type schemaEntry struct {
+	path   string
+	schema *schemaS
+	err    error // compiling error
+}
+
+// schemaS is a compiled JSON Schema.
+type schemaS struct {
+	always *bool // boolean schema: true or false
+
+	types    []string
+	props    map[string]*schemaS
+	required []string
+	addl     *schemaS // additionalProperties
+	items    *schemaS
+	enum     []any
+	konst    any
+	hasConst bool
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	minLength, maxLength               *int
+	minItems, maxItems                 *int
+	pattern                            *regexp.Regexp
+
+	def    any
+	hasDef bool
+}
+
+func compileSchema(data []byte) (s *schemaS, err error) {
+	var raw json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	return compileSchemaRaw(raw)
+}
+
+func compileSchemaRaw(raw json.RawMessage) (s *schemaS, err error) {
+	s = &schemaS{}
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		s.always = &b
+		return
+	}
+
+	var m map[string]json.RawMessage
+	if err = json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	ec := errors.New()
+	defer ec.Defer(&err)
+	for k, v := range m {
+		switch k {
+		case "type":
+			var t string
+			if json.Unmarshal(v, &t) == nil {
+				s.types = []string{t}
+			} else {
+				ec.Attach(json.Unmarshal(v, &s.types))
+			}
+		case "properties":
+			var props map[string]json.RawMessage
+			ec.Attach(json.Unmarshal(v, &props))
+			s.props = make(map[string]*schemaS, len(props))
+			for name, p := range props {
+				sub, e := compileSchemaRaw(p)
+				ec.Attach(e)
+				s.props[name] = sub
+			}
+		case "required":
+			ec.Attach(json.Unmarshal(v, &s.required))
+		case "additionalProperties":
+			var e error
+			s.addl, e = compileSchemaRaw(v)
+			ec.Attach(e)
+		case "items":
+			var e error
+			s.items, e = compileSchemaRaw(v)
+			ec.Attach(e)
+		case "enum":
+			ec.Attach(json.Unmarshal(v, &s.enum))
+		case "const":
+			s.hasConst = true
+			ec.Attach(json.Unmarshal(v, &s.konst))
+		case "default":
+			s.hasDef = true
+			ec.Attach(json.Unmarshal(v, &s.def))
+		case "minimum":
+			ec.Attach(json.Unmarshal(v, &s.minimum))
+		case "maximum":
+			ec.Attach(json.Unmarshal(v, &s.maximum))
+		case "exclusiveMinimum":
+			ec.Attach(json.Unmarshal(v, &s.exclusiveMinimum))
+		case "exclusiveMaximum":
+			ec.Attach(json.Unmarshal(v, &s.exclusiveMaximum))
+		case "minLength":
+			ec.Attach(json.Unmarshal(v, &s.minLength))
+		case "maxLength":
+			ec.Attach(json.Unmarshal(v, &s.maxLength))
+		case "minItems":
+			ec.Attach(json.Unmarshal(v, &s.minItems))
+		case "maxItems":
+			ec.Attach(json.Unmarshal(v, &s.maxItems))
+		case "pattern":
+			var p string
+			if e := json.Unmarshal(v, &p); e != nil {
+				ec.Attach(e)
+			} else {
+				s.pattern, e = regexp.Compile(p)
+				ec.Attach(e)
+			}
+		}
+		// the others, such as $schema, title and description, are ignored.
+	}
+	return
+}
+
+// lookup finds the sub-schema for the relative key path.
+func (s *schemaS) lookup(keys []string) (sub *schemaS) {
+	sub = s
+	for _, k := range keys {
+		if sub == nil || sub.always != nil {
+			return
+		}
+		if p, ok := sub.props[k]; ok {
+			sub = p
+		} else if _, err := strconv.Atoi(k); err == nil && sub.items != nil {
+			sub = sub.items
+		} else {
+			sub = sub.addl
+		}
+	}
+	return
+}
+
+// validate checks 'v' at 'path'. If partial is true, the required
+// keys aren't checked, since 'v' is a part of the final value.
+func (s *schemaS) validate(v any, path, delim string, partial bool, report func(e *SchemaError)) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...any) {
+		report(&SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.always != nil {
+		if !*s.always {
+			fail("not allowed")
+		}
+		return
+	}
+
+	if len(s.types) > 0 {
+		ok := false
+		for _, t := range s.types {
+			if schemaTypeOf(v, t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("expecting %s, but got %T", strings.Join(s.types, " or "), v)
+			return
+		}
+	}
+	if len(s.enum) > 0 {
+		ok := false
+		for _, e := range s.enum {
+			if jsonEqual(v, e) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("value %v is not one of %v", v, s.enum)
+		}
+	}
+	if s.hasConst && !jsonEqual(v, s.konst) {
+		fail("value %v should be %v", v, s.konst)
+	}
+
+	if f, ok := schemaNumber(v); ok {
+		if s.minimum != nil && f < *s.minimum {
+			fail("value %v is less than minimum %v", v, *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			fail("value %v is greater than maximum %v", v, *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+			fail("value %v should be greater than %v", v, *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+			fail("value %v should be less than %v", v, *s.exclusiveMaximum)
+		}
+	}
+
+	if str, ok := v.(string); ok {
+		l := len([]rune(str))
+		if s.minLength != nil && l < *s.minLength {
+			fail("length %d is less than minLength %d", l, *s.minLength)
+		}
+		if s.maxLength != nil && l > *s.maxLength {
+			fail("length %d is greater than maxLength %d", l, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			fail("%q doesn't match the pattern %q", str, s.pattern.String())
+		}
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		l := rv.Len()
+		if s.minItems != nil && l < *s.minItems {
+			fail("%d items are less than minItems %d", l, *s.minItems)
+		}
+		if s.maxItems != nil && l > *s.maxItems {
+			fail("%d items are greater than maxItems %d", l, *s.maxItems)
+		}
+		for i := 0; i < l && s.items != nil; i++ {
+			s.items.validate(rv.Index(i).Interface(), joinPath(delim, path, strconv.Itoa(i)), delim, partial, report)
+		}
+	}
+
+	if m, ok := v.(map[string]any); ok {
+		if !partial {
+			for _, k := range s.required {
+				if _, ok := m[k]; !ok {
+					report(&SchemaError{Path: joinPath(delim, path, k), Message: "required"})
+				}
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, ok := s.props[k]
+			if !ok {
+				sub = s.addl
+			}
+			sub.validate(m[k], joinPath(delim, path, k), delim, partial, report)
+		}
+	}
+}
+
+// defaults collects the default values of the missing keys.
+func (s *schemaS) defaults(has func(path string) bool, path, delim string, put func(path string, v any)) {
+	if s == nil {
+		return
+	}
+	for k, sub := range s.props {
+		kp := joinPath(delim, path, k)
+		if has(kp) {
+			sub.defaults(has, kp, delim, put)
+		} else if sub.hasDef {
+			put(kp, sub.def)
+		} else if len(sub.props) > 0 {
+			sub.defaults(has, kp, delim, put)
+		}
+	}
+}
+
+func schemaTypeOf(v any, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := schemaNumber(v)
+		return ok
+	case "integer":
+		f, ok := schemaNumber(v)
+		return ok && f == math.Trunc(f)
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		k := reflect.ValueOf(v).Kind()
+		return k == reflect.Slice || k == reflect.Array
+	}
+	return false
+}
+
+func schemaNumber(v any) (f float64, ok bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	if n, yes := v.(json.Number); yes {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return
+}
+
+// Validate checks the whole store against the schemas attached
+// by WithSchema.
+//
+// The returned error is ErrSchemaViolation with the *SchemaError
+// items attached.
+func (s *storeS) Validate() (err error) {
+	if len(s.schemas) == 0 {
+		return
+	}
+	root := s.dupS(s.Trie.WithPrefixReplaced())
+	delim := string(s.Delimiter())
+
+	var ve []error
+	for _, e := range s.schemas {
+		if e.err != nil {
+			return e.err
+		}
+		e.schema.validate(root.schemaValue(e.path), e.path, delim, false, func(se *SchemaError) {
+			ve = append(ve, se)
+		})
+	}
+	if len(ve) > 0 {
+		err = errors.New("schema violation").WithErrors(ErrSchemaViolation).WithErrors(ve...)
+	}
+	return
+}
+
+// schemaValue returns the value at path for validating. A branch
+// is returned as a nested map.
+func (s *storeS) schemaValue(path string) any {
+	if path != "" {
+		if node, branch, partial, found := s.Locate(path, nil); found && !partial && node != nil &&
+			!branch && !strings.HasSuffix(node.Key(), string(s.Delimiter())) {
+			return node.Data()
+		}
+	}
+	m, _ := s.GetM(path, WithoutFlattenKeys[any](true), WithRawValues[any](true))
+	if m == nil {
+		m = make(map[string]any)
+	}
+	return m
+}
+
+// checkSchema checks the new value at the full path against the
+// schemas. 'partial' is true for merging.
+func (s *storeS) checkSchema(fullPath string, v any, partial bool) (err error) {
+	delim := string(s.Delimiter())
+	var ve []error
+	for _, e := range s.schemas {
+		if e.err != nil {
+			continue
+		}
+		var keys []string
+		if e.path != "" {
+			if fullPath != e.path && !strings.HasPrefix(fullPath, e.path+delim) {
+				continue
+			}
+			if rest := strings.TrimPrefix(strings.TrimPrefix(fullPath, e.path), delim); rest != "" {
+				keys = radix.SplitPath(rest, s.Delimiter())
+			}
+		} else if fullPath != "" {
+			keys = radix.SplitPath(fullPath, s.Delimiter())
+		}
+		e.schema.lookup(keys).validate(v, fullPath, delim, partial, func(se *SchemaError) {
+			ve = append(ve, se)
+		})
+	}
+	if len(ve) > 0 {
+		err = errors.New("schema violation").WithErrors(ErrSchemaViolation).WithErrors(ve...)
+		if !s.schemaStrict {
+			logz.Warn("[store] schema violation", "path", fullPath, "err", err)
+		}
+	}
+	return
+}
+
+// applySchemaDefaults puts the defaults declared in the schemas
+// into the missing keys.
+func (s *storeS) applySchemaDefaults() {
+	root := s.dupS(s.Trie.WithPrefixReplaced())
+	delim := string(s.Delimiter())
+	for _, e := range s.schemas {
+		if e.err != nil {
+			continue
+		}
+		e.schema.defaults(root.Has, e.path, delim, func(path string, v any) {
+			if m, ok := v.(map[string]any); ok {
+				_ = root.loadMap(m, path, true, nil)
+			} else {
+				root.setKV(path, v, true, nil)
+			}
+		})
+	}
+}
+
+// checkLoaded merges the dataset loaded from source into a fork
+// of the tree, puts the defaults, and validates the result. The
+// store is not changed, and no events are fired.
+func (s *storeS) checkLoaded(data map[string]ValPkg, bin map[string]any) (err error) {
+	return s.checkStaged(func(stage *storeS) (err error) {
+		_, err = stage.mergeLoaded(data, bin)
+		return
+	})
+}
+
+// checkStaged runs fn on a fork of the tree, puts the defaults,
+// and validates the result, see checkLoaded.
+func (s *storeS) checkStaged(fn func(stage *storeS) (err error)) (err error) {
+	if len(s.schemas) == 0 {
+		return
+	}
+	stage := s.dupS(s.Trie.Fork())
+	stage.origins, stage.history, stage.watchers, stage.journal = nil, nil, nil, nil
+	if err = fn(stage); err == nil {
+		stage.applySchemaDefaults()
+		err = stage.Validate()
+	}
+	return
+}
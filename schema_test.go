@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["host", "port"],
+  "properties": {
+    "host": {"type": "string", "minLength": 1},
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535},
+    "mode": {"enum": ["dev", "prod"], "default": "dev"},
+    "tls": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean", "default": false}
+      }
+    },
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+func schemaErrors(err error) (paths []string) {
+	for _, e := range errors.Causes(err) {
+		if se, ok := e.(*SchemaError); ok {
+			paths = append(paths, se.Path)
+		}
+	}
+	return
+}
+
+func TestWithSchema(t *testing.T) {
+	conf := New(WithSchema("app.server", []byte(testSchema)))
+	defer conf.Close()
+	ctx := context.TODO()
+
+	assertEqual(t, "dev", conf.MustString("app.server.mode"), "default value")
+	assertEqual(t, false, conf.MustBool("app.server.tls.enabled", true), "nested default value")
+
+	_, err := conf.Load(ctx, WithProvider(newMapPvdr("bad", map[string]any{
+		"app.server.port": 70000,
+		"app.server.tags": []any{"a", 1},
+	})))
+	assertTrue(t, errors.Is(err, ErrSchemaViolation), "expecting ErrSchemaViolation")
+	assertEqual(t, []string{"app.server.host", "app.server.port", "app.server.tags.1"}, schemaErrors(err))
+	assertFalse(t, conf.Has("app.server.port"), "the bad data should be rejected")
+	assertEqual(t, "dev", conf.MustString("app.server.mode"))
+
+	_, err = conf.Load(ctx, WithProvider(newMapPvdr("good", map[string]any{
+		"app.server.host": "localhost",
+		"app.server.port": 7999,
+	})))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertEqual(t, 7999, conf.MustInt("app.server.port"))
+	assertTrue(t, conf.Validate() == nil, "the store should be valid")
+
+	conf.Set("app.server.port", "x") // not strict, the value is written
+	assertEqual(t, "x", conf.MustString("app.server.port"))
+	err = conf.Validate()
+	assertEqual(t, []string{"app.server.port"}, schemaErrors(err))
+}
+
+func TestWithSchema_RejectedLoad(t *testing.T) {
+	var events []string
+	conf := New(
+		WithSchema("app.server", []byte(testSchema)),
+		WithOriginTracking(true),
+		WithOnNewHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "new "+path)
+		}),
+		WithOnChangeHandlers(func(path string, value, oldValue any, mergingMapOrLoading bool) {
+			events = append(events, "change "+path)
+		}),
+	)
+	defer conf.Close()
+	unsubscribe := conf.Watch("app.**", func(d Delta) { events = append(events, "watch "+d.Path) })
+	defer unsubscribe()
+
+	_, err := conf.Load(context.TODO(), WithProvider(newMapPvdr("bad", map[string]any{
+		"app.server.host": "localhost",
+		"app.server.port": 70000,
+	})))
+	assertTrue(t, errors.Is(err, ErrSchemaViolation), "expecting ErrSchemaViolation")
+	assertEqual(t, []string(nil), events, "a rejected load should fire nothing")
+	assertFalse(t, conf.Has("app.server.host"))
+	assertEqual(t, 0, len(conf.Origin("app.server.host")), "a rejected load should not be recorded")
+}
+
+func TestWithSchemaStrict(t *testing.T) {
+	conf := New(WithSchema("", []byte(`{"properties":{"app":{"properties":{"server":`+testSchema+`}}}}`)), WithSchemaStrict(true))
+	defer conf.Close()
+
+	conf.Set("app.server.port", 8080)
+	conf.WithPrefix("app.server").Set("port", 0)
+	assertEqual(t, 8080, conf.MustInt("app.server.port"), "a bad value should be rejected")
+
+	err := conf.Merge("app.server", map[string]any{"host": "", "mode": "test"})
+	assertEqual(t, []string{"app.server.host", "app.server.mode"}, schemaErrors(err))
+	assertEqual(t, "dev", conf.MustString("app.server.mode"))
+
+	err = conf.Merge("app.server", map[string]any{"host": "localhost", "mode": "prod"})
+	assertTrue(t, err == nil, "the merging should be ok")
+	assertEqual(t, "prod", conf.MustString("app.server.mode"))
+
+	assertTrue(t, New(WithSchema("", []byte(`{"type":`))).Validate() != nil, "a bad schema should be reported")
+}
+
+func TestWithSchema_RejectedReload(t *testing.T) {
+	conf := New(WithSchema("app.server", []byte(testSchema)), WithWatchEnable(true))
+	defer conf.Close()
+	src := &watchableS{data: `{"app":{"server":{"host":"a","port":80,"mode":"prod"}}}`}
+	if _, err := conf.Load(context.TODO(), WithProvider(src)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	var deltas []Delta
+	conf.Watch("**", func(d Delta) { deltas = append(deltas, d) })
+
+	src.change(OpWrite, `{"app":{"server":{"host":"b","port":70000,"mode":"prod"}}}`)
+	assertEqual(t, []Delta(nil), deltas, "a rejected reload should fire nothing")
+	assertEqual(t, "a", conf.MustString("app.server.host"))
+	assertEqual(t, 80, conf.MustInt("app.server.port"))
+
+	// diffed against the last accepted source
+	src.change(OpWrite, `{"app":{"server":{"host":"a","port":81}}}`)
+	assertEqual(t, 81, conf.MustInt("app.server.port"))
+	assertEqual(t, "dev", conf.MustString("app.server.mode"), "the default is put back")
+	assertTrue(t, conf.Validate() == nil)
+}
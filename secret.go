@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gopkg.in/hedzr/errors.v3"
+)
+
+// SecretScheme is the prefix of the values which refer to a
+// secret, see WithSecretResolver.
+const SecretScheme = "secret://"
+
+// SecretResolver fetches a secret from a secret manager, such as
+// Vault, or the mounted files.
+type SecretResolver interface {
+	// Resolve returns the secret referred by ref.
+	//
+	// For a value "secret://vault/kv/db#password", the resolver
+	// registered as "vault" is called with ref "kv/db#password".
+	// See also SplitSecretRef.
+	Resolve(ctx context.Context, ref string) (value string, err error)
+}
+
+// SecretResolverFunc is a functional SecretResolver.
+type SecretResolverFunc func(ctx context.Context, ref string) (value string, err error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (value string, err error) {
+	return f(ctx, ref)
+}
+
+// SplitSecretRef splits a reference "kv/db#password" to its path
+// "kv/db" and field "password". The field is empty if no '#'.
+func SplitSecretRef(ref string) (path, field string) {
+	path, field, _ = strings.Cut(ref, "#")
+	return
+}
+
+const (
+	defaultSecretTTL     = 5 * time.Minute
+	defaultSecretTimeout = 10 * time.Second
+)
+
+// WithSecretResolver registers a resolver for the values like
+// "secret://<name>/<ref>".
+//
+// The secrets are resolved lazily, by the typed getters
+// (GetString, MustString, ...), GetM, GetR and To. The stored
+// values are kept as is, so Get returns the reference itself,
+// and Save or SaveAs never write a resolved secret back.
+//
+// The resolved secrets are cached for 5 minutes by default, and
+// fetched again on the next reading after expired. See
+// WithSecretTTL.
+//
+//	conf := store.New(store.WithSecretResolver("vault", vault.NewResolver()))
+//	conf.Set("db.password", "secret://vault/kv/db#password")
+//	pwd := conf.MustString("db.password")
+func WithSecretResolver(name string, resolver SecretResolver) Opt {
+	return func(s *storeS) {
+		s.secretsS().register(name, resolver)
+	}
+}
+
+// WithSecretTimeout sets the deadline of a resolving, 10 seconds
+// by default. A zero or negative timeout disables the deadline.
+func WithSecretTimeout(timeout time.Duration) Opt {
+	return func(s *storeS) {
+		sec := s.secretsS()
+		sec.mu.Lock()
+		defer sec.mu.Unlock()
+		sec.timeout = timeout
+	}
+}
+
+// WithSecretTTL sets how long the resolved secrets are cached.
+// A zero or negative ttl disables the expiration, the secrets
+// are cached until the store closed.
+func WithSecretTTL(ttl time.Duration) Opt {
+	return func(s *storeS) {
+		sec := s.secretsS()
+		sec.mu.Lock()
+		defer sec.mu.Unlock()
+		sec.ttl = ttl
+	}
+}
+
+func (s *storeS) secretsS() *secretsS {
+	if s.secrets == nil {
+		s.secrets = &secretsS{ttl: defaultSecretTTL, timeout: defaultSecretTimeout}
+		s.closers = append(s.closers, s.secrets)
+		s.Trie.RegisterValueResolver(SecretScheme, s.secrets.resolve)
+	}
+	return s.secrets
+}
+
+// secretsS is shared by a store and all of its prefixed views.
+type secretsS struct {
+	mu        sync.Mutex
+	resolvers map[string]SecretResolver
+	ttl       time.Duration
+	timeout   time.Duration // the deadline of a resolving, see WithSecretTimeout
+	cache     map[string]secretEntry
+}
+
+// secretEntry is a cached secret, it never expires if expires is
+// zero.
+type secretEntry struct {
+	value   string
+	expires time.Time
+}
+
+func (s *secretsS) register(name string, resolver SecretResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resolvers == nil {
+		s.resolvers = make(map[string]SecretResolver)
+	}
+	s.resolvers[name] = resolver
+}
+
+// Close drops the cached secrets.
+func (s *secretsS) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = nil
+}
+
+// resolve fetches the secret for "<name>/<ref>", the text after
+// SecretScheme.
+//
+// The resolver is called without holding the lock, so a slow
+// secret manager doesn't block the cached secrets and the other
+// resolvers.
+func (s *secretsS) resolve(text string) (value string, err error) {
+	name, ref, _ := strings.Cut(text, "/")
+	r, timeout, cached, ok := s.lookup(text, name)
+	if ok {
+		return cached, nil
+	}
+	if r == nil {
+		return "", errors.New("secret resolver %q not registered", name).WithErrors(errors.NotFound)
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if value, err = r.Resolve(ctx, ref); err != nil {
+		return
+	}
+	s.store(text, value)
+	return
+}
+
+// lookup returns the cached secret, or the resolver by name. An
+// expired secret is dropped.
+func (s *secretsS) lookup(text, name string) (r SecretResolver, timeout time.Duration, value string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, found := s.cache[text]; found {
+		if e.expires.IsZero() || time.Now().Before(e.expires) {
+			return nil, 0, e.value, true
+		}
+		delete(s.cache, text)
+	}
+	return s.resolvers[name], s.timeout, "", false
+}
+
+// store caches a resolved secret.
+func (s *secretsS) store(text, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		s.cache = make(map[string]secretEntry)
+	}
+	e := secretEntry{value: value}
+	if s.ttl > 0 {
+		e.expires = time.Now().Add(s.ttl)
+	}
+	s.cache[text] = e
+}
+
+// NewFileSecretResolver returns a resolver which reads the
+// secrets from the files under dir, such as the docker or
+// kubernetes secrets mounted at /run/secrets.
+//
+// For "secret://file/db/password", it returns the content of
+// file dir/db/password, the trailing newlines are trimmed. For
+// "secret://file/db.json#password", the file is decoded as a JSON
+// object, and its "password" field is returned.
+//
+// The files are opened in dir by [os.Root], a ref such as
+// "../etc/passwd", an absolute path, or a symlink leading out of
+// dir is rejected.
+func NewFileSecretResolver(dir string) SecretResolver {
+	return SecretResolverFunc(func(ctx context.Context, ref string) (value string, err error) {
+		path, field := SplitSecretRef(ref)
+		name := filepath.FromSlash(path)
+		if !filepath.IsLocal(name) {
+			return "", errors.New("secret %q is not a local path under the secrets dir", path).WithErrors(errors.InvalidArgument)
+		}
+		var root *os.Root
+		if root, err = os.OpenRoot(dir); err != nil {
+			return
+		}
+		defer root.Close()
+		var b []byte
+		if b, err = root.ReadFile(name); err != nil {
+			return
+		}
+		if field == "" {
+			return strings.TrimRight(string(b), "\r\n"), nil
+		}
+		var m map[string]any
+		if err = json.Unmarshal(b, &m); err != nil {
+			return
+		}
+		return secretField(m, path, field)
+	})
+}
+
+// NewExecSecretResolver returns a resolver which runs a command
+// with the given args and the ref appended, and returns its
+// output, the trailing newlines are trimmed.
+//
+//	store.WithSecretResolver("pass", store.NewExecSecretResolver("pass", "show"))
+//	// "secret://pass/db/password" runs `pass show db/password`
+//
+// The ref is rejected if it's empty, starts with '-', which could
+// be taken as an option of the command, or has control characters.
+func NewExecSecretResolver(name string, args ...string) SecretResolver {
+	return SecretResolverFunc(func(ctx context.Context, ref string) (value string, err error) {
+		if err = checkExecSecretRef(ref); err != nil {
+			return
+		}
+		var out []byte
+		cmd := exec.CommandContext(ctx, name, append(args[:len(args):len(args)], ref)...)
+		if out, err = cmd.Output(); err != nil {
+			return "", errors.New("cannot run %q for secret %q", name, ref).WithErrors(err)
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	})
+}
+
+func checkExecSecretRef(ref string) (err error) {
+	if ref == "" || strings.HasPrefix(ref, "-") || strings.ContainsFunc(ref, unicode.IsControl) {
+		return errors.New("invalid secret ref %q for the command", ref).WithErrors(errors.InvalidArgument)
+	}
+	return
+}
+
+// secretField picks a field from the decoded secret data, for
+// the resolvers.
+func secretField(m map[string]any, path, field string) (value string, err error) {
+	v, ok := m[field]
+	if !ok {
+		return "", errors.New("field %q not found in secret %q", field, path).WithErrors(errors.NotFound)
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprint(v), nil
+}
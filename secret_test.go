@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStoreS_SecretResolver(t *testing.T) {
+	var calls atomic.Int32
+	fake := SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		calls.Add(1)
+		path, field := SplitSecretRef(ref)
+		return path + "/" + field + "-" + string(rune('0'+calls.Load())), nil
+	})
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "token"), []byte("t0k3n\n"), 0600)
+	_ = os.WriteFile(filepath.Join(dir, "db.json"), []byte(`{"user":"app","port":5432}`), 0600)
+
+	conf := New(
+		WithSecretResolver("fake", fake),
+		WithSecretResolver("file", NewFileSecretResolver(dir)),
+		WithSecretTTL(100*time.Millisecond),
+	)
+	defer conf.Close()
+	conf.Set("db.password", "secret://fake/kv/db#password")
+	conf.Set("db.user", "secret://file/db.json#user")
+	conf.Set("db.port", "secret://file/db.json#port")
+	conf.Set("app.token", "secret://file/token")
+	conf.Set("app.other", "secret://nothing/x")
+
+	assertEqual(t, "kv/db/password-1", conf.MustString("db.password"))
+	assertEqual(t, "kv/db/password-1", conf.WithPrefix("db").MustString("password"), "cached")
+	assertEqual(t, int32(1), calls.Load())
+	assertEqual(t, "secret://fake/kv/db#password", conf.MustGet("db.password"), "raw value is kept")
+	assertEqual(t, "app", conf.MustString("db.user"))
+	assertEqual(t, 5432, conf.MustInt("db.port"))
+	assertEqual(t, "t0k3n", conf.MustString("app.token"))
+	_, err := conf.GetString("app.other")
+	assertTrue(t, err != nil, "expecting unregistered resolver error")
+
+	time.Sleep(300 * time.Millisecond)
+	sec := conf.(*storeS).secrets
+	_, _, _, ok := sec.lookup("fake/kv/db#password", "fake")
+	assertFalse(t, ok, "expired")
+	sec.mu.Lock()
+	_, cached := sec.cache["fake/kv/db#password"]
+	sec.mu.Unlock()
+	assertFalse(t, cached, "the expired secret is dropped")
+	assertEqual(t, "kv/db/password-2", conf.MustString("db.password"), "refreshed after ttl")
+
+	out := filepath.Join(t.TempDir(), "out.json")
+	if err = conf.SaveAs(context.TODO(), out); err != nil {
+		t.Fatalf("SaveAs failed: %v", err)
+	}
+	b, _ := os.ReadFile(out)
+	assertFalse(t, strings.Contains(string(b), "password-"), "secrets must not be saved")
+	var m map[string]any
+	_ = json.Unmarshal(b, &m)
+	assertEqual(t, "secret://fake/kv/db#password", m["db"].(map[string]any)["password"])
+}
+
+func TestNewFileSecretResolver_Escape(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "secrets")
+	_ = os.Mkdir(dir, 0700)
+	_ = os.WriteFile(filepath.Join(base, "outside"), []byte("leaked"), 0600)
+	_ = os.WriteFile(filepath.Join(dir, "token"), []byte("t0k3n"), 0600)
+	_ = os.Symlink(filepath.Join(base, "outside"), filepath.Join(dir, "link"))
+
+	r := NewFileSecretResolver(dir)
+	v, err := r.Resolve(context.TODO(), "token")
+	assertEqual(t, "t0k3n", v)
+	assertTrue(t, err == nil, "a file under dir")
+	for _, ref := range []string{"../outside", "a/../../outside", "/etc/passwd", "link"} {
+		v, err = r.Resolve(context.TODO(), ref)
+		assertTrue(t, err != nil, "expecting an error for "+ref)
+		assertEqual(t, "", v)
+	}
+}
+
+func TestNewExecSecretResolver_Ref(t *testing.T) {
+	r := NewExecSecretResolver("echo")
+	v, err := r.Resolve(context.TODO(), "db/password")
+	if err != nil {
+		t.Skip("echo is not available: ", err)
+	}
+	assertEqual(t, "db/password", v)
+	for _, ref := range []string{"", "-n", "--help", "a\nb"} {
+		_, err = r.Resolve(context.TODO(), ref)
+		assertTrue(t, err != nil, "expecting an error for "+ref)
+	}
+}
+
+func TestStoreS_SecretResolveUnlocked(t *testing.T) {
+	release := make(chan struct{})
+	slow := SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		select {
+		case <-release:
+			return "slow", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+	fast := SecretResolverFunc(func(ctx context.Context, ref string) (string, error) { return "fast", nil })
+	conf := New(
+		WithSecretResolver("slow", slow),
+		WithSecretResolver("fast", fast),
+		WithSecretTimeout(100*time.Millisecond),
+	)
+	defer conf.Close()
+	conf.Set("a", "secret://slow/a")
+	conf.Set("b", "secret://fast/b")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := conf.GetString("a")
+		assertTrue(t, err != nil, "expecting the deadline exceeded")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	assertEqual(t, "fast", conf.MustString("b"))
+	assertTrue(t, time.Since(start) < 50*time.Millisecond, "a slow resolver should not block the others")
+	<-done
+	close(release)
+}
@@ -7,17 +7,27 @@ import (
 	"sync"
 	"time"
 
+	"gopkg.in/hedzr/errors.v3"
+
 	"github.com/hedzr/store/radix"
 )
 
 func newStore(opts ...Opt) *storeS {
 	_ = os.Setenv("STORE_VERSION", Version)
 	s := &storeS{
-		Trie: radix.NewTrie[any](),
+		Trie:     radix.NewTrie[any](),
+		txMu:     &sync.Mutex{},
+		history:  newHistory(),
+		watchers: newWatchers(),
+		profiles: newProfiles(),
+		guards:   newGuards(),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.journal != nil {
+		s.openJournal()
+	}
 	return s
 }
 
@@ -38,6 +48,18 @@ func WithPrefix(prefix string) Opt {
 	}
 }
 
+// WithLiteralKeys keeps the delimiters in the map keys loaded by
+// Merge or a codec, a key "api.example.com" becomes one segment
+// instead of the nested "api", "example" and "com".
+//
+// By default a flat dotted key is split, and only the quoted or
+// escaped delimiters in a key are kept literally.
+func WithLiteralKeys(b bool) Opt {
+	return func(s *storeS) {
+		s.literalKeys = b
+	}
+}
+
 // WithOnChangeHandlers allows user's handlers can be callback once a node changed.
 func WithOnChangeHandlers(handlers ...OnChangeHandler) Opt {
 	return func(s *storeS) {
@@ -59,6 +81,27 @@ func WithOnDeleteHandlers(handlers ...OnDeleteHandler) Opt {
 	}
 }
 
+// WithInterpolation enables the value interpolation, such as
+// ${app.home}/logs, for the typed getters (GetString,
+// MustInt, ...), GetM, GetR and To.
+//
+// The stored values are kept unexpanded, so SaveAs writes back
+// the original templates. See [radix.Trie.SetInterpolation] for
+// the syntax.
+func WithInterpolation(b bool) Opt {
+	return func(s *storeS) {
+		s.Trie.SetInterpolation(b)
+	}
+}
+
+// WithResolver adds a resolver for the references like
+// ${scheme:name}, see WithInterpolation.
+func WithResolver(scheme string, resolver radix.Resolver) Opt {
+	return func(s *storeS) {
+		s.Trie.RegisterResolver(scheme, resolver)
+	}
+}
+
 // WithFlattenSlice sets a bool flag to tell Store the slice value should be
 // treated as node leaf. The index of the slice would be part of node path.
 // For example, you're loading a slice []string{"A","B"} into node path
@@ -100,6 +143,7 @@ type storeS struct {
 	onChangeHandlers []OnChangeHandler
 	onNewHandlers    []OnNewHandler
 	OnDeleteHandlers []OnDeleteHandler
+	onCommitHandlers []OnCommitHandler
 
 	// The following members need to Dup, WithPrefix, and
 	// WithPrefixReplaced.
@@ -109,6 +153,32 @@ type storeS struct {
 
 	flattenSlice bool
 	allowWatch   bool
+	literalKeys  bool // see WithLiteralKeys
+
+	origins *originsS // shared with prefixed views, see WithOriginTracking
+	origin  *Origin   // the source of the writings via this view, nil for the runtime writings
+
+	txMu         *sync.Mutex // serializes Tx, shared with prefixed views
+	txValidators []TxValidator
+
+	history *historyS // shared with prefixed views, see WithHistory
+
+	schemas      []*schemaEntry // see WithSchema
+	schemaStrict bool
+
+	secrets *secretsS // shared with prefixed views, see WithSecretResolver
+
+	watchers *watchersS // shared with prefixed views, see Watch
+
+	profiles *profilesS // shared with prefixed views, see ActivateProfiles
+
+	journal *journalS // shared with prefixed views, see WithJournal
+
+	guards       *guardsS // shared with prefixed views, see Freeze
+	readOnly     bool     // see ReadOnly
+	fromProvider bool     // updating by a watching provider, see providerView
+
+	merge mergeS // for loading and merging, see WithMergeStrategy and MergeOpt
 }
 
 func (s *storeS) String() string {
@@ -140,7 +210,23 @@ func (s *storeS) dupS(trie radix.Trie[any]) (newStore *storeS) {
 		Trie:         trie,
 		flattenSlice: s.flattenSlice,
 		allowWatch:   s.allowWatch,
+		literalKeys:  s.literalKeys,
 		loading:      s.loading,
+		origins:      s.origins,
+		origin:       s.origin,
+		txMu:         s.txMu,
+		txValidators: s.txValidators,
+		history:      s.history,
+		schemas:      s.schemas,
+		schemaStrict: s.schemaStrict,
+		secrets:      s.secrets,
+		watchers:     s.watchers,
+		profiles:     s.profiles,
+		journal:      s.journal,
+		guards:       s.guards,
+		readOnly:     s.readOnly,
+		fromProvider: s.fromProvider,
+		merge:        s.merge,
 		// don't dup the member 'parent' here
 	}
 	return
@@ -244,6 +330,7 @@ func (s *storeS) Get(path string) (data any, found bool) {
 
 // Set sets key('path') and value pair into storeS.
 func (s *storeS) Set(path string, data any) (node radix.Node[any], oldData any) {
+	path = radix.NormalizePath(path, s.Delimiter())
 	old, branch, found, err := s.Trie.Query(path, nil)
 	if !found {
 		if err != nil || !branch {
@@ -254,6 +341,13 @@ func (s *storeS) Set(path string, data any) (node radix.Node[any], oldData any)
 		oldData = old
 	}
 
+	if len(s.schemas) > 0 {
+		if err = s.checkSchema(s.join(s.Prefix(), path), data, true); err != nil && s.schemaStrict {
+			s.logRejected(err)
+			return
+		}
+	}
+
 	node, oldData = s.setKV(path, data, !found, nil)
 	// s.tryOnSet(path, false, old, data)
 	return
@@ -282,12 +376,25 @@ func (s *storeS) Set(path string, data any) (node radix.Node[any], oldData any)
 //
 // The returned `state`: 0 assumed no error.
 func (s *storeS) SetTTL(path string, ttl time.Duration, cb radix.OnTTLRinging[any]) (state int) {
+	if !s.writable("settl", path, false) {
+		return -1
+	}
 	state = s.Trie.SetTTL(path, ttl, cb)
 	return
 }
 
 // Merge a map at path point 'pathAt'.
-func (s *storeS) Merge(pathAt string, data map[string]any) (err error) {
+//
+// By default, the leaves and slices are overwritten, and the maps
+// are merged key by key. The semantics can be changed by the
+// options, such as WithMergeAppend, WithMergeUnionByKey and
+// WithMergeDeleteOnNull, for the environment overlays:
+//
+//	err := conf.Merge("app", overlay, store.WithMergeUnionByKey(), store.WithMergeDeleteOnNull())
+//
+// The slice options work on the slices stored as leaves, they
+// don't apply to the slices flattened by WithFlattenSlice.
+func (s *storeS) Merge(pathAt string, data map[string]any, opts ...MergeOpt) (err error) {
 	// _, _, _, err = s.Trie.Query(pathAt)
 	// // if !found {
 	// // 	if err1 != nil || !branch {
@@ -298,13 +405,44 @@ func (s *storeS) Merge(pathAt string, data map[string]any) (err error) {
 	// 	return
 	// }
 
-	err = s.loadMap(data, pathAt, false, nil)
+	pathAt = radix.NormalizePath(pathAt, s.Delimiter())
+	if err = s.checkMergeable(pathAt, data); err != nil {
+		return
+	}
+	if len(s.schemas) > 0 {
+		if err = s.checkSchema(s.join(s.Prefix(), pathAt), data, true); err != nil && s.schemaStrict {
+			return
+		}
+	}
+
+	set := s
+	if len(opts) > 0 {
+		set = s.dupS(s.Trie)
+		for _, opt := range opts {
+			opt(&set.merge)
+		}
+		if set.merge.strategy == MergeReplaceSubtree {
+			for k := range data {
+				if err = s.checkWritable("merge", s.join(pathAt, s.mapKey(k)), true); err != nil {
+					return
+				}
+			}
+			for k := range data {
+				set.Remove(s.join(pathAt, s.mapKey(k)))
+			}
+		}
+	}
+
+	err = set.loadMap(data, pathAt, false, nil)
 	// s.tryOnSet(pathAt, true, old, data)
 	return
 }
 
 // Update a node whether it existed or not.
 func (s *storeS) Update(path string, cb func(node radix.Node[any], old any)) {
+	if !s.writable("update", path, false) {
+		return
+	}
 	s.Trie.Update(path, cb)
 }
 
@@ -327,21 +465,47 @@ func (s *storeS) Update(path string, cb func(node radix.Node[any], old any)) {
 // }
 
 func (s *storeS) setKV(path string, data any, createOrModify bool, onSet lmOnSet) (node radix.Node[any], oldData any) {
+	path = radix.NormalizePath(path, s.Delimiter())
+	if !s.writable("set", path, false) {
+		return
+	}
 	node, oldData = s.Trie.Set(path, data)
 	loading := s.inLoading()
 	user := !loading
+	if s.origins != nil && node != nil {
+		s.origins.record(node.Key(), s.writer())
+	}
 	if user {
 		if oldData != nil {
 			createOrModify = false // set it to is-modifying instead of is-creating
 		}
 		if node != nil {
-			if onSet != nil {
-				onSet(node)
+			if n, ok := s.Trie.WithPrefixReplaced().Modify(node.Key(), func(nd radix.Node[any]) {
+				if onSet != nil {
+					onSet(nd)
+				}
+				nd.SetModified(true)
+			}); ok {
+				node = n
 			}
-			node.SetModified(true)
 		}
 	}
 	s.tryOnSet(path, user, oldData, data, createOrModify)
+	if node != nil {
+		op := OpWrite
+		if createOrModify && oldData == nil {
+			op = OpCreate
+		}
+		key := node.Key()
+		if radix.IsIndexPath(path, s.Delimiter()) {
+			key = s.join(s.Prefix(), path) // the element, not the slice holding it
+		}
+		d := Delta{Op: op, Path: key, OldValue: oldData, NewValue: data}
+		if s.journaling() {
+			s.journalDelta(d)
+		}
+		s.watchers.fire(d, s.Delimiter())
+	}
 	return
 }
 
@@ -390,22 +554,46 @@ retryPD:
 }
 
 func (s *storeS) Remove(path string) (removed bool) {
+	path = radix.NormalizePath(path, s.Delimiter())
+	if !s.writable("remove", path, true) {
+		return
+	}
 	var rmn, np radix.Node[any]
 	rmn, np, removed = s.Trie.RemoveEx(path)
 	if removed {
+		if s.origins != nil {
+			s.origins.forget(rmn.Key(), s.Delimiter())
+		}
 		loading := s.inLoading()
 		data := rmn.Data()
 		s.tryOnDelete(path, !loading, data, rmn, np)
+		d := Delta{Op: OpRemove, Path: rmn.Key(), OldValue: data}
+		if s.journaling() {
+			s.journalDelta(d)
+		}
+		s.watchers.fire(d, s.Delimiter())
 	}
 	return
 }
 
 func (s *storeS) RemoveEx(path string) (nodeRemoved, nodeParent radix.Node[any], removed bool) {
+	path = radix.NormalizePath(path, s.Delimiter())
+	if !s.writable("remove", path, true) {
+		return
+	}
 	nodeRemoved, nodeParent, removed = s.Trie.RemoveEx(path)
 	if removed {
+		if s.origins != nil {
+			s.origins.forget(nodeRemoved.Key(), s.Delimiter())
+		}
 		loading := s.inLoading()
 		data := nodeRemoved.Data()
 		s.tryOnDelete(path, !loading, data, nodeRemoved, nodeParent)
+		d := Delta{Op: OpRemove, Path: nodeRemoved.Key(), OldValue: data}
+		if s.journaling() {
+			s.journalDelta(d)
+		}
+		s.watchers.fire(d, s.Delimiter())
 	}
 	return
 }
@@ -437,7 +625,15 @@ func (s *storeS) Clone() (newStore Store) { return s.Dup() } // make a clone for
 //
 // At this scene, the parent store still holds the cleanup closers.
 func (s *storeS) Dup() (newStore Store) {
-	return s.dupS(s.Trie.Dup())
+	ns := s.dupS(s.Trie.Dup())
+	ns.origins = s.origins.dup()
+	ns.txMu = &sync.Mutex{}
+	ns.history = newHistory()
+	ns.watchers = newWatchers()
+	ns.profiles = newProfiles()
+	ns.guards = newGuards()
+	ns.journal = nil
+	return ns
 }
 
 // WithPrefix makes a lightweight copy from current storeS.
@@ -482,6 +678,41 @@ func (s *storeS) SetPrefix(newPrefix ...string) {
 	s.Trie.SetPrefix(newPrefix...)
 }
 
+// ChangeDelimiter re-keys the whole store with a new delimiter,
+// see [radix.Trie.ChangeDelimiter]. The origins are re-keyed too.
+//
+//	err := conf.ChangeDelimiter('/')
+//	println(conf.MustString("app/logging/file"))
+//
+// It's for the root store only, a prefixed or read-only view, or
+// a frozen subtree in the store, rejects it. The snapshots in the
+// history keep the old keys.
+//
+// The journal, see WithJournal, is re-keyed and compacted into
+// its snapshot at once, so the store should be created with
+// WithDelimiter(delimiter) on the next start.
+func (s *storeS) ChangeDelimiter(delimiter rune) (err error) {
+	from := s.Delimiter()
+	if delimiter == from {
+		return
+	}
+	if err = s.checkWritable("change delimiter", "", true); err != nil {
+		return
+	}
+	if err = s.Trie.ChangeDelimiter(delimiter); err != nil {
+		return
+	}
+	if s.origins != nil {
+		s.origins.rekey(from, delimiter)
+	}
+	if s.journal != nil {
+		if err = s.journal.rekey(from, delimiter); err != nil {
+			err = errors.New("cannot compact journal").WithErrors(err)
+		}
+	}
+	return
+}
+
 func (s *storeS) N() (newStore Store)               { return s.dupS(s.Trie.N()) }
 func (s *storeS) R() (newStore Store)               { return s.dupS(s.Trie.R()) }
 func (s *storeS) BR() (newStore Store)              { return s.dupS(s.Trie.BR()) }
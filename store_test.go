@@ -608,6 +608,23 @@ func TestStoreS_GetSectionFrom(t *testing.T) {
 
 //
 
+func TestStoreS_Iterators(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.server.port", 80)
+	conf.Set("app.server.host", "a")
+	conf.Set("app.debug", true)
+
+	ns := conf.WithPrefix("app")
+	var keys []string
+	for key, val := range ns.Leaves("server") {
+		keys = append(keys, key)
+		ns.Set("server.tls", val != nil) // not observed
+	}
+	assertEqual(t, []string{"app.server.host", "app.server.port"}, keys)
+	assertEqual(t, map[string]any{"server.host": "a", "server.port": 80, "server.tls": true}, collectLeaves(ns, "server"))
+}
+
 func assertEqual(t testing.TB, expect, actual any, msg ...any) { //nolint:govet //it's a printf/println dual interface
 	if reflect.DeepEqual(expect, actual) {
 		return
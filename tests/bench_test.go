@@ -95,6 +95,68 @@ func BenchmarkTrieGetLong(b *testing.B) { //nolint:revive
 	// })
 }
 
+// BenchmarkTrieMustGetters measures the hot path of the typed
+// getters, with and without a concurrent writer.
+func BenchmarkTrieMustGetters(b *testing.B) { //nolint:revive
+	conf := newStore()
+
+	b.Run("MustString", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = conf.MustString("app.logging.file")
+			}
+		})
+	})
+	b.Run("MustInt", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = conf.MustInt("app.server.start")
+			}
+		})
+	})
+	b.Run("MustString/LongKey", func(b *testing.B) {
+		const key = "app.logging.targets.remote-syslog.endpoint"
+		conf.Set(key, "udp://localhost:514")
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = conf.MustString(key)
+			}
+		})
+	})
+	b.Run("MustInt/WithPrefix", func(b *testing.B) {
+		ns := conf.WithPrefix("app.logging")
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = ns.MustInt("rotate")
+			}
+		})
+	})
+	b.Run("MustInt/Writing", func(b *testing.B) {
+		done := make(chan struct{})
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+					conf.Set("app.dump", i)
+				}
+			}
+		}()
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = conf.MustInt("app.server.start")
+			}
+		})
+		close(done)
+	})
+}
+
 func TestStoreDump(t *testing.T) {
 	conf := newStore()
 	t.Logf("\nPath:\n%v\n\n", conf.Dump())
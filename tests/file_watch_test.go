@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hedzr/store"
+	"github.com/hedzr/store/codecs/json"
+	"github.com/hedzr/store/providers/file"
+)
+
+func TestStore_FileWatch_Reload(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "app.json")
+	assert.NoError(t, os.WriteFile(cfg, []byte(`{"server":{"port":7999,"host":"a"}}`), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := store.New(store.WithWatchEnable(true))
+	defer s.Close()
+	_, err := s.Load(ctx,
+		store.WithStorePrefix("app"),
+		store.WithCodec(json.New()),
+		store.WithProvider(file.New(cfg, file.WithWatchEnabled(true))),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 7999, s.MustInt("app.server.port"))
+
+	ch := s.Events(ctx, store.WithEventsWindow(50*time.Millisecond))
+	assert.NoError(t, os.WriteFile(cfg, []byte(`{"server":{"port":8080,"tls":true}}`), 0600))
+
+	select {
+	case batch := <-ch:
+		assert.Equal(t, []string{"app.server.host", "app.server.port", "app.server.tls"}, paths(batch.Changes))
+	case <-time.After(3 * time.Second):
+		t.Fatal("no change event received")
+	}
+	assert.Equal(t, 8080, s.MustInt("app.server.port"))
+	assert.False(t, s.Has("app.server.host"))
+	assert.False(t, s.Has(cfg), "the file name must not be written into the tree")
+}
+
+func TestStore_FileWatch_InPlaceSave(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "app.json")
+	assert.NoError(t, os.WriteFile(cfg, []byte(`{"server":{"port":7999}}`), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := store.New(store.WithWatchEnable(true))
+	defer s.Close()
+	_, err := s.Load(ctx,
+		store.WithStorePrefix("app"),
+		store.WithCodec(json.New()),
+		store.WithProvider(file.New(cfg, file.WithWatchEnabled(true))),
+	)
+	assert.NoError(t, err)
+
+	// truncated first, and written a moment later, like os.WriteFile
+	f, err := os.OpenFile(cfg, os.O_WRONLY|os.O_TRUNC, 0600)
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = f.WriteString(`{"server":{"port":8080}}`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	assert.Eventually(t, func() bool { return s.MustInt("app.server.port") == 8080 },
+		3*time.Second, 10*time.Millisecond, "the completed file should be reloaded")
+}
+
+func paths(deltas []store.Delta) (ret []string) {
+	for _, d := range deltas {
+		ret = append(ret, d.Path)
+	}
+	return
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"gopkg.in/hedzr/errors.v3"
+
+	"github.com/hedzr/store/radix"
+)
+
+// OnCommitHandler is called back once a transaction committed,
+// with the whole change set. See [Store.Tx].
+type OnCommitHandler func(changes []Delta)
+
+// TxValidator checks the staged store before a transaction
+// committing. Returning an error rolls back the transaction.
+//
+// Both 'tx' and the paths in 'changes' are not prefixed, even if
+// Tx was invoked on a prefixed view.
+type TxValidator func(tx Store, changes []Delta) (err error)
+
+func (*OnCommitHandler) GobDecode([]byte) error    { return nil }
+func (OnCommitHandler) GobEncode() ([]byte, error) { return nil, nil }
+
+// WithOnCommitHandlers allows user's handlers can be callback
+// once a transaction committed.
+func WithOnCommitHandlers(handlers ...OnCommitHandler) Opt {
+	return func(s *storeS) {
+		s.onCommitHandlers = append(s.onCommitHandlers, handlers...)
+	}
+}
+
+// WithTxValidators adds validation hooks which will be invoked
+// before a transaction committing.
+func WithTxValidators(validators ...TxValidator) Opt {
+	return func(s *storeS) {
+		s.txValidators = append(s.txValidators, validators...)
+	}
+}
+
+// ErrTxEmpty is returned by Tx when a nil functor given.
+var ErrTxEmpty = errors.New("empty transaction")
+
+// Tx runs fn in a transaction.
+//
+// The writings in fn are staged in a fork of the store, which
+// shares the unchanged subtrees with it, so nobody can see a
+// half-updated config. The fork is passed to fn as 'tx', reading
+// from 'tx' gives you the staged values.
+//
+// If fn returns nil and all validators (see WithTxValidators)
+// passed, the changes made by fn, which are the differences
+// between the store at the beginning and 'tx', are published
+// into the store at once. The keys written by others meanwhile
+// are kept unless fn changed them too.
+// And then, OnNewHandler, OnChangeHandler and OnDeleteHandler
+// are called back for each changed key, and OnCommitHandler is
+// called back once with the whole change set. The paths passed
+// to the handlers are full paths, the prefix of the store is
+// not stripped.
+//
+// If fn or a validator returns an error, the staged changes are
+// discarded, and the error will be returned.
+//
+//	err := conf.Tx(func(tx store.Store) error {
+//	    tx.Set("db.host", "10.0.0.2")
+//	    tx.Set("db.port", 5433)
+//	    return nil
+//	})
+//
+// Only the values are transactional, the comments and tags set
+// in fn are discarded, set them by 'tx' rather than the nodes.
+// The transactions on a store (and its prefixed views) are
+// serialized.
+func (s *storeS) Tx(fn func(tx Store) error) (err error) {
+	if fn == nil {
+		return ErrTxEmpty
+	}
+	if s.readOnly {
+		return &ReadOnlyError{Op: "tx", Path: s.Prefix()}
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	base := s.Trie.Fork()
+	tx := s.dupS(base.Fork())
+	tx.watchers = nil // fire after committed, see notify
+	tx.journal = nil  // journal after committed, see notify
+	tx.origins = nil  // record after committed, see commit
+	if err = fn(tx); err != nil {
+		return
+	}
+
+	baseRoot := s.dupS(base.WithPrefixReplaced())
+	txRoot := tx.dupS(tx.Trie.WithPrefixReplaced())
+	changes := Diff(baseRoot, txRoot, s.Prefix())
+	if len(changes) == 0 {
+		return
+	}
+	for _, v := range s.txValidators {
+		if err = v(txRoot, changes); err != nil {
+			return
+		}
+	}
+
+	changes = s.commit(changes)
+	s.notify(changes)
+	return
+}
+
+// commit applies the changes to the current root, and publishes
+// it at once. The old values are updated to the current ones.
+func (s *storeS) commit(changes []Delta) []Delta {
+	_ = s.Trie.Batch(func(root radix.Trie[any]) (err error) {
+		for i, d := range changes {
+			switch d.Op {
+			case OpCreate, OpWrite:
+				node, old := root.Set(d.Path, d.NewValue)
+				if node != nil {
+					if s.origins != nil {
+						s.origins.record(node.Key(), s.writer())
+					}
+					node.SetModified(true)
+				}
+				changes[i].OldValue = old
+			case OpRemove:
+				if rmn, _, removed := root.RemoveEx(d.Path); removed {
+					changes[i].OldValue = rmn.Data()
+					if s.origins != nil {
+						s.origins.forget(rmn.Key(), s.Delimiter())
+					}
+				}
+			}
+		}
+		return
+	})
+	return changes
+}
+
+// notify fires the handlers for the changes which have been
+// written.
+func (s *storeS) notify(changes []Delta) {
+	for _, d := range changes {
+		switch d.Op {
+		case OpCreate, OpWrite:
+			s.tryOnSet(d.Path, true, d.OldValue, d.NewValue, d.Op == OpCreate)
+		case OpRemove:
+			s.tryOnDelete(d.Path, true, d.OldValue, nil, nil)
+		}
+		if s.journal != nil {
+			s.journalDelta(d)
+		}
+		s.watchers.fire(d, s.Delimiter())
+	}
+	for ptr := s; ptr != nil; ptr = ptr.parent {
+		for _, cb := range ptr.onCommitHandlers {
+			if cb != nil {
+				cb(changes)
+			}
+		}
+	}
+}
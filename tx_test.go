@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStoreS_Tx(t *testing.T) {
+	var events []string
+	var batches [][]Delta
+	conf := newBasicStore(
+		WithOnChangeHandlers(func(path string, value, oldValue any, mergingMapOrLoading bool) {
+			events = append(events, "change "+path)
+		}),
+		WithOnNewHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "new "+path)
+		}),
+		WithOnDeleteHandlers(func(path string, value any, mergingMapOrLoading bool) {
+			events = append(events, "delete "+path)
+		}),
+		WithOnCommitHandlers(func(changes []Delta) {
+			batches = append(batches, changes)
+		}),
+	)
+	defer conf.Close()
+	events = nil
+
+	err := conf.Tx(func(tx Store) error {
+		tx.Set("app.server.start", 6)
+		tx.Set("app.server.port", 7999)
+		tx.Remove("app.debug")
+		assertEqual(t, 0, len(events), "handlers should not be fired before committing")
+		assertEqual(t, 5, conf.MustInt("app.server.start"), "staged value is invisible")
+		assertEqual(t, 6, tx.MustInt("app.server.start"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Tx failed: %v", err)
+	}
+	assertEqual(t, 6, conf.MustInt("app.server.start"))
+	assertEqual(t, 7999, conf.MustInt("app.server.port"))
+	assertFalse(t, conf.Has("app.debug"))
+	assertEqual(t, []string{"delete app.debug", "new app.server.port", "change app.server.start"}, events)
+	assertEqual(t, 1, len(batches))
+	assertEqual(t, 3, len(batches[0]))
+
+	t.Run("rollback", func(t *testing.T) {
+		events, batches = nil, nil
+		errBoom := errors.New("boom")
+		err := conf.Tx(func(tx Store) error {
+			tx.Set("app.server.start", 7)
+			return errBoom
+		})
+		assertTrue(t, errors.Is(err, errBoom), "expecting the error of fn")
+		assertEqual(t, 6, conf.MustInt("app.server.start"))
+		assertEqual(t, 0, len(events))
+		assertEqual(t, 0, len(batches))
+	})
+}
+
+func TestStoreS_TxValidator(t *testing.T) {
+	errBadPort := errors.New("bad port")
+	conf := newBasicStore(WithTxValidators(func(tx Store, changes []Delta) error {
+		if tx.MustInt("app.server.port", 80) < 1024 {
+			return errBadPort
+		}
+		return nil
+	}))
+	defer conf.Close()
+
+	err := conf.WithPrefix("app").Tx(func(tx Store) error {
+		tx.Set("server.port", 80)
+		tx.Set("server.host", "0.0.0.0")
+		return nil
+	})
+	assertTrue(t, errors.Is(err, errBadPort), "expecting the error of validator")
+	assertFalse(t, conf.Has("app.server.port"))
+	assertFalse(t, conf.Has("app.server.host"))
+
+	err = conf.WithPrefix("app").Tx(func(tx Store) error {
+		tx.Set("server.port", 8080)
+		return nil
+	})
+	assertTrue(t, err == nil, "tx should be committed")
+	assertEqual(t, 8080, conf.WithPrefix("app").MustInt("server.port"))
+	assertEqual(t, 8080, conf.MustInt("app.server.port"))
+}
+
+func TestStoreS_TxConcurrentWriters(t *testing.T) {
+	conf := newBasicStore()
+	defer conf.Close()
+
+	err := conf.Tx(func(tx Store) error {
+		tx.Set("app.server.start", 6)
+		conf.Set("app.logging.rotate", 7) // a writer out of the transaction
+		return nil
+	})
+	assertTrue(t, err == nil, err)
+	assertEqual(t, 6, conf.MustInt("app.server.start"))
+	assertEqual(t, 7, conf.MustInt("app.logging.rotate"), "a concurrent writing is kept")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			_ = conf.Tx(func(tx Store) error {
+				tx.Set("app.a", i)
+				tx.Set("app.b", i)
+				return nil
+			})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			assertEqual(t, 199, conf.MustInt("app.b"))
+			return
+		default:
+			_, snap := conf.Snapshot()
+			a, _ := snap.Get("app.a")
+			b, _ := snap.Get("app.b")
+			assertEqual(t, a, b, "a transaction is published at once")
+		}
+	}
+}
+
+func TestStoreS_TxOrigins(t *testing.T) {
+	errBoom := errors.New("boom")
+	conf := New(WithOriginTracking(true), WithTxValidators(func(tx Store, changes []Delta) error {
+		if tx.Has("a.bad") {
+			return errBoom
+		}
+		return nil
+	}))
+	defer conf.Close()
+	if _, err := conf.Load(context.TODO(), WithProvider(newMapPvdr("defaults", map[string]any{"a.b": 1}))); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	explain := conf.Explain()
+
+	err := conf.Tx(func(tx Store) error {
+		tx.Remove("a.b")
+		tx.Set("a.c", 2)
+		return errBoom
+	})
+	assertTrue(t, errors.Is(err, errBoom), "expecting the error of fn")
+	err = conf.Tx(func(tx Store) error {
+		tx.Set("a.b", 3)
+		tx.Set("a.bad", 1)
+		return nil
+	})
+	assertTrue(t, errors.Is(err, errBoom), "expecting the error of validator")
+	assertEqual(t, 1, len(conf.Origin("a.b")), "a failed tx should not touch the origins")
+	assertEqual(t, "defaults", conf.Origin("a.b")[0].Provider)
+	assertEqual(t, 0, len(conf.Origin("a.c")))
+	assertEqual(t, explain, conf.Explain())
+
+	err = conf.Tx(func(tx Store) error {
+		tx.Set("a.b", 3)
+		return nil
+	})
+	assertTrue(t, err == nil, "tx should be committed")
+	chain := conf.Origin("a.b")
+	assertEqual(t, 2, len(chain))
+	assertTrue(t, chain[1].IsRuntime(), "recorded by commit")
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hedzr/store/radix"
+)
+
+// WatchFunc is called back for a change matched by the pattern of
+// [Store.Watch]. d.Path is the full path of the changed key.
+type WatchFunc func(d Delta)
+
+// Watch subscribes the changes of the keys matching pattern, and
+// returns a function to unsubscribe.
+//
+// A pattern is a dotted path (delimited by the store's
+// delimiter), in which '*' matches any one segment, and '**'
+// matches zero or more segments. For example:
+//
+//	app.server.port       the key itself
+//	app.server.*          app.server.port, app.server.host, ...
+//	app.**.timeout        app.timeout, app.db.timeout, app.a.b.timeout, ...
+//	app.**                app and all keys under it
+//
+// On a prefixed view, pattern is relative to the prefix, but the
+// paths passed to fn are full paths.
+//
+// fn is called for the changes made by Set, Merge, Remove, Move,
+// Copy, Rename, Tx, Rollback and the provider watching events. A
+// moved key is reported as one OpRename, which matches the
+// pattern by its old or new path. It's invoked in the goroutine
+// which made the change, so don't block it long.
+//
+//	unsubscribe := conf.Watch("app.server.*", func(d store.Delta) {
+//	    log.Printf("%v %s: %v -> %v", d.Op, d.Path, d.OldValue, d.NewValue)
+//	})
+//	defer unsubscribe()
+func (s *storeS) Watch(pattern string, fn WatchFunc) (unsubscribe func()) {
+	if s.watchers == nil || fn == nil {
+		return func() {}
+	}
+	parts := splitPath(s.join(s.Prefix(), pattern), s.Delimiter())
+	id := s.watchers.add(parts, fn)
+	var once sync.Once
+	return func() { once.Do(func() { s.watchers.remove(parts, id) }) }
+}
+
+// watchersS keeps the patterns of Watch in a trie of segments, so
+// a path can be matched against all patterns in one pass. It is
+// shared by a store and all of its prefixed views.
+type watchersS struct {
+	mu    sync.RWMutex
+	root  watchNodeS
+	seq   uint64
+	count atomic.Int32
+}
+
+type watchNodeS struct {
+	children map[string]*watchNodeS
+	subs     map[uint64]WatchFunc // the subscribers whose pattern ends here
+}
+
+func newWatchers() *watchersS { return &watchersS{} }
+
+func (s *watchersS) add(parts []string, fn WatchFunc) (id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := &s.root
+	for _, p := range parts {
+		if n.children == nil {
+			n.children = make(map[string]*watchNodeS)
+		}
+		c := n.children[p]
+		if c == nil {
+			c = &watchNodeS{}
+			n.children[p] = c
+		}
+		n = c
+	}
+	if n.subs == nil {
+		n.subs = make(map[uint64]WatchFunc)
+	}
+	s.seq++
+	id = s.seq
+	n.subs[id] = fn
+	s.count.Add(1)
+	return
+}
+
+func (s *watchersS) remove(parts []string, id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.root.remove(parts, id) {
+		s.count.Add(-1)
+	}
+}
+
+// remove drops the subscriber and prunes the empty nodes.
+func (n *watchNodeS) remove(parts []string, id uint64) (removed bool) {
+	if len(parts) == 0 {
+		if _, removed = n.subs[id]; removed {
+			delete(n.subs, id)
+		}
+		return
+	}
+	if c := n.children[parts[0]]; c != nil {
+		if removed = c.remove(parts[1:], id); removed && len(c.subs) == 0 && len(c.children) == 0 {
+			delete(n.children, parts[0])
+		}
+	}
+	return
+}
+
+func (n *watchNodeS) match(parts []string, hits map[uint64]WatchFunc) {
+	if len(parts) == 0 {
+		for id, fn := range n.subs {
+			hits[id] = fn
+		}
+	} else {
+		if c := n.children[parts[0]]; c != nil {
+			c.match(parts[1:], hits)
+		}
+		if c := n.children["*"]; c != nil {
+			c.match(parts[1:], hits)
+		}
+	}
+	if c := n.children["**"]; c != nil {
+		for i := 0; i <= len(parts); i++ {
+			c.match(parts[i:], hits)
+		}
+	}
+}
+
+// fire calls back the subscribers matching d.Path, a full path.
+// For OpRename, the ones matching d.OldPath are called back too.
+func (s *watchersS) fire(d Delta, delimiter rune) {
+	if s == nil || s.count.Load() == 0 {
+		return
+	}
+	hits := make(map[uint64]WatchFunc)
+	s.mu.RLock()
+	s.root.match(splitPath(d.Path, delimiter), hits)
+	if d.Op == OpRename {
+		s.root.match(splitPath(d.OldPath, delimiter), hits)
+	}
+	s.mu.RUnlock()
+	ids := make([]uint64, 0, len(hits))
+	for id := range hits {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids) // in subscribing order
+	for _, id := range ids {
+		hits[id](d)
+	}
+}
+
+func splitPath(path string, delimiter rune) []string {
+	if path == "" {
+		return nil
+	}
+	return radix.SplitPath(path, delimiter)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// changeS is a Change for the tests, it emits the pairs in order.
+type changeS struct {
+	op    Op
+	pairs [][2]any
+	idx   int
+}
+
+func (s *changeS) Next() (key string, val any, ok bool) {
+	if s.idx < len(s.pairs) {
+		key, val, ok = s.pairs[s.idx][0].(string), s.pairs[s.idx][1], true
+		s.idx++
+	}
+	return
+}
+func (s *changeS) Path() string         { return "" }
+func (s *changeS) Op() Op               { return s.op }
+func (s *changeS) Has(op Op) bool       { return s.op&op != 0 }
+func (s *changeS) Timestamp() time.Time { return time.Now() }
+func (s *changeS) Provider() Provider   { return nil }
+
+func TestStoreS_Watch(t *testing.T) {
+	conf := New()
+	defer conf.Close()
+	conf.Set("app.server.port", 7999)
+
+	var server, timeouts, exact []Delta
+	unsub := conf.Watch("app.server.*", func(d Delta) { server = append(server, d) })
+	conf.Watch("app.**.timeout", func(d Delta) { timeouts = append(timeouts, d) })
+	conf.WithPrefix("app").Watch("server.port", func(d Delta) { exact = append(exact, d) })
+
+	conf.Set("app.server.port", 8080)
+	conf.Set("app.server.host", "localhost")
+	conf.Set("app.timeout", 5)
+	conf.WithPrefix("app").Set("db.conn.timeout", 3)
+	conf.Set("app.db.host", "db")
+	_ = conf.Merge("app.server", map[string]any{"port": 9090, "tls": map[string]any{"timeout": 1}})
+	conf.Remove("app.server.host")
+	_ = conf.Tx(func(tx Store) error {
+		tx.Set("app.server.port", 9091)
+		return nil
+	})
+	conf.(*storeS).applyChanges(&changeS{op: OpWrite, pairs: [][2]any{{"app.server.port", 9092}, {"app.cache.timeout", 9}}})
+
+	assertEqual(t, []Delta{
+		{Op: OpWrite, Path: "app.server.port", OldValue: 7999, NewValue: 8080},
+		{Op: OpCreate, Path: "app.server.host", NewValue: "localhost"},
+		{Op: OpWrite, Path: "app.server.port", OldValue: 8080, NewValue: 9090},
+		{Op: OpRemove, Path: "app.server.host", OldValue: "localhost"},
+		{Op: OpWrite, Path: "app.server.port", OldValue: 9090, NewValue: 9091},
+		{Op: OpWrite, Path: "app.server.port", OldValue: 9091, NewValue: 9092},
+	}, server)
+	assertEqual(t, []string{"app.timeout", "app.db.conn.timeout", "app.server.tls.timeout", "app.cache.timeout"}, deltaPaths(timeouts))
+	assertEqual(t, 4, len(exact), "relative pattern on a prefixed view")
+
+	unsub()
+	unsub()
+	conf.Set("app.server.port", 1)
+	assertEqual(t, 6, len(server), "unsubscribed")
+	assertEqual(t, 5, len(exact))
+}
+
+func TestWatchersS_Match(t *testing.T) {
+	w := newWatchers()
+	hit := map[string]int{}
+	for _, p := range []string{"a.b", "a.*", "a.**", "**.c", "a.**.c", "*.*.*"} {
+		w.add(splitPath(p, '.'), func(d Delta) { hit[p]++ })
+	}
+	for _, path := range []string{"a", "a.b", "a.b.c", "x.c", "a.c"} {
+		w.fire(Delta{Path: path}, '.')
+	}
+	assertEqual(t, map[string]int{"a.b": 1, "a.*": 2, "a.**": 4, "**.c": 3, "a.**.c": 2, "*.*.*": 1}, hit)
+}
+
+func deltaPaths(deltas []Delta) (paths []string) {
+	for _, d := range deltas {
+		paths = append(paths, d.Path)
+	}
+	return
+}